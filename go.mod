@@ -6,6 +6,7 @@ require (
 	github.com/bmatcuk/doublestar/v4 v4.7.1
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/sirupsen/logrus v1.4.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
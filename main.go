@@ -7,6 +7,8 @@ package main
 import (
 	"context"
 	"github.com/harness-community/drone-coverage-report/plugin"
+	"os"
+	"path/filepath"
 
 	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
 	"github.com/kelseyhightower/envconfig"
@@ -14,36 +16,29 @@ import (
 )
 
 func main() {
-	logrus.SetFormatter(new(formatter))
+	_ = pd.SetupLogging("", "")
 
-	var args pd.Args
-	if err := envconfig.Process("", &args); err != nil {
-		logrus.Fatalln(err)
+	if len(os.Args) > 1 && plugin.IsCliCommand(os.Args[1]) {
+		os.Exit(plugin.RunCli(context.Background(), filepath.Base(os.Args[0]), os.Args[1:], os.Stdout, os.Stderr))
 	}
 
-	switch args.Level {
-	case "debug":
-		logrus.SetFormatter(textFormatter)
-		logrus.SetLevel(logrus.DebugLevel)
-	case "trace":
-		logrus.SetFormatter(textFormatter)
-		logrus.SetLevel(logrus.TraceLevel)
-	}
+	var args pd.Args
+	exitOnSettingsError(envconfig.Process("", &args))
+	exitOnSettingsError(pd.ApplyConfigFile(&args, pd.GetTestWorkSpaceDir()))
+	exitOnSettingsError(pd.SetupLogging(args.Level, args.LogFormat))
 
 	if _, err := plugin.Exec(context.Background(), args); err != nil {
-		logrus.Fatalln(err)
+		logrus.Errorln(err)
+		os.Exit(pd.GetExitCode(err))
 	}
 }
 
-// default formatter that writes logs without including timestamp
-// or level information.
-type formatter struct{}
-
-func (*formatter) Format(entry *logrus.Entry) ([]byte, error) {
-	return []byte(entry.Message), nil
-}
-
-// text formatter that writes logs with level information
-var textFormatter = &logrus.TextFormatter{
-	DisableTimestamp: true,
+// exitOnSettingsError stops the step when the settings are invalid, before
+// the plugin runs.
+func exitOnSettingsError(err error) {
+	if err != nil {
+		logrus.Errorln(err)
+		_ = pd.WriteEnvVariableAsString(pd.CoverageResultOutputVariable, pd.ResultError)
+		os.Exit(pd.ExitCodeUsage)
+	}
 }
@@ -0,0 +1,228 @@
+package badge
+
+import (
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// ColorBand colours a badge with Color when the coverage is at least
+// MinimumPercentage and below the next band.
+type ColorBand struct {
+	MinimumPercentage float64
+	Color             string
+}
+
+type Badge struct {
+	FileName   string
+	Label      string
+	Percentage float64
+	HasData    bool
+	ColorBands []ColorBand
+}
+
+// GetColorBands parses bands given as a comma separated list of
+// percentage:color pairs, for example "0:red,60:yellow,80:brightgreen".
+// Without explicit bands the badge is red below the threshold, yellow
+// within the warning margin above it and bright green beyond that.
+func GetColorBands(colorBandsCsv string, threshold, warningMargin float64) ([]ColorBand, error) {
+
+	if strings.TrimSpace(colorBandsCsv) == "" {
+		if threshold <= 0 {
+			colorBandsCsv = DefaultColorBands
+		} else {
+			colorBandsCsv = fmt.Sprintf("0:red,%g:yellow,%g:brightgreen",
+				threshold, minFloat(threshold+warningMargin, 100))
+		}
+	}
+
+	var bands []ColorBand
+	for _, bandStr := range strings.Split(colorBandsCsv, ",") {
+		parts := strings.SplitN(strings.TrimSpace(bandStr), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid badge color band %q, expected percentage:color", bandStr)
+		}
+		percentage, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid badge color band %q: %w", bandStr, err)
+		}
+		bands = append(bands, ColorBand{MinimumPercentage: percentage, Color: strings.TrimSpace(parts[1])})
+	}
+
+	sort.SliceStable(bands, func(i, j int) bool {
+		return bands[i].MinimumPercentage < bands[j].MinimumPercentage
+	})
+	return bands, nil
+}
+
+// GetCoverageBadges returns the line, branch and overall badges for the
+// report. The overall coverage combines covered lines and branches.
+func GetCoverageBadges(report *coverage.Report, lineBands, branchBands, overallBands []ColorBand) []Badge {
+	lineCounter := report.Counters.Get(coverage.LineCounter)
+	branchCounter := report.Counters.Get(coverage.BranchCounter)
+	overallCounter := coverage.Counter{
+		Covered: lineCounter.Covered + branchCounter.Covered,
+		Missed:  lineCounter.Missed + branchCounter.Missed,
+	}
+
+	return []Badge{
+		GetNewBadge(LineBadgeFileName, "line coverage", lineCounter, lineBands),
+		GetNewBadge(BranchBadgeFileName, "branch coverage", branchCounter, branchBands),
+		GetNewBadge(OverallBadgeFileName, "coverage", overallCounter, overallBands),
+	}
+}
+
+func GetNewBadge(fileName, label string, counter coverage.Counter, bands []ColorBand) Badge {
+	return Badge{
+		FileName:   fileName,
+		Label:      label,
+		Percentage: counter.Percentage(),
+		HasData:    counter.Total() > 0,
+		ColorBands: bands,
+	}
+}
+
+func (b Badge) GetValue() string {
+	if !b.HasData {
+		return "unknown"
+	}
+	return fmt.Sprintf("%.1f%%", b.Percentage)
+}
+
+func (b Badge) GetColor() string {
+	if !b.HasData {
+		return GetColorCode("lightgrey")
+	}
+	color := "lightgrey"
+	for _, band := range b.ColorBands {
+		if b.Percentage >= band.MinimumPercentage {
+			color = band.Color
+		}
+	}
+	return GetColorCode(color)
+}
+
+// GetColorCode maps the shields.io colour names to their hex codes, other
+// values are used as given.
+func GetColorCode(color string) string {
+	if code, ok := namedColors[strings.ToLower(color)]; ok {
+		return code
+	}
+	return color
+}
+
+// Render returns the shields.io flat style svg of the badge.
+func (b Badge) Render() (string, error) {
+	label := b.Label
+	value := b.GetValue()
+	labelWidth := GetTextWidth(label) + 10
+	valueWidth := GetTextWidth(value) + 10
+
+	data := map[string]interface{}{
+		"Label":      html.EscapeString(label),
+		"Value":      html.EscapeString(value),
+		"Color":      html.EscapeString(b.GetColor()),
+		"Width":      labelWidth + valueWidth,
+		"LabelWidth": labelWidth,
+		"ValueWidth": valueWidth,
+		"LabelX":     labelWidth * 10 / 2,
+		"ValueX":     (labelWidth + valueWidth/2) * 10,
+		"LabelTextL": (labelWidth - 10) * 10,
+		"ValueTextL": (valueWidth - 10) * 10,
+	}
+
+	var sb strings.Builder
+	err := svgTemplate.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// GetTextWidth approximates the rendered width of text in 11px Verdana.
+func GetTextWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("il.:|!' ", r):
+			width += 3.5
+		case strings.ContainsRune("%mwMW", r):
+			width += 10
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 6.5
+		}
+	}
+	return int(width + 0.5)
+}
+
+// WriteBadges renders the badges into the output dir and returns the
+// written file paths.
+func WriteBadges(badges []Badge, outputDir string) ([]string, error) {
+	err := os.MkdirAll(outputDir, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create badge dir %s: %w", outputDir, err)
+	}
+
+	var paths []string
+	for _, b := range badges {
+		svg, err := b.Render()
+		if err != nil {
+			return paths, fmt.Errorf("failed to render badge %s: %w", b.FileName, err)
+		}
+		badgePath := filepath.Join(outputDir, b.FileName)
+		err = os.WriteFile(badgePath, []byte(svg), 0644)
+		if err != nil {
+			return paths, fmt.Errorf("failed to write badge %s: %w", badgePath, err)
+		}
+		paths = append(paths, badgePath)
+	}
+	return paths, nil
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+var namedColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"lightgrey":   "#9f9f9f",
+}
+
+var svgTemplate = template.Must(template.New("badge").Parse(
+	`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Value}}">` +
+		`<title>{{.Label}}: {{.Value}}</title>` +
+		`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+		`<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>` +
+		`<g clip-path="url(#r)"><rect width="{{.LabelWidth}}" height="20" fill="#555"/>` +
+		`<rect x="{{.LabelWidth}}" width="{{.ValueWidth}}" height="20" fill="{{.Color}}"/>` +
+		`<rect width="{{.Width}}" height="20" fill="url(#s)"/></g>` +
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="110">` +
+		`<text aria-hidden="true" x="{{.LabelX}}" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="{{.LabelTextL}}">{{.Label}}</text>` +
+		`<text x="{{.LabelX}}" y="140" transform="scale(.1)" fill="#fff" textLength="{{.LabelTextL}}">{{.Label}}</text>` +
+		`<text aria-hidden="true" x="{{.ValueX}}" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="{{.ValueTextL}}">{{.Value}}</text>` +
+		`<text x="{{.ValueX}}" y="140" transform="scale(.1)" fill="#fff" textLength="{{.ValueTextL}}">{{.Value}}</text>` +
+		`</g></svg>` + "\n"))
+
+const (
+	LineBadgeFileName    = "coverage-line.svg"
+	BranchBadgeFileName  = "coverage-branch.svg"
+	OverallBadgeFileName = "coverage.svg"
+	DefaultColorBands    = "0:red,50:yellow,80:brightgreen"
+)
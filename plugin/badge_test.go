@@ -0,0 +1,62 @@
+package plugin
+
+import (
+	"context"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoberturaCoverageBadges(t *testing.T) {
+
+	envPluginInputArgs := pd.EnvPluginInputArgs{
+		MinimumLineCoverage:   5.0,
+		MinimumBranchCoverage: 50.0,
+	}
+
+	args := GetTestCoberturaNewArgs(envPluginInputArgs)
+	args.PluginFailOnThreshold = false
+	args.BadgeDir = t.TempDir()
+	args.BadgeWarningMargin = 10
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaCoverageBadges: %s", err.Error())
+	}
+
+	// line coverage 11.76% is within the warning margin above the 5% threshold
+	CheckBadge(t, args.BadgeDir, "coverage-line.svg", "11.8%", "#dfb317")
+	// branch coverage 0% is below the 50% threshold
+	CheckBadge(t, args.BadgeDir, "coverage-branch.svg", "0.0%", "#e05d44")
+	CheckBadge(t, args.BadgeDir, "coverage.svg", "8.0%", "#dfb317")
+}
+
+func TestCoberturaCoverageBadgesWithColorBands(t *testing.T) {
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.BadgeDir = t.TempDir()
+	args.BadgeColorBands = "0:orange, 10:#123456"
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaCoverageBadgesWithColorBands: %s", err.Error())
+	}
+
+	CheckBadge(t, args.BadgeDir, "coverage-line.svg", "#123456")
+	CheckBadge(t, args.BadgeDir, "coverage-branch.svg", "#fe7d37")
+}
+
+func CheckBadge(t *testing.T, badgeDir, badgeName string, expectedContents ...string) {
+	data, err := os.ReadFile(filepath.Join(badgeDir, badgeName))
+	if err != nil {
+		t.Fatalf("Error in CheckBadge: %s", err.Error())
+	}
+	for _, expected := range expectedContents {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Error in CheckBadge: %s does not contain %s", badgeName, expected)
+		}
+	}
+}
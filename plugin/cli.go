@@ -0,0 +1,668 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/diff"
+	"github.com/harness-community/drone-coverage-report/plugin/export"
+	jc "github.com/harness-community/drone-coverage-report/plugin/jacoco"
+	"github.com/harness-community/drone-coverage-report/plugin/markdown"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/kelseyhightower/envconfig"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	DefaultCliHtmlReportDir = "coverage-report"
+	DefaultMergeFormat      = export.CoberturaFormat
+	DefaultMergedExecPath   = jc.MergedExecFileName
+	cliDefaultLogLevel      = "warn"
+)
+
+// CliCommand is a subcommand of the command line mode.
+type CliCommand struct {
+	Name        string
+	Arguments   string
+	Description string
+	run         func(c *Cli, args pd.Args, positional []string) int
+}
+
+var CliCommands = []CliCommand{
+	{"check", "", "Parse the reports and fail when the thresholds are not met, like the plugin step.",
+		(*Cli).runCheck},
+	{"report", "", "Write the configured report outputs, an HTML report in coverage-report by default.",
+		(*Cli).runReport},
+	{"summary", "[summary.json]", "Print the coverage of the reports, or of a summary JSON written before.",
+		(*Cli).runSummary},
+	{"convert", "[format [output]]", "Convert the report to cobertura, lcov, jacoco, sonar or codecov.",
+		(*Cli).runConvert},
+	{"merge", "[report ...]", "Merge the reports, all those matching reports_path_pattern by default, into one.",
+		(*Cli).runMerge},
+	{"merge-exec", "[exec ...]", "Merge JaCoCo exec files, all those matching reports_path_pattern by default, " +
+		"into one exec file.", (*Cli).runMergeExec},
+	{"diff", "[baseline.json [current.json]]", "Compare the coverage with a baseline summary and show the " +
+		"coverage of the changed lines.", (*Cli).runDiff},
+}
+
+// IsCliCommand tells whether the first argument of the binary selects the
+// command line mode. Without one the binary runs as the plugin, configured
+// by the PLUGIN_* env vars.
+func IsCliCommand(name string) bool {
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		return true
+	}
+	_, ok := getCliCommand(name)
+	return ok
+}
+
+func getCliCommand(name string) (CliCommand, bool) {
+	for _, command := range CliCommands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+	return CliCommand{}, false
+}
+
+// Cli runs the plugin from a terminal. Settings are read from the flags,
+// which mirror the PLUGIN_* settings, then from the env vars and the config
+// file.
+type Cli struct {
+	Name   string
+	Stdout io.Writer
+	Stderr io.Writer
+	Color  bool
+
+	ctx            context.Context
+	printJson      bool
+	failOnDecrease bool
+}
+
+// RunCli runs the command of the arguments and returns the exit code.
+func RunCli(ctx context.Context, name string, arguments []string, stdout, stderr io.Writer) int {
+
+	c := &Cli{Name: name, Stdout: stdout, Stderr: stderr, Color: isTerminal(stdout), ctx: ctx}
+
+	command, ok := getCliCommand(arguments[0])
+	if !ok {
+		c.writeUsage(c.Stdout)
+		return pd.ExitCodeOk
+	}
+
+	args, positional, err := c.parseArgs(command, arguments[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return pd.ExitCodeOk
+	}
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\nRun %s %s -h for the flags.\n", err.Error(), c.Name, command.Name)
+		return pd.ExitCodeUsage
+	}
+
+	// The floors are read before the run raises them, so the summary shows
+	// the floors that were checked
+	err = LoadRatchetFloors(&args, pd.GetTestWorkSpaceDir())
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+		return pd.ExitCodeError
+	}
+
+	return command.run(c, args, positional)
+}
+
+func (c *Cli) writeUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [arguments]\n\nCommands:\n", c.Name)
+	for _, command := range CliCommands {
+		fmt.Fprintf(w, "  %-10s %s\n", command.Name, command.Description)
+	}
+	fmt.Fprintf(w, "\nFlags mirror the plugin settings, e.g. --threshold-line 80 for PLUGIN_THRESHOLD_LINE.\n"+
+		"Flags take precedence over the PLUGIN_* env vars, which take precedence over the config file.\n"+
+		"Run %s <command> -h for the flags.\n\n"+
+		"Exit codes: %d success, %d error, %d invalid flags or settings, %d thresholds not met, "+
+		"%d no reports found,\n%d report cannot be parsed, %d coverage tool failed, "+
+		"%d class files do not match the exec files\n",
+		c.Name, pd.ExitCodeOk, pd.ExitCodeError, pd.ExitCodeUsage, pd.ExitCodeThresholdsNotMet,
+		pd.ExitCodeNoReports, pd.ExitCodeParseError, pd.ExitCodeToolError, pd.ExitCodeExecDataMismatch)
+}
+
+// settingFlag records the value of a setting flag, applied once the env vars
+// and the config file are read.
+type settingFlag struct {
+	setting pd.Setting
+	values  map[string]string
+}
+
+func (f *settingFlag) String() string {
+	return ""
+}
+
+func (f *settingFlag) Set(value string) error {
+	err := f.setting.SetString(value)
+	if err != nil {
+		return err
+	}
+	f.values[f.setting.Name] = value
+	return nil
+}
+
+func (f *settingFlag) IsBoolFlag() bool {
+	return f.setting.Value.Kind() == reflect.Bool
+}
+
+// parseArgs returns the settings and the positional arguments of the
+// command. Flags and positional arguments may be mixed.
+func (c *Cli) parseArgs(command CliCommand, arguments []string) (pd.Args, []string, error) {
+
+	flagSet := flag.NewFlagSet(c.Name+" "+command.Name, flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+
+	var flagArgs pd.Args
+	flagValues := map[string]string{}
+	for _, setting := range pd.GetSettings(&flagArgs) {
+		flagSet.Var(&settingFlag{setting: setting, values: flagValues}, strings.ReplaceAll(setting.Name, "_", "-"),
+			"sets "+setting.EnvVarKey)
+	}
+	workSpaceDir := flagSet.String("workspace", "", "workspace `dir` the paths are relative to, "+
+		"DRONE_WORKSPACE or the current directory by default")
+	noColor := flagSet.Bool("no-color", false, "disable the colours of the tables")
+	flagSet.BoolVar(&c.printJson, "json", false, "print the summary as JSON (summary)")
+	flagSet.BoolVar(&c.failOnDecrease, "fail-on-decrease", false,
+		"exit with the threshold exit code when the coverage decreased (diff)")
+
+	var positional []string
+	for {
+		err := flagSet.Parse(arguments)
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(c.Stdout, "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n", c.Name, command.Name,
+				command.Arguments, command.Description)
+			flagSet.SetOutput(c.Stdout)
+			flagSet.PrintDefaults()
+		}
+		if err != nil {
+			return pd.Args{}, nil, err
+		}
+		if flagSet.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flagSet.Arg(0))
+		arguments = flagSet.Args()[1:]
+	}
+
+	if *noColor || os.Getenv("NO_COLOR") != "" {
+		c.Color = false
+	}
+	if *workSpaceDir != "" {
+		_ = os.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, *workSpaceDir)
+	} else if os.Getenv(pd.DefaultWorkSpaceDirEnvVarKey) == "" {
+		_ = os.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, ".")
+	}
+
+	// quiet until the log level of the settings is known
+	_ = pd.SetupLogging(cliDefaultLogLevel, "")
+
+	var args pd.Args
+	err := envconfig.Process("", &args)
+	if err != nil {
+		return args, nil, err
+	}
+	if configFile, ok := flagValues["config_file"]; ok {
+		args.ConfigFile = configFile
+	}
+	err = pd.ApplyConfigFile(&args, pd.GetTestWorkSpaceDir())
+	if err != nil {
+		return args, nil, err
+	}
+	for _, setting := range pd.GetSettings(&args) {
+		if value, ok := flagValues[setting.Name]; ok {
+			_ = setting.SetString(value)
+		}
+	}
+
+	level := args.Level
+	if level == "" {
+		level = cliDefaultLogLevel
+	}
+	err = pd.SetupLogging(level, args.LogFormat)
+	if err != nil {
+		return args, nil, err
+	}
+
+	return args, positional, nil
+}
+
+func (c *Cli) runCheck(args pd.Args, positional []string) int {
+
+	args.PluginFailOnThreshold = true
+	p, err := Exec(c.ctx, args)
+	summary, ok := getCliSummary(p, args)
+	if ok {
+		c.writeSummary(summary)
+	}
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+	}
+	return pd.GetExitCode(err)
+}
+
+func (c *Cli) runReport(args pd.Args, positional []string) int {
+
+	args.PluginFailOnThreshold = false
+	if args.HtmlReportDir == "" && args.BadgeDir == "" && args.SummaryJsonPath == "" && args.MarkdownPath == "" &&
+		args.JunitReportPath == "" && args.SarifPath == "" && args.ConvertFormat == "" {
+		args.HtmlReportDir = DefaultCliHtmlReportDir
+	}
+
+	p, err := Exec(c.ctx, args)
+	if summary, ok := getCliSummary(p, args); ok {
+		c.writeSummary(summary)
+	}
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+		return pd.GetExitCode(err)
+	}
+	if args.HtmlReportDir != "" {
+		fmt.Fprintf(c.Stdout, "\nHTML report written to %s\n",
+			pd.GetWorkSpaceRelativePath(pd.GetTestWorkSpaceDir(), args.HtmlReportDir))
+	}
+	return pd.ExitCodeOk
+}
+
+func (c *Cli) runSummary(args pd.Args, positional []string) int {
+
+	var summary pd.CoverageSummary
+	var report *coverage.Report
+	if len(positional) > 0 {
+		readSummary, err := pd.ReadCoverageSummary(positional[0])
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.ExitCodeError
+		}
+		summary = *readSummary
+	} else {
+		args.PluginFailOnThreshold = false
+		p, err := RunPlugin(c.ctx, args)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.GetExitCode(err)
+		}
+		report = p.GetCoverageReport()
+		summary, _ = getCliSummary(p, args)
+	}
+
+	if c.printJson {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.ExitCodeError
+		}
+		fmt.Fprintln(c.Stdout, string(data))
+		return pd.ExitCodeOk
+	}
+
+	c.writeSummary(summary)
+	if report != nil {
+		fmt.Fprintln(c.Stdout)
+		c.writePackages(report)
+	}
+	return pd.ExitCodeOk
+}
+
+func (c *Cli) runConvert(args pd.Args, positional []string) int {
+
+	if len(positional) > 0 {
+		args.ConvertFormat = positional[0]
+	}
+	if len(positional) > 1 {
+		args.ConvertOutputPath = positional[1]
+	}
+	if args.ConvertFormat == "" {
+		fmt.Fprintf(c.Stderr, "Error: no format given, expected %s convert <format> or --convert-format\n", c.Name)
+		return pd.ExitCodeUsage
+	}
+
+	args.PluginFailOnThreshold = false
+	p, err := RunPlugin(c.ctx, args)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+		return pd.GetExitCode(err)
+	}
+	return c.writeConverted(p.GetCoverageReport(), args, "Coverage report converted")
+}
+
+func (c *Cli) runMerge(args pd.Args, positional []string) int {
+
+	workSpaceDir := pd.GetTestWorkSpaceDir()
+	reportPaths := positional
+	if len(reportPaths) == 0 {
+		matches, err := doublestar.Glob(os.DirFS(workSpaceDir), args.ExecFilesPathPattern)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.ExitCodeUsage
+		}
+		reportPaths = matches
+	}
+	if len(reportPaths) == 0 {
+		fmt.Fprintf(c.Stderr, "Error: no reports found matching %q\n", args.ExecFilesPathPattern)
+		return pd.ExitCodeNoReports
+	}
+
+	var reports []*coverage.Report
+	for _, reportPath := range reportPaths {
+		reportArgs := args
+		reportArgs.PluginFailOnThreshold = false
+		reportArgs.ExecFilesPathPattern = escapeGlobPattern(filepath.ToSlash(reportPath))
+		p, err := RunPlugin(c.ctx, reportArgs)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error in %s: %s\n", reportPath, err.Error())
+			return pd.GetExitCode(err)
+		}
+		reports = append(reports, p.GetCoverageReport())
+	}
+
+	if args.ConvertFormat == "" {
+		args.ConvertFormat = DefaultMergeFormat
+	}
+	return c.writeConverted(coverage.MergeReports(reports), args, fmt.Sprintf("%d reports merged", len(reports)))
+}
+
+func (c *Cli) runMergeExec(args pd.Args, positional []string) int {
+
+	workSpaceDir := pd.GetTestWorkSpaceDir()
+	var execFilePaths []string
+	for _, execFilePath := range positional {
+		execFilePaths = append(execFilePaths, pd.GetWorkSpaceRelativePath(workSpaceDir, execFilePath))
+	}
+	if len(execFilePaths) == 0 && args.ExecFilesPathPattern != "" {
+		matches, err := pd.GetAllJacocoExecFilesFromGlobPattern(workSpaceDir, args.ExecFilesPathPattern)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.ExitCodeUsage
+		}
+		for _, match := range matches {
+			execFilePaths = append(execFilePaths, filepath.Join(match.CompletePathPrefix, match.RelativePath))
+		}
+	}
+	if len(execFilePaths) == 0 {
+		fmt.Fprintf(c.Stderr, "Error: no exec files found matching %q\n", args.ExecFilesPathPattern)
+		return pd.ExitCodeNoReports
+	}
+
+	merged, err := jc.MergeExecFiles(execFilePaths)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+		return pd.GetExitCode(err)
+	}
+
+	outputPath := args.MergedExecPath
+	if outputPath == "" {
+		outputPath = DefaultMergedExecPath
+	}
+	outputPath = pd.GetWorkSpaceRelativePath(workSpaceDir, outputPath)
+	err = jc.WriteExecFile(outputPath, merged)
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+		return pd.ExitCodeError
+	}
+
+	fmt.Fprintf(c.Stdout, "%d exec files merged, %d sessions and %d classes written to %s\n", len(execFilePaths),
+		len(merged.Sessions), len(merged.Classes), outputPath)
+	return pd.ExitCodeOk
+}
+
+func (c *Cli) writeConverted(report *coverage.Report, args pd.Args, message string) int {
+
+	workSpaceDir := pd.GetTestWorkSpaceDir()
+	err := MapSourcePaths(report, args, workSpaceDir)
+	if err == nil {
+		err = WriteConvertedReport(report, args, workSpaceDir)
+	}
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+		return pd.ExitCodeError
+	}
+
+	outputPath := args.ConvertOutputPath
+	if outputPath == "" {
+		outputPath = export.GetDefaultOutputName(args.ConvertFormat)
+	}
+	c.writeSummary(pd.GetCoverageSummary(report, nil, args.Pipeline))
+	fmt.Fprintf(c.Stdout, "\n%s, %s written to %s\n", message, args.ConvertFormat,
+		pd.GetWorkSpaceRelativePath(workSpaceDir, outputPath))
+	return pd.ExitCodeOk
+}
+
+func (c *Cli) runDiff(args pd.Args, positional []string) int {
+
+	baselinePath := args.BaselineSummaryJsonPath
+	if len(positional) > 0 {
+		baselinePath = positional[0]
+	}
+	if baselinePath == "" {
+		fmt.Fprintf(c.Stderr, "Error: no baseline summary given, expected %s diff <baseline.json> "+
+			"or --baseline-summary-path\n", c.Name)
+		return pd.ExitCodeUsage
+	}
+	workSpaceDir := pd.GetTestWorkSpaceDir()
+	baseline, err := pd.ReadCoverageSummary(pd.GetWorkSpaceRelativePath(workSpaceDir, baselinePath))
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+		return pd.ExitCodeError
+	}
+
+	var current pd.CoverageSummary
+	var report *coverage.Report
+	if len(positional) > 1 {
+		readSummary, err := pd.ReadCoverageSummary(pd.GetWorkSpaceRelativePath(workSpaceDir, positional[1]))
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.ExitCodeError
+		}
+		current = *readSummary
+	} else {
+		args.PluginFailOnThreshold = false
+		p, err := RunPlugin(c.ctx, args)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.GetExitCode(err)
+		}
+		report = p.GetCoverageReport()
+		current, _ = getCliSummary(p, args)
+	}
+
+	table := CliTable{Header: []string{"Metric", "Baseline", "Current", "Delta"}}
+	decreased := false
+	for _, counterType := range coverage.AllCounterTypes {
+		if !current.Counters.Has(counterType) || !baseline.Counters.Has(counterType) {
+			continue
+		}
+		delta, _ := current.GetDelta(baseline, counterType)
+		deltaColor := ""
+		switch {
+		case delta > 0.005:
+			deltaColor = ansiGreen
+		case delta < -0.005:
+			deltaColor = ansiRed
+			decreased = true
+		}
+		table.AddRow(CliCell{Text: counterType.DisplayName()},
+			CliCell{Text: fmt.Sprintf("%.2f%%", baseline.Counters.Get(counterType).Percentage())},
+			CliCell{Text: fmt.Sprintf("%.2f%%", current.Counters.Get(counterType).Percentage())},
+			CliCell{Text: markdown.FormatDelta(delta), Color: deltaColor})
+	}
+	table.Write(c.Stdout, c.Color)
+
+	if report != nil && (args.DiffBase != "" || args.DiffFile != "") {
+		err := MapSourcePaths(report, args, workSpaceDir)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.ExitCodeError
+		}
+		changedLines, err := GetChangedLines(args, workSpaceDir)
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "Error: %s\n", err.Error())
+			return pd.ExitCodeError
+		}
+		counter := GetChangedLinesCounter(report, changedLines)
+		fmt.Fprintf(c.Stdout, "\nChanged lines: %s\n", markdown.FormatCounter(counter))
+	}
+
+	if decreased && c.failOnDecrease {
+		fmt.Fprintf(c.Stderr, "Error: coverage decreased\n")
+		return pd.ExitCodeThresholdsNotMet
+	}
+	return pd.ExitCodeOk
+}
+
+// GetChangedLinesCounter counts the covered and missed lines among the
+// changed lines.
+func GetChangedLinesCounter(report *coverage.Report, changedLines diff.ChangedLines) coverage.Counter {
+	var counter coverage.Counter
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			_, lines := changedLines.GetFileLines(file.GetSourcePath())
+			for _, line := range file.Lines {
+				if !diff.Contains(lines, line.Number) {
+					continue
+				}
+				if line.IsCovered() {
+					counter.Covered++
+				} else {
+					counter.Missed++
+				}
+			}
+		}
+	}
+	return counter
+}
+
+func getCliSummary(p pd.Plugin, args pd.Args) (pd.CoverageSummary, bool) {
+	if p == nil || p.GetCoverageReport() == nil {
+		return pd.CoverageSummary{}, false
+	}
+	return pd.GetCoverageSummary(p.GetCoverageReport(), GetThresholdChecks(p, args), args.Pipeline), true
+}
+
+// writeSummary writes a table of the coverage with its thresholds, then the
+// other threshold checks and the verdict.
+func (c *Cli) writeSummary(summary pd.CoverageSummary) {
+
+	checksByCounter := map[coverage.CounterType]pd.ThresholdCheck{}
+	var otherChecks []pd.ThresholdCheck
+	for _, check := range summary.ThresholdChecks {
+		if check.IsCounterCheck() {
+			checksByCounter[check.CounterType] = check
+		} else {
+			otherChecks = append(otherChecks, check)
+		}
+	}
+
+	table := CliTable{Header: []string{"Metric", "Covered", "Missed", "Coverage", "Threshold", "Status"}}
+	for _, counterType := range coverage.AllCounterTypes {
+		if !summary.Counters.Has(counterType) {
+			continue
+		}
+		counter := summary.Counters.Get(counterType)
+		coverageCell := CliCell{Text: fmt.Sprintf("%.2f%%", counter.Percentage())}
+		thresholdCell, statusCell := CliCell{Text: "-"}, CliCell{}
+		if check, ok := checksByCounter[counterType]; ok {
+			thresholdCell.Text = check.GetExpectedString()
+			statusCell = getCliStatusCell(check)
+			coverageCell.Color = statusCell.Color
+		}
+		table.AddRow(CliCell{Text: counterType.DisplayName()}, CliCell{Text: fmt.Sprint(counter.Covered)},
+			CliCell{Text: fmt.Sprint(counter.Missed)}, coverageCell, thresholdCell, statusCell)
+	}
+	table.Write(c.Stdout, c.Color)
+
+	if len(otherChecks) > 0 {
+		fmt.Fprintln(c.Stdout)
+		checksTable := CliTable{Header: []string{"Check", "Observed", "Threshold", "Status"}}
+		for _, check := range otherChecks {
+			checksTable.AddRow(CliCell{Text: check.Metric}, CliCell{Text: check.FormatValue(check.ObservedValue)},
+				CliCell{Text: check.GetExpectedString()}, getCliStatusCell(check))
+		}
+		checksTable.Write(c.Stdout, c.Color)
+	}
+
+	failedChecks := pd.GetFailedThresholdChecks(summary.ThresholdChecks)
+	warningChecks := pd.GetWarningThresholdChecks(summary.ThresholdChecks)
+	switch {
+	case len(summary.ThresholdChecks) == 0:
+	case len(failedChecks) == 0 && len(warningChecks) == 0:
+		fmt.Fprintf(c.Stdout, "\n%s\n", Colorize(fmt.Sprintf("All %d coverage thresholds met",
+			len(summary.ThresholdChecks)), ansiGreen, c.Color))
+	case len(failedChecks) == 0:
+		fmt.Fprintf(c.Stdout, "\n%s\n", Colorize(fmt.Sprintf("%d of %d coverage thresholds not met as warnings",
+			len(warningChecks), len(summary.ThresholdChecks)), ansiYellow, c.Color))
+	default:
+		fmt.Fprintf(c.Stdout, "\n%s\n", Colorize(fmt.Sprintf("%d of %d coverage thresholds not met",
+			len(failedChecks), len(summary.ThresholdChecks)), ansiRed, c.Color))
+	}
+}
+
+// writePackages writes the coverage of every package of the report.
+func (c *Cli) writePackages(report *coverage.Report) {
+
+	var counterTypes []coverage.CounterType
+	header := []string{"Package"}
+	for _, counterType := range coverage.AllCounterTypes {
+		if counterType != coverage.ComplexityCounter && report.Counters.Has(counterType) {
+			counterTypes = append(counterTypes, counterType)
+			header = append(header, counterType.DisplayName())
+		}
+	}
+
+	table := CliTable{Header: header}
+	for _, pkg := range report.Packages {
+		row := []CliCell{{Text: pkg.Name}}
+		for _, counterType := range counterTypes {
+			if pkg.Counters.Has(counterType) {
+				row = append(row, CliCell{Text: fmt.Sprintf("%.2f%%", pkg.Counters.Get(counterType).Percentage())})
+			} else {
+				row = append(row, CliCell{Text: "-"})
+			}
+		}
+		table.AddRow(row...)
+	}
+	table.Write(c.Stdout, c.Color)
+}
+
+func getCliStatusCell(check pd.ThresholdCheck) CliCell {
+	if check.Passed {
+		return CliCell{Text: "passed", Color: ansiGreen}
+	}
+	if check.IsWarning() {
+		return CliCell{Text: "warning", Color: ansiYellow}
+	}
+	return CliCell{Text: "failed", Color: ansiRed}
+}
+
+// escapeGlobPattern escapes the glob meta characters of a path, so the path
+// can be given as a reports path pattern.
+func escapeGlobPattern(path string) string {
+	var escaped strings.Builder
+	for _, c := range path {
+		if strings.ContainsRune(`*?[]{}\`, c) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(c)
+	}
+	return escaped.String()
+}
+
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// CliCell is a table cell with an optional ANSI colour.
+type CliCell struct {
+	Text  string
+	Color string
+}
+
+// CliTable is a table written to the terminal with aligned columns. The
+// first column is left aligned and the others, holding numbers, right
+// aligned.
+type CliTable struct {
+	Header []string
+	Rows   [][]CliCell
+}
+
+func (t *CliTable) AddRow(cells ...CliCell) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Write writes the table, colouring the cells when color is set. Widths
+// are computed from the text alone, so colours do not break the alignment.
+func (t *CliTable) Write(w io.Writer, color bool) {
+
+	widths := make([]int, len(t.Header))
+	for i, title := range t.Header {
+		widths[i] = utf8.RuneCountInString(title)
+	}
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			if i < len(widths) && utf8.RuneCountInString(cell.Text) > widths[i] {
+				widths[i] = utf8.RuneCountInString(cell.Text)
+			}
+		}
+	}
+
+	header := make([]CliCell, len(t.Header))
+	for i, title := range t.Header {
+		header[i] = CliCell{Text: title, Color: ansiBold}
+	}
+	writeCliTableRow(w, header, widths, color)
+
+	separators := make([]CliCell, len(widths))
+	for i, width := range widths {
+		separators[i] = CliCell{Text: strings.Repeat("-", width)}
+	}
+	writeCliTableRow(w, separators, widths, color)
+
+	for _, row := range t.Rows {
+		writeCliTableRow(w, row, widths, color)
+	}
+}
+
+func writeCliTableRow(w io.Writer, row []CliCell, widths []int, color bool) {
+	cells := make([]string, len(widths))
+	for i, width := range widths {
+		var cell CliCell
+		if i < len(row) {
+			cell = row[i]
+		}
+		padding := strings.Repeat(" ", width-utf8.RuneCountInString(cell.Text))
+		text := cell.Text
+		if color && cell.Color != "" && text != "" {
+			text = cell.Color + text + ansiReset
+		}
+		if i == 0 {
+			cells[i] = text + padding
+		} else {
+			cells[i] = padding + text
+		}
+	}
+	fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+}
+
+// Colorize wraps the text in the colour when color is set.
+func Colorize(text, ansiColor string, color bool) string {
+	if !color || ansiColor == "" {
+		return text
+	}
+	return ansiColor + text + ansiReset
+}
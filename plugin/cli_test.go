@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// RunTestCli runs the command line mode on the test workspace and returns
+// the exit code with the output.
+func RunTestCli(t *testing.T, arguments ...string) (int, string, string) {
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, pd.TestWorkSpaceDir)
+	t.Cleanup(func() {
+		_ = pd.SetupLogging("", "")
+	})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := RunCli(context.TODO(), "coverage-report", arguments, &stdout, &stderr)
+	return exitCode, stdout.String(), stderr.String()
+}
+
+func TestCliCheckExitCodes(t *testing.T) {
+
+	jacocoXmlFlags := []string{"--tool", "jacoco-xml", "--reports-path-pattern", "**/gameoflife-core/**/jacoco.xml",
+		"--threshold-complexity", "100"}
+
+	testCases := []struct {
+		arguments        []string
+		expectedExitCode int
+		expectedOutput   string
+	}{
+		{append([]string{"check", "--threshold-branch", "90"}, jacocoXmlFlags...), pd.ExitCodeOk,
+			"All 2 coverage thresholds met"},
+		{append([]string{"check", "--threshold-branch=99"}, jacocoXmlFlags...), pd.ExitCodeThresholdsNotMet,
+			"1 of 2 coverage thresholds not met"},
+		{append([]string{"check", "--threshold-branch", "high"}, jacocoXmlFlags...), pd.ExitCodeUsage,
+			`invalid value "high" for threshold_branch`},
+		{[]string{"check", "--tool", "gcov"}, pd.ExitCodeError, "Unknown plugin type: gcov"},
+		{[]string{"check", "--tool", "cobertura", "--reports-path-pattern", "**/missing.xml"}, pd.ExitCodeNoReports,
+			"No Cobertura report xml found"},
+	}
+
+	for _, testCase := range testCases {
+		exitCode, stdout, stderr := RunTestCli(t, testCase.arguments...)
+		if exitCode != testCase.expectedExitCode || !strings.Contains(stdout+stderr, testCase.expectedOutput) {
+			t.Errorf("Error in TestCliCheckExitCodes: %v exited with %d, expected %d with %q, output %s%s",
+				testCase.arguments, exitCode, testCase.expectedExitCode, testCase.expectedOutput, stdout, stderr)
+		}
+	}
+}
+
+func TestCliCheckWithoutThresholds(t *testing.T) {
+
+	for _, arguments := range [][]string{
+		{"check", "--tool", "cobertura", "--reports-path-pattern", "**/coverage.xml"},
+		{"check", "--tool", "jacoco-xml", "--reports-path-pattern", "**/gameoflife-core/**/jacoco.xml"},
+	} {
+		exitCode, stdout, stderr := RunTestCli(t, arguments...)
+		if exitCode != pd.ExitCodeOk || strings.Contains(stdout+stderr, "not met") {
+			t.Errorf("Error in TestCliCheckWithoutThresholds: %v exited with %d, output %s%s",
+				arguments, exitCode, stdout, stderr)
+		}
+	}
+}
+
+func TestCliSummaryTable(t *testing.T) {
+
+	exitCode, stdout, stderr := RunTestCli(t, "summary", "--tool", "cobertura", "--threshold-line", "20",
+		"--reports-path-pattern", "**/coverage.xml")
+	if exitCode != pd.ExitCodeOk {
+		t.Fatalf("Error in TestCliSummaryTable: exit code %d, %s", exitCode, stderr)
+	}
+
+	for _, expected := range []string{
+		"Metric  Covered  Missed  Coverage  Threshold  Status",
+		"Line          2      15    11.76%  >= 20.00%  failed",
+		"com.example.package1   0.00%  33.33%  40.00%  100.00%",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Error in TestCliSummaryTable: %q not found in\n%s", expected, stdout)
+		}
+	}
+	if strings.Contains(stdout, ansiReset) {
+		t.Errorf("Error in TestCliSummaryTable: colours written to a buffer")
+	}
+}
+
+func TestCliMerge(t *testing.T) {
+
+	outputDir := t.TempDir()
+	outputPath := filepath.Join(outputDir, "merged.xml")
+	exitCode, stdout, stderr := RunTestCli(t, "merge", "--tool", "jacoco-xml", "--reports-path-pattern",
+		"**/target/site/jacoco/jacoco.xml", "--convert-output-path", outputPath)
+	if exitCode != pd.ExitCodeOk || !strings.Contains(stdout, "2 reports merged, cobertura written to") {
+		t.Fatalf("Error in TestCliMerge: exit code %d, %s%s", exitCode, stdout, stderr)
+	}
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.ExecFilesPathPattern = "merged.xml"
+	args.PluginFailOnThreshold = false
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, outputDir)
+	p, err := RunPlugin(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCliMerge: %s", err.Error())
+	}
+	if p.GetCoverageReport().Counters.Get(coverage.LineCounter).Total() == 0 {
+		t.Errorf("Error in TestCliMerge: merged report has no lines")
+	}
+}
+
+func TestMergeReports(t *testing.T) {
+
+	getReport := func(lines ...coverage.Line) *coverage.Report {
+		return &coverage.Report{Tool: "cobertura", Packages: []*coverage.Package{{
+			Name:  "com.example",
+			Files: []*coverage.File{{Name: "App.java", Path: "com/example/App.java", Lines: lines}},
+		}}}
+	}
+
+	merged := coverage.MergeReports([]*coverage.Report{
+		getReport(coverage.Line{Number: 1}, coverage.Line{Number: 2, Hits: 1, Branches: 2, CoveredBranches: 1}),
+		getReport(coverage.Line{Number: 2, Hits: 3, Branches: 2, CoveredBranches: 2}, coverage.Line{Number: 3}),
+	})
+
+	if len(merged.Packages) != 1 || len(merged.Packages[0].Files) != 1 {
+		t.Fatalf("Error in TestMergeReports: packages and files not merged %+v", merged.Packages)
+	}
+	if merged.Counters.Get(coverage.LineCounter) != (coverage.Counter{Covered: 1, Missed: 2}) ||
+		merged.Counters.Get(coverage.BranchCounter) != (coverage.Counter{Covered: 2, Missed: 0}) {
+		t.Errorf("Error in TestMergeReports: unexpected counters %v", merged.Counters)
+	}
+	if merged.Packages[0].Files[0].Lines[1].Hits != 4 {
+		t.Errorf("Error in TestMergeReports: hits of line 2 not added up %v", merged.Packages[0].Files[0].Lines)
+	}
+}
@@ -3,6 +3,7 @@ package cobertura
 import (
 	"encoding/xml"
 	"fmt"
+	"github.com/sirupsen/logrus"
 	"os"
 	"regexp"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 
 type Coverage struct {
 	XMLName  xml.Name  `xml:"coverage"`
+	Sources  []string  `xml:"sources>source"`
 	Packages []Package `xml:"packages>package"`
 }
 
@@ -22,6 +24,7 @@ type Package struct {
 
 type Class struct {
 	Name       string   `xml:"name,attr"`
+	Filename   string   `xml:"filename,attr"`
 	Complexity float64  `xml:"complexity,attr"`
 	BranchRate float64  `xml:"branch-rate,attr"`
 	LineRate   float64  `xml:"line-rate,attr"`
@@ -63,21 +66,29 @@ type CoverageStats struct {
 
 func GetCoberturaCoverageMetrics(coverageXmlCompletePath string) (CoverageStats, error) {
 
-	file, err := os.Open(coverageXmlCompletePath)
+	coverage, err := ParseCoberturaCoverageXml(coverageXmlCompletePath)
 	if err != nil {
-		fmt.Println("Error opening file:", err)
 		return CoverageStats{}, err
 	}
+
+	stats := calculateCoverage(coverage)
+	return stats, nil
+}
+
+func ParseCoberturaCoverageXml(coverageXmlCompletePath string) (Coverage, error) {
+
+	file, err := os.Open(coverageXmlCompletePath)
+	if err != nil {
+		return Coverage{}, err
+	}
 	defer file.Close()
 
 	var coverage Coverage
 	if err := xml.NewDecoder(file).Decode(&coverage); err != nil {
-		fmt.Println("Error decoding XML:", err)
-		return CoverageStats{}, err
+		return Coverage{}, err
 	}
 
-	stats := calculateCoverage(coverage)
-	return stats, nil
+	return coverage, nil
 }
 
 func calculateCoverage(c Coverage) CoverageStats {
@@ -88,6 +99,7 @@ func calculateCoverage(c Coverage) CoverageStats {
 	var totalComplexity float64 = 0.0
 	var totalMethods, totalMethodsCovered int
 	var totalClasses, totalCoveredClasses int
+	coveredFiles := map[string]bool{}
 
 	for _, pkg := range c.Packages {
 		var pkgLines, pkgCovered int
@@ -97,6 +109,8 @@ func calculateCoverage(c Coverage) CoverageStats {
 			classLines, classLinesCovered := getLineStats(class.Lines)
 			totalClasses++
 
+			filePath := GetClassFilePath(pkg, class)
+			coveredFiles[filePath] = coveredFiles[filePath] || classLinesCovered > 0
 			if classLinesCovered > 0 {
 				totalCoveredClasses++
 				packageCoveredClasses++
@@ -128,18 +142,26 @@ func calculateCoverage(c Coverage) CoverageStats {
 		}
 	}
 
-	packageCoverage := calculatePercentage(totalPackages, totalCoveredPackages)
-	fileCoverage := calculatePercentage(totalCovered, totalPackages)
+	totalCoveredFiles := 0
+	for _, covered := range coveredFiles {
+		if covered {
+			totalCoveredFiles++
+		}
+	}
+
+	packageCoverage := calculatePercentage(totalCoveredPackages, totalPackages)
+	fileCoverage := calculatePercentage(totalCoveredFiles, len(coveredFiles))
+	methodCoverage := calculatePercentage(totalMethodsCovered, totalMethods)
 	classCoverage := calculatePercentage(totalCoveredClasses, totalClasses)
 	branchCoverage := calculatePercentage(totalCoveredBranches, totalBranches)
 	lineCoverage := calculatePercentage(totalCovered, totalLines)
 
-	fmt.Printf("Branch covered: %d Total branches: %d\n", totalCoveredBranches, totalBranches)
-	fmt.Printf("Methods covered: %d Total methods: %d\n", totalMethodsCovered, totalMethods)
-	fmt.Printf("Classes covered: %d Total classes: %d\n", totalCoveredClasses, totalClasses)
-	fmt.Printf("Complexity: %.2f\n", totalComplexity)
-	fmt.Printf("Total Lines: %d\n", totalLines)
-	fmt.Printf("Method Coverage: %d\n", totalMethods)
+	logrus.Debugf("Branch covered: %d Total branches: %d\n", totalCoveredBranches, totalBranches)
+	logrus.Debugf("Methods covered: %d Total methods: %d\n", totalMethodsCovered, totalMethods)
+	logrus.Debugf("Classes covered: %d Total classes: %d\n", totalCoveredClasses, totalClasses)
+	logrus.Debugf("Complexity: %.2f\n", totalComplexity)
+	logrus.Debugf("Total Lines: %d\n", totalLines)
+	logrus.Debugf("Method Coverage: %d\n", totalMethods)
 
 	return CoverageStats{
 		PackageCoverage:   packageCoverage,
@@ -147,6 +169,7 @@ func calculateCoverage(c Coverage) CoverageStats {
 		ClassCoverage:     classCoverage,
 		BranchCoverage:    branchCoverage,
 		LineCoverage:      lineCoverage,
+		MethodCoverage:    methodCoverage,
 		Complexity:        int(totalComplexity),
 		ComplexityDensity: fmt.Sprintf("%d/%d", int(totalComplexity), totalLines),
 		LOC:               totalLines,
@@ -195,13 +218,15 @@ func calculatePercentage(part, total int) float64 {
 	return float64(part) / float64(total) * 100
 }
 
-func (stats *CoverageStats) PrintToConsole() {
-	fmt.Printf("Package Coverage: %.2f%%\n", stats.PackageCoverage)
-	fmt.Printf("File Coverage: %.2f%%\n", stats.FileCoverage)
-	fmt.Printf("Class Coverage: %.2f%%\n", stats.ClassCoverage)
-	fmt.Printf("Branch Coverage: %.2f%%\n", stats.BranchCoverage)
-	fmt.Printf("Line Coverage: %.2f%%\n", stats.LineCoverage)
-	fmt.Printf("Complexity: %v\n", stats.Complexity)
-	fmt.Printf("Complexity Density: %v\n", stats.ComplexityDensity)
-	fmt.Printf("LOC: %v\n", stats.LOC)
+// LogStats writes the Cobertura specific statistics at debug level, the
+// coverage summary of every run is logged at info level.
+func (stats *CoverageStats) LogStats() {
+	logrus.Debugf("Package Coverage: %.2f%%\n", stats.PackageCoverage)
+	logrus.Debugf("File Coverage: %.2f%%\n", stats.FileCoverage)
+	logrus.Debugf("Class Coverage: %.2f%%\n", stats.ClassCoverage)
+	logrus.Debugf("Branch Coverage: %.2f%%\n", stats.BranchCoverage)
+	logrus.Debugf("Line Coverage: %.2f%%\n", stats.LineCoverage)
+	logrus.Debugf("Complexity: %v\n", stats.Complexity)
+	logrus.Debugf("Complexity Density: %v\n", stats.ComplexityDensity)
+	logrus.Debugf("LOC: %v\n", stats.LOC)
 }
@@ -0,0 +1,157 @@
+package cobertura
+
+import (
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"path"
+)
+
+func ToCoverageLines(lines []Line) []coverage.Line {
+	var coverageLines []coverage.Line
+	for _, line := range lines {
+		coveredBranches, totalBranches := 0, 0
+		if line.ConditionCoverage != "" {
+			coveredBranches, totalBranches = parseConditionCoverage(line.ConditionCoverage)
+		}
+		coverageLines = append(coverageLines, coverage.Line{
+			Number:          line.Number,
+			Hits:            line.Hits,
+			Branches:        totalBranches,
+			CoveredBranches: coveredBranches,
+		})
+	}
+	return coverageLines
+}
+
+// ToCoverageReport converts the parsed Cobertura xml report into the tool
+// independent coverage model. Classes sharing a source file are merged into
+// one file entry.
+func (c *Coverage) ToCoverageReport() *coverage.Report {
+
+	coverageReport := &coverage.Report{
+		Tool:    pd.CoberturaPluginType,
+		Sources: c.Sources,
+	}
+
+	for _, pkg := range c.Packages {
+		coveragePackage := &coverage.Package{Name: pkg.Name}
+		filesMap := map[string]*coverage.File{}
+
+		for _, class := range pkg.Classes {
+			filePath := GetClassFilePath(pkg, class)
+
+			file, ok := filesMap[filePath]
+			if !ok {
+				file = &coverage.File{
+					Name:     path.Base(filePath),
+					Path:     filePath,
+					Counters: coverage.Counters{},
+				}
+				filesMap[filePath] = file
+				coveragePackage.Files = append(coveragePackage.Files, file)
+			}
+
+			file.Lines = append(file.Lines, ToCoverageLines(class.Lines)...)
+
+			classMethods, classMethodsCovered := getMethodStats(class.Methods)
+			_, classLinesCovered := getLineStats(class.Lines)
+			classCounter := coverage.Counter{Missed: 1}
+			if classLinesCovered > 0 {
+				classCounter = coverage.Counter{Covered: 1}
+			}
+			file.Counters.Add(coverage.Counters{
+				coverage.MethodCounter: {Covered: classMethodsCovered, Missed: classMethods - classMethodsCovered},
+				coverage.ClassCounter:  classCounter,
+			})
+		}
+
+		for _, file := range coveragePackage.Files {
+			file.Lines = coverage.SortLines(file.Lines)
+		}
+		coverageReport.Packages = append(coverageReport.Packages, coveragePackage)
+	}
+
+	coverageReport.Recompute()
+	return coverageReport
+}
+
+// GetClassFilePath returns the report path of the source file of the class.
+func GetClassFilePath(pkg Package, class Class) string {
+	if class.Filename != "" {
+		return class.Filename
+	}
+	return coverage.GetFilePath(pkg.Name, class.Name)
+}
+
+// Filter removes the classes whose class path or source file path is not
+// selected by the filters, and the packages left without classes. It
+// returns the number of classes removed.
+func (c *Coverage) Filter(classFilter, sourceFilter *coverage.PathFilter) int {
+
+	removed := 0
+	var packages []Package
+	for _, pkg := range c.Packages {
+		var classes []Class
+		for _, class := range pkg.Classes {
+			if classFilter.IsIncluded(coverage.GetClassPath(class.Name)) &&
+				sourceFilter.IsIncluded(GetClassFilePath(pkg, class)) {
+				classes = append(classes, class)
+			}
+		}
+		removed += len(pkg.Classes) - len(classes)
+		pkg.Classes = classes
+		if len(classes) > 0 {
+			packages = append(packages, pkg)
+		}
+	}
+	c.Packages = packages
+	return removed
+}
+
+// ExcludeLines removes the excluded lines, given by report file path, from
+// the classes and their methods. Methods and classes left without lines are
+// removed as well. It returns the number of lines removed.
+func (c *Coverage) ExcludeLines(excludedLines map[string]map[int]bool) int {
+
+	removed := 0
+	for i := range c.Packages {
+		pkg := &c.Packages[i]
+		var classes []Class
+		for _, class := range pkg.Classes {
+			excluded := excludedLines[GetClassFilePath(*pkg, class)]
+			if len(excluded) == 0 {
+				classes = append(classes, class)
+				continue
+			}
+
+			var lineCount int
+			class.Lines, lineCount = getIncludedLines(class.Lines, excluded)
+			removed += lineCount
+
+			var methods []Method
+			for _, method := range class.Methods {
+				method.Lines, lineCount = getIncludedLines(method.Lines, excluded)
+				if len(method.Lines) > 0 || lineCount == 0 {
+					methods = append(methods, method)
+				}
+			}
+			class.Methods = methods
+
+			if len(class.Lines) > 0 || len(class.Methods) > 0 {
+				classes = append(classes, class)
+			}
+		}
+		pkg.Classes = classes
+	}
+	return removed
+}
+
+func getIncludedLines(lines []Line, excluded map[int]bool) ([]Line, int) {
+	var included []Line
+	for _, line := range lines {
+		if !excluded[line.Number] {
+			included = append(included, line)
+		}
+	}
+	return included, len(lines) - len(included)
+}
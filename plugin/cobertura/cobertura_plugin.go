@@ -3,6 +3,7 @@ package cobertura
 import (
 	"fmt"
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
 	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
 	"github.com/sirupsen/logrus"
 	"os"
@@ -19,6 +20,7 @@ type CoberturaPlugin struct {
 type CoberturaPluginStateStore struct {
 	WorkSpacePath           string
 	CompleteCoverageXmlPath string
+	ParsedCoverage          *Coverage
 }
 
 func (c *CoberturaPlugin) Init(args *pd.Args) error {
@@ -57,68 +59,120 @@ func (c *CoberturaPlugin) Run() error {
 		return err
 	}
 
-	c.Stats, err = GetCoberturaCoverageMetrics(c.CompleteCoverageXmlPath)
+	parsedCoverage, err := ParseCoberturaCoverageXml(c.CompleteCoverageXmlPath)
+	if err != nil {
+		return pd.GetNewKindError(pd.ErrParse, "Error in ParseCoberturaCoverageXml: "+err.Error())
+	}
+	err = c.ApplyReportFilters(&parsedCoverage)
 	if err != nil {
 		return err
 	}
 
+	err = c.ApplySourceExclusions(&parsedCoverage)
+	if err != nil {
+		return err
+	}
+
+	c.ParsedCoverage = &parsedCoverage
+	c.Stats = calculateCoverage(parsedCoverage)
+
 	if c.InputArgs.PluginFailOnThreshold == true {
 		isGood := c.AnalyzeCoberturaThresholds()
 		if !isGood {
-			return pd.GetNewError("Cobertura thresholds not met")
+			return pd.GetNewKindError(pd.ErrThreshold, "Cobertura thresholds not met")
 		}
 	}
 
-	c.Stats.PrintToConsole()
+	c.Stats.LogStats()
 	return nil
 }
 
-func (c *CoberturaPlugin) AnalyzeCoberturaThresholds() bool {
+// ApplyReportFilters removes the classes left out by the class and source
+// inclusion and exclusion patterns before the coverage is calculated.
+func (c *CoberturaPlugin) ApplyReportFilters(parsedCoverage *Coverage) error {
 
-	if c.InputArgs.PluginFailOnThreshold == false {
-		return true
+	classFilter, sourceFilter, err := pd.GetReportFilters(*c.InputArgs)
+	if err != nil {
+		return err
 	}
 
-	type ThresholdsCompare struct {
-		ObservedValue float64
-		ExpectedValue float64
-		ThresholdType string
+	removed := parsedCoverage.Filter(classFilter, sourceFilter)
+	if removed > 0 {
+		logrus.Printf("%d classes excluded from the report by the class and source patterns\n", removed)
 	}
+	return nil
+}
+
+// ApplySourceExclusions removes the lines excluded by markers in the
+// source files before the coverage is calculated.
+func (c *CoberturaPlugin) ApplySourceExclusions(parsedCoverage *Coverage) error {
 
-	complexityDensity := float64(c.Stats.Complexity) / float64(c.Stats.LOC)
+	if c.InputArgs.SkipExclusionMarkers {
+		return nil
+	}
 
-	thresholdsCompareList := []ThresholdsCompare{
-		{c.Stats.BranchCoverage, c.InputArgs.MinimumBranchCoverage, "Branch"},
-		{c.Stats.ClassCoverage, c.InputArgs.MinimumClassCoverage, "Class"},
-		{c.Stats.LineCoverage, c.InputArgs.MinimumLineCoverage, "Line"},
-		{c.Stats.MethodCoverage, c.InputArgs.MinimumMethodCoverage, "Method"},
-		{c.Stats.PackageCoverage, c.InputArgs.MinimumPackageCoverage, "Package"},
-		{c.Stats.FileCoverage, c.InputArgs.MinimumFileCoverage, "File"},
+	exclusions, err := pd.GetSourceExclusions(parsedCoverage.ToCoverageReport(), *c.InputArgs, c.GetWorkSpaceDir())
+	if err != nil {
+		return pd.GetNewError("Error in ApplySourceExclusions: " + err.Error())
 	}
 
-	for _, thresholdCompare := range thresholdsCompareList {
-		if thresholdCompare.ObservedValue < thresholdCompare.ExpectedValue {
-			pd.LogPrintln(c, "CoberturaPlugin "+thresholdCompare.ThresholdType+" threshold not met",
-				" expected = ", thresholdCompare.ExpectedValue, " observed = ", thresholdCompare.ObservedValue)
-			logrus.Printf("Threshold type: %s threshold not met expected = %.2f observed = %.2f\n",
-				thresholdCompare.ThresholdType, thresholdCompare.ExpectedValue, thresholdCompare.ObservedValue)
-			return false
+	removed := parsedCoverage.ExcludeLines(exclusions)
+	if removed > 0 {
+		logrus.Printf("%d report lines excluded from coverage by source markers in %d files\n", removed, len(exclusions))
+	}
+	return nil
+}
+
+func (c *CoberturaPlugin) AnalyzeCoberturaThresholds() bool {
+
+	if c.InputArgs.PluginFailOnThreshold == false {
+		return true
+	}
+
+	isGood := true
+	for _, thresholdCheck := range c.GetThresholdChecks() {
+		if thresholdCheck.IsWarningRaised() {
+			logrus.Warnf("CoberturaPlugin %s\n", thresholdCheck.String())
+		} else if thresholdCheck.IsFailed() {
+			pd.LogPrintln(c, "CoberturaPlugin "+thresholdCheck.String())
+			isGood = false
 		}
 	}
 
-	if (c.Stats.LOC < c.InputArgs.MinimumLOC) ||
-		(c.Stats.Complexity > c.InputArgs.MinimumComplexityCoverage) ||
-		complexityDensity > c.InputArgs.MaxComplexityDensityCoverage {
+	return isGood
+}
+
+func (c *CoberturaPlugin) GetThresholdChecks() []pd.ThresholdCheck {
 
-		pd.LogPrintln(c, "CoberturaPlugin Complexity threshold not met",
-			" expected = ", c.InputArgs.MinimumComplexityCoverage, " observed = ", c.Stats.Complexity)
-		fmt.Println("CoberturaPlugin Complexity threshold not met",
-			" expected = ", c.InputArgs.MinimumComplexityCoverage, " observed = ", c.Stats.Complexity)
+	if c.InputArgs == nil {
+		return nil
+	}
 
-		return false
+	complexityDensity := 0.0
+	if c.Stats.LOC > 0 {
+		complexityDensity = float64(c.Stats.Complexity) / float64(c.Stats.LOC)
 	}
 
-	return true
+	return pd.ApplyThresholdSeverities(pd.GetConfiguredThresholdChecks([]pd.ThresholdCheck{
+		pd.GetMinimumThresholdCheck("Branch", coverage.BranchCounter,
+			c.Stats.BranchCoverage, c.InputArgs.MinimumBranchCoverage, true),
+		pd.GetMinimumThresholdCheck("Class", coverage.ClassCounter,
+			c.Stats.ClassCoverage, c.InputArgs.MinimumClassCoverage, true),
+		pd.GetMinimumThresholdCheck("Line", coverage.LineCounter,
+			c.Stats.LineCoverage, c.InputArgs.MinimumLineCoverage, true),
+		pd.GetMinimumThresholdCheck("Method", coverage.MethodCounter,
+			c.Stats.MethodCoverage, c.InputArgs.MinimumMethodCoverage, true),
+		pd.GetMinimumThresholdCheck("Package", "",
+			c.Stats.PackageCoverage, c.InputArgs.MinimumPackageCoverage, true),
+		pd.GetMinimumThresholdCheck("File", "",
+			c.Stats.FileCoverage, c.InputArgs.MinimumFileCoverage, true),
+		pd.GetMinimumValueThresholdCheck("LOC",
+			float64(c.Stats.LOC), float64(c.InputArgs.MinimumLOC), true),
+		pd.GetMaximumThresholdCheck("Complexity",
+			float64(c.Stats.Complexity), float64(c.InputArgs.MinimumComplexityCoverage)),
+		pd.GetMaximumThresholdCheck("Complexity Density",
+			complexityDensity, c.InputArgs.MaxComplexityDensityCoverage),
+	}), c.InputArgs.EnvPluginInputArgs)
 }
 
 func (c *CoberturaPlugin) LocateCoberturaCoverageXmlPath() error {
@@ -140,7 +194,7 @@ func (c *CoberturaPlugin) LocateCoberturaCoverageXmlPath() error {
 	}
 
 	if len(matchedDirs) < 1 {
-		return pd.GetNewError("No Cobertura report xml found")
+		return pd.GetNewKindError(pd.ErrNoReports, "No Cobertura report xml found")
 	}
 
 	relativeXmlReportPath := matchedDirs[0]
@@ -151,6 +205,7 @@ func (c *CoberturaPlugin) LocateCoberturaCoverageXmlPath() error {
 		return err
 	}
 
+	logrus.Printf("Cobertura report xml found at %s\n", completeXmlPath)
 	c.CompleteCoverageXmlPath = completeXmlPath
 
 	return nil
@@ -191,6 +246,13 @@ func (c *CoberturaPlugin) PersistResults() error {
 	return nil
 }
 
+func (c *CoberturaPlugin) GetCoverageReport() *coverage.Report {
+	if c.ParsedCoverage == nil {
+		return nil
+	}
+	return c.ParsedCoverage.ToCoverageReport()
+}
+
 func (c *CoberturaPlugin) GetPluginType() string {
 	return "cobertura"
 }
@@ -2,6 +2,8 @@ package plugin
 
 import (
 	"context"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/cobertura"
 	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
 	"testing"
 )
@@ -13,8 +15,8 @@ func TestCoberturaGoodThreshold(t *testing.T) {
 		MinimumClassCoverage:         30,
 		MinimumLineCoverage:          5.0,
 		MinimumMethodCoverage:        0,
-		MinimumPackageCoverage:       100,
-		MinimumFileCoverage:          50.0,
+		MinimumPackageCoverage:       30,
+		MinimumFileCoverage:          30.0,
 		MinimumLOC:                   15,
 		MinimumComplexityCoverage:    10,
 		MaxComplexityDensityCoverage: 0.50,
@@ -35,8 +37,8 @@ func TestCoberturaBadThreshold(t *testing.T) {
 		MinimumClassCoverage:         40,
 		MinimumLineCoverage:          5.0,
 		MinimumMethodCoverage:        0,
-		MinimumPackageCoverage:       100,
-		MinimumFileCoverage:          50.0,
+		MinimumPackageCoverage:       30,
+		MinimumFileCoverage:          30.0,
 		MinimumLOC:                   15,
 		MinimumComplexityCoverage:    10,
 		MaxComplexityDensityCoverage: 0.50,
@@ -57,8 +59,8 @@ func TestCoberturaNoFailOnBadThreshold(t *testing.T) {
 		MinimumClassCoverage:         40,
 		MinimumLineCoverage:          5.0,
 		MinimumMethodCoverage:        0,
-		MinimumPackageCoverage:       100,
-		MinimumFileCoverage:          50.0,
+		MinimumPackageCoverage:       30,
+		MinimumFileCoverage:          30.0,
 		MinimumLOC:                   15,
 		MinimumComplexityCoverage:    10,
 		MaxComplexityDensityCoverage: 0.50,
@@ -80,8 +82,8 @@ func TestCoberturaBadComplexityCoverage(t *testing.T) {
 		MinimumClassCoverage:         30,
 		MinimumLineCoverage:          5.0,
 		MinimumMethodCoverage:        0,
-		MinimumPackageCoverage:       100,
-		MinimumFileCoverage:          50.0,
+		MinimumPackageCoverage:       30,
+		MinimumFileCoverage:          30.0,
 		MinimumLOC:                   15,
 		MinimumComplexityCoverage:    5,
 		MaxComplexityDensityCoverage: 0.50,
@@ -102,8 +104,8 @@ func TestCoberturaBadComplexityDensityCoverage(t *testing.T) {
 		MinimumClassCoverage:         30,
 		MinimumLineCoverage:          5.0,
 		MinimumMethodCoverage:        0,
-		MinimumPackageCoverage:       100,
-		MinimumFileCoverage:          50.0,
+		MinimumPackageCoverage:       30,
+		MinimumFileCoverage:          30.0,
 		MinimumLOC:                   15,
 		MinimumComplexityCoverage:    10,
 		MaxComplexityDensityCoverage: 0.25,
@@ -130,3 +132,29 @@ func GetTestCoberturaNewArgs(envPluginInputArgs pd.EnvPluginInputArgs) pd.Args {
 	args.ExecFilesPathPattern = "**/coverage.xml"
 	return args
 }
+
+func TestCoberturaUnsetThresholds(t *testing.T) {
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	plugin, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaUnsetThresholds: unset thresholds should pass: %s", err.Error())
+	}
+	if checks := plugin.GetThresholdChecks(); len(checks) != 0 {
+		t.Errorf("Error in TestCoberturaUnsetThresholds: expected no checks, got %+v", checks)
+	}
+
+	stats := plugin.(*cobertura.CoberturaPlugin).Stats
+	if fmt.Sprintf("%.2f %.2f %.2f", stats.PackageCoverage, stats.FileCoverage, stats.MethodCoverage) !=
+		"33.33 33.33 22.22" {
+		t.Errorf("Error in TestCoberturaUnsetThresholds: unexpected package, file and method coverage %+v", stats)
+	}
+
+	emptyPlugin := &cobertura.CoberturaPlugin{InputArgs: &pd.Args{EnvPluginInputArgs: pd.EnvPluginInputArgs{
+		MaxComplexityDensityCoverage: 0.50,
+	}}}
+	checks := emptyPlugin.GetThresholdChecks()
+	if len(checks) != 1 || !checks[0].Passed {
+		t.Errorf("Error in TestCoberturaUnsetThresholds: empty report should pass, got %+v", checks)
+	}
+}
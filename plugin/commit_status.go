@@ -0,0 +1,169 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/diff"
+	"github.com/harness-community/drone-coverage-report/plugin/export"
+	"github.com/harness-community/drone-coverage-report/plugin/markdown"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/harness-community/drone-coverage-report/plugin/scm"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// PublishCommitStatuses publishes one status per configured metric on the
+// commit of the build, e.g. "coverage/line: 78.40% (+1.20%)". The state of
+// every status follows the threshold verdict. Uncovered changed lines are
+// annotated on the first status when a diff is configured.
+func PublishCommitStatuses(ctx context.Context, report *coverage.Report, summary pd.CoverageSummary,
+	baseline *pd.CoverageSummary, args pd.Args, workSpaceDir string) error {
+
+	if args.Commit.Rev == "" {
+		logrus.Printf("No commit sha, skipping the coverage commit status\n")
+		return nil
+	}
+
+	publisher, err := scm.GetNewStatusPublisher(GetScmConfig(args), nil)
+	if err != nil {
+		return err
+	}
+
+	counterTypes, err := GetCommitStatusCounterTypes(args.CommitStatusMetrics)
+	if err != nil {
+		return err
+	}
+
+	changedLines, err := GetChangedLines(args, workSpaceDir)
+	if err != nil {
+		return err
+	}
+
+	statusContext := args.CommitStatusContext
+	if statusContext == "" {
+		statusContext = DefaultCommitStatusContext
+	}
+	targetUrl := args.Build.Link
+	if targetUrl == "" {
+		targetUrl = args.Repo.Link
+	}
+
+	annotated := false
+	for _, counterType := range counterTypes {
+		if !summary.Counters.Has(counterType) {
+			logrus.Printf("No %s coverage in the report, skipping its commit status\n",
+				strings.ToLower(counterType.DisplayName()))
+			continue
+		}
+
+		status := scm.CommitStatus{
+			Name:        statusContext + "/" + strings.ToLower(string(counterType)),
+			Description: GetCommitStatusDescription(summary, baseline, counterType),
+			TargetURL:   targetUrl,
+			Passed:      summary.Passed,
+		}
+		if !annotated && changedLines != nil {
+			status.Annotations = GetUncoveredChangedLineAnnotations(report, changedLines)
+			status.Summary = fmt.Sprintf("%d uncovered or partially covered ranges in changed lines.",
+				len(status.Annotations))
+			annotated = true
+		}
+
+		err = publisher.PublishStatus(ctx, status)
+		if err != nil {
+			return err
+		}
+		logrus.Printf("Commit status %s: %s published\n", status.Name, status.Description)
+	}
+
+	return nil
+}
+
+func GetCommitStatusCounterTypes(metricsCsv string) ([]coverage.CounterType, error) {
+	if strings.TrimSpace(metricsCsv) == "" {
+		metricsCsv = DefaultCommitStatusMetrics
+	}
+
+	var counterTypes []coverage.CounterType
+	for _, metric := range pd.ToStringArrayFromCsvString(metricsCsv) {
+		counterType := coverage.CounterType(strings.ToUpper(strings.TrimSpace(metric)))
+		known := false
+		for _, knownType := range coverage.AllCounterTypes {
+			known = known || knownType == counterType
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown commit status metric: %s", metric)
+		}
+		counterTypes = append(counterTypes, counterType)
+	}
+	return counterTypes, nil
+}
+
+func GetCommitStatusDescription(summary pd.CoverageSummary, baseline *pd.CoverageSummary,
+	counterType coverage.CounterType) string {
+
+	description := fmt.Sprintf("%.2f%%", summary.Counters.Get(counterType).Percentage())
+	if delta, ok := summary.GetDelta(baseline, counterType); ok {
+		description += " (" + markdown.FormatDelta(delta) + ")"
+	}
+	return description
+}
+
+// GetChangedLines reads the changed lines from the diff file, or from git
+// relative to the diff base. Without either nil is returned.
+func GetChangedLines(args pd.Args, workSpaceDir string) (diff.ChangedLines, error) {
+	if args.DiffFile != "" {
+		return diff.ReadDiffFile(pd.GetWorkSpaceRelativePath(workSpaceDir, args.DiffFile))
+	}
+	if args.DiffBase != "" {
+		return diff.GetGitDiff(context.Background(), workSpaceDir, args.DiffBase)
+	}
+	return nil, nil
+}
+
+// GetUncoveredChangedLineAnnotations returns an annotation per range of
+// uncovered changed lines and per partially covered changed line.
+func GetUncoveredChangedLineAnnotations(report *coverage.Report, changedLines diff.ChangedLines) []scm.Annotation {
+
+	var annotations []scm.Annotation
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			diffPath, lines := changedLines.GetFileLines(file.GetSourcePath())
+			if len(lines) == 0 {
+				continue
+			}
+
+			uncoveredRanges := coverage.GetLineRanges(file.Lines, func(line coverage.Line) bool {
+				return !line.IsCovered() && diff.Contains(lines, line.Number)
+			})
+			for _, lineRange := range uncoveredRanges {
+				annotations = append(annotations, scm.Annotation{
+					Path:      diffPath,
+					StartLine: lineRange.Start,
+					EndLine:   lineRange.End,
+					Title:     "Uncovered lines",
+					Message:   export.GetUncoveredLinesMessage(lineRange),
+				})
+			}
+
+			for _, line := range file.Lines {
+				if line.IsPartiallyCovered() && diff.Contains(lines, line.Number) {
+					annotations = append(annotations, scm.Annotation{
+						Path:      diffPath,
+						StartLine: line.Number,
+						EndLine:   line.Number,
+						Title:     "Partially covered line",
+						Message:   export.GetPartiallyCoveredLineMessage(line),
+					})
+				}
+			}
+		}
+	}
+	return annotations
+}
+
+const (
+	DefaultCommitStatusContext = "coverage"
+	DefaultCommitStatusMetrics = "line"
+)
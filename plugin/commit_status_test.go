@@ -0,0 +1,279 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/harness-community/drone-coverage-report/plugin/diff"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/harness-community/drone-coverage-report/plugin/scm"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// RecordingScmServer records the requests sent to it and answers every one
+// of them with a check run id.
+type RecordingScmServer struct {
+	mutex    sync.Mutex
+	requests []RecordedRequest
+}
+
+func (s *RecordingScmServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	body := map[string]interface{}{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	s.requests = append(s.requests, RecordedRequest{Method: r.Method, Path: r.URL.EscapedPath(), Body: body})
+
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(`{"id": 42}`))
+}
+
+const TestCalculatorDiff = `diff --git a/src/main/java/com/example/package1/Calculator.java b/src/main/java/com/example/package1/Calculator.java
+index 1111111..2222222 100644
+--- a/src/main/java/com/example/package1/Calculator.java
++++ b/src/main/java/com/example/package1/Calculator.java
+@@ -7,0 +8,12 @@ public class Calculator {
++    public int subtract(int a, int b) {
++        return a - b;
++    }
++
++    public int multiply(int a, int b) {
++        return a * b;
++    }
++
++    public int divide(int a, int b) {
++        if (b == 0) throw new IllegalArgumentException("Cannot divide by zero");
++        return a / b;
++    }
+diff --git a/README.md b/README.md
+deleted file mode 100644
+--- a/README.md
++++ /dev/null
+@@ -1,2 +0,0 @@
+-# Sample
+-text
+`
+
+func TestCoberturaGithubCheckRunWithAnnotations(t *testing.T) {
+
+	recordingServer := &RecordingScmServer{}
+	server := httptest.NewServer(recordingServer)
+	defer server.Close()
+
+	diffPath := filepath.Join(t.TempDir(), "changes.diff")
+	err := os.WriteFile(diffPath, []byte(TestCalculatorDiff), 0644)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaGithubCheckRunWithAnnotations: %s", err.Error())
+	}
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{MinimumLineCoverage: 40.0})
+	args.PluginFailOnThreshold = false
+	args.Repo.Slug = "octocat/hello-world"
+	args.Repo.Link = "https://github.com/octocat/hello-world"
+	args.Commit.Rev = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+	args.Build.Link = "https://drone.example.com/octocat/hello-world/1"
+	args.CommitStatus = true
+	args.CommitStatusMetrics = "line,branch"
+	args.ScmBaseUrl = server.URL
+	args.ScmToken = "test-token"
+	args.DiffFile = diffPath
+
+	_, err = Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaGithubCheckRunWithAnnotations: %s", err.Error())
+	}
+
+	if len(recordingServer.requests) != 2 {
+		t.Fatalf("Error in TestCoberturaGithubCheckRunWithAnnotations: expected 2 check runs, found %d",
+			len(recordingServer.requests))
+	}
+
+	lineCheckRun := recordingServer.requests[0]
+	if lineCheckRun.Method != http.MethodPost || lineCheckRun.Path != "/repos/octocat/hello-world/check-runs" {
+		t.Errorf("Error in TestCoberturaGithubCheckRunWithAnnotations: unexpected request %s %s",
+			lineCheckRun.Method, lineCheckRun.Path)
+	}
+	expectedFields := map[string]interface{}{
+		"name":        "coverage/line",
+		"head_sha":    args.Commit.Rev,
+		"status":      "completed",
+		"conclusion":  "failure",
+		"details_url": args.Build.Link,
+	}
+	for key, expected := range expectedFields {
+		if lineCheckRun.Body[key] != expected {
+			t.Errorf("Error in TestCoberturaGithubCheckRunWithAnnotations: %s is %v, expected %v",
+				key, lineCheckRun.Body[key], expected)
+		}
+	}
+
+	output := lineCheckRun.Body["output"].(map[string]interface{})
+	if output["title"] != "11.76%" {
+		t.Errorf("Error in TestCoberturaGithubCheckRunWithAnnotations: unexpected title %v", output["title"])
+	}
+	annotations, _ := output["annotations"].([]interface{})
+	if len(annotations) != 1 {
+		t.Fatalf("Error in TestCoberturaGithubCheckRunWithAnnotations: expected 1 annotation, found %d",
+			len(annotations))
+	}
+	annotation := annotations[0].(map[string]interface{})
+	if annotation["path"] != "src/main/java/com/example/package1/Calculator.java" ||
+		annotation["start_line"] != 9.0 || annotation["end_line"] != 18.0 {
+		t.Errorf("Error in TestCoberturaGithubCheckRunWithAnnotations: unexpected annotation %v", annotation)
+	}
+
+	branchCheckRun := recordingServer.requests[1]
+	if branchCheckRun.Body["name"] != "coverage/branch" || branchCheckRun.Body["output"].(map[string]interface{})["annotations"] != nil {
+		t.Errorf("Error in TestCoberturaGithubCheckRunWithAnnotations: unexpected branch check run %v",
+			branchCheckRun.Body)
+	}
+}
+
+func TestCommitStatusPublishers(t *testing.T) {
+
+	testCases := []struct {
+		provider string
+		path     string
+		state    string
+		field    string
+	}{
+		{scm.GitlabProvider, "/projects/group%2Fproject/statuses/abc123", "failed", "name"},
+		{scm.BitbucketProvider, "/repositories/group/project/commit/abc123/statuses/build", "FAILED", "key"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.provider, func(t *testing.T) {
+			recordingServer := &RecordingScmServer{}
+			server := httptest.NewServer(recordingServer)
+			defer server.Close()
+
+			publisher, err := scm.GetNewStatusPublisher(scm.Config{
+				Provider:  testCase.provider,
+				BaseURL:   server.URL,
+				Token:     "test-token",
+				RepoSlug:  "group/project",
+				CommitSha: "abc123",
+			}, nil)
+			if err != nil {
+				t.Fatalf("Error in TestCommitStatusPublishers: %s", err.Error())
+			}
+
+			err = publisher.PublishStatus(context.TODO(), scm.CommitStatus{
+				Name:        "coverage/line",
+				Description: "78.40% (+1.20%)",
+				TargetURL:   "https://drone.example.com/group/project/1",
+			})
+			if err != nil {
+				t.Fatalf("Error in TestCommitStatusPublishers: %s", err.Error())
+			}
+
+			request := recordingServer.requests[0]
+			if request.Path != testCase.path {
+				t.Errorf("Error in TestCommitStatusPublishers: unexpected path %s", request.Path)
+			}
+			if request.Body["state"] != testCase.state || request.Body[testCase.field] != "coverage/line" ||
+				request.Body["description"] != "78.40% (+1.20%)" {
+				t.Errorf("Error in TestCommitStatusPublishers: unexpected body %v", request.Body)
+			}
+		})
+	}
+}
+
+func TestBitbucketStatusWithoutLink(t *testing.T) {
+
+	recordingServer := &RecordingScmServer{}
+	server := httptest.NewServer(recordingServer)
+	defer server.Close()
+
+	publisher, err := scm.GetNewStatusPublisher(scm.Config{
+		Provider:  scm.BitbucketProvider,
+		BaseURL:   server.URL,
+		Token:     "test-token",
+		RepoSlug:  "group/project",
+		CommitSha: "abc123",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Error in TestBitbucketStatusWithoutLink: %s", err.Error())
+	}
+
+	err = publisher.PublishStatus(context.TODO(), scm.CommitStatus{Name: "coverage/line", Passed: true})
+	if err != nil {
+		t.Fatalf("Error in TestBitbucketStatusWithoutLink: %s", err.Error())
+	}
+	if url := recordingServer.requests[0].Body["url"]; url != "https://bitbucket.org/group/project/commits/abc123" {
+		t.Errorf("Error in TestBitbucketStatusWithoutLink: unexpected url %v", url)
+	}
+}
+
+func TestCommitStatusWithoutThresholds(t *testing.T) {
+
+	recordingServer := &RecordingScmServer{}
+	server := httptest.NewServer(recordingServer)
+	defer server.Close()
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.Repo.Slug = "octocat/hello-world"
+	args.Commit.Rev = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+	args.CommitStatus = true
+	args.ScmProvider = scm.GitlabProvider
+	args.ScmBaseUrl = server.URL
+	args.ScmToken = "test-token"
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCommitStatusWithoutThresholds: %s", err.Error())
+	}
+	if len(recordingServer.requests) != 1 || recordingServer.requests[0].Body["state"] != "success" {
+		t.Errorf("Error in TestCommitStatusWithoutThresholds: expected a successful status, got %+v",
+			recordingServer.requests)
+	}
+}
+
+func TestParseUnifiedDiff(t *testing.T) {
+
+	diffContent := TestCalculatorDiff + `diff --git a/src/Main.go b/src/Main.go
+--- a/src/Main.go
++++ b/src/Main.go
+@@ -3,3 +3,4 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	fmt.Println(a, b)
+@@ -20 +21 @@
+-	return
++	return nil
+\ No newline at end of file
+`
+
+	changedLines, err := diff.ParseUnifiedDiff(strings.NewReader(diffContent))
+	if err != nil {
+		t.Fatalf("Error in TestParseUnifiedDiff: %s", err.Error())
+	}
+
+	expected := diff.ChangedLines{
+		"src/main/java/com/example/package1/Calculator.java": {8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19},
+		"src/Main.go": {4, 5, 21},
+	}
+	if !reflect.DeepEqual(changedLines, expected) {
+		t.Errorf("Error in TestParseUnifiedDiff: expected %v, found %v", expected, changedLines)
+	}
+
+	diffPath, lines := changedLines.GetFileLines("com/example/package1/Calculator.java")
+	if diffPath != "src/main/java/com/example/package1/Calculator.java" || len(lines) != 12 {
+		t.Errorf("Error in TestParseUnifiedDiff: report path not matched to %s", diffPath)
+	}
+}
@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"context"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoberturaConfigFile(t *testing.T) {
+
+	workSpaceDir := t.TempDir()
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, workSpaceDir)
+	t.Setenv("PLUGIN_THRESHOLD_LINE", "5")
+
+	CopyToWorkSpace(t, workSpaceDir, "cobertura-sample/bad-metrics-project/coverage.xml", nil)
+	config := `
+tool: cobertura
+reports_path_pattern: "**/coverage.xml"
+fail_on_threshold: true
+threshold_line: 90
+threshold_complexity: 10
+threshold_complexity_density: 1.0
+summary_json_path: summary.json
+source_exclusion_pattern:
+  - "**/Generated*.java"
+  - "**/*Test.java"
+package_rules:
+  - pattern: com/example/package1
+    threshold_line: 30
+  - pattern: "com.example.package[23]"
+    threshold_line: 10
+`
+	err := os.WriteFile(filepath.Join(workSpaceDir, pd.DefaultConfigFileName), []byte(config), 0644)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaConfigFile: %s", err.Error())
+	}
+
+	args := pd.Args{}
+	args.MinimumLineCoverage = 5
+	err = pd.ApplyConfigFile(&args, pd.GetTestWorkSpaceDir())
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaConfigFile: %s", err.Error())
+	}
+	if args.PluginToolType != pd.CoberturaPluginType || args.MinimumLineCoverage != 5 ||
+		args.SourceExclusionPattern != "**/Generated*.java,**/*Test.java" || len(args.PackageRules) != 2 {
+		t.Fatalf("Error in TestCoberturaConfigFile: unexpected settings %+v", args)
+	}
+
+	_, err = Exec(context.TODO(), args)
+	if err == nil || !strings.Contains(err.Error(), "2 package coverage rules not met") {
+		t.Fatalf("Error in TestCoberturaConfigFile: expected package rules to fail, got %v", err)
+	}
+
+	summary, err := pd.ReadCoverageSummary(filepath.Join(workSpaceDir, "summary.json"))
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaConfigFile: %s", err.Error())
+	}
+	passedByPackage := map[string]bool{}
+	for _, check := range summary.ThresholdChecks {
+		if check.Package != "" {
+			passedByPackage[check.Package] = check.Passed
+		}
+	}
+	if len(passedByPackage) != 3 || !passedByPackage["com.example.package1"] ||
+		passedByPackage["com.example.package2"] || passedByPackage["com.example.package3"] {
+		t.Errorf("Error in TestCoberturaConfigFile: unexpected package rule checks %v", summary.ThresholdChecks)
+	}
+}
+
+func TestConfigFileValidation(t *testing.T) {
+
+	testCases := []struct {
+		config        string
+		expectedError string
+	}{
+		{"threshold_lin: 80", `line 1: unknown key "threshold_lin"`},
+		{"tool: cobertura\nthreshold_line: high", `line 2: invalid value for key "threshold_line": expected a number`},
+		{"fail_on_threshold: \"yes\"", `invalid value for key "fail_on_threshold": expected true or false`},
+		{"tool: gcov", `invalid value for key "tool": unknown tool gcov`},
+		{"html_report_dir: {path: html}", `invalid value for key "html_report_dir"`},
+		{"package_rules:\n  - threshold_line: 80", `missing key "package_rules[0].pattern"`},
+		{"package_rules:\n  - pattern: a/**\n    threshold_line: 120", `key "package_rules[0].threshold_line"`},
+		{"package_rules:\n  - pattern: a/**\n    threshold_lines: 80", `line 3: unknown key "package_rules[0].threshold_lines"`},
+		{"- tool", "expected a mapping"},
+	}
+
+	noEnv := func(string) (string, bool) { return "", false }
+	for _, testCase := range testCases {
+		err := pd.ParseConfigFile([]byte(testCase.config), &pd.Args{}, noEnv)
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+			t.Errorf("Error in TestConfigFileValidation: expected %q for %q, got %v",
+				testCase.expectedError, testCase.config, err)
+		}
+	}
+
+	args := pd.Args{}
+	args.ConfigFile = "missing.yml"
+	err := pd.ApplyConfigFile(&args, t.TempDir())
+	if err == nil {
+		t.Errorf("Error in TestConfigFileValidation: expected an error for a missing config file")
+	}
+}
@@ -0,0 +1,281 @@
+package coverage
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Report is the tool independent coverage model shared by the report
+// renderers. Each coverage tool converts its own parsed report into it.
+type Report struct {
+	Tool     string     `json:"tool"`
+	Name     string     `json:"name,omitempty"`
+	Sources  []string   `json:"sources,omitempty"`
+	Counters Counters   `json:"counters"`
+	Packages []*Package `json:"packages"`
+}
+
+type Package struct {
+	Name string `json:"name"`
+	// Module is the module of multi-module reports, like the group of a
+	// JaCoCo report-aggregate report, or "" for other reports.
+	Module   string   `json:"module,omitempty"`
+	Counters Counters `json:"counters"`
+	Files    []*File  `json:"files"`
+}
+
+// Module is the coverage of the packages of one module.
+type Module struct {
+	Name     string   `json:"name"`
+	Counters Counters `json:"counters"`
+}
+
+// GetModules returns the modules of the report in the order of their first
+// package, with the counters of their packages added up. Reports without
+// modules have none.
+func (r *Report) GetModules() []*Module {
+	var modules []*Module
+	modulesByName := map[string]*Module{}
+	for _, pkg := range r.Packages {
+		if pkg.Module == "" {
+			continue
+		}
+		module, ok := modulesByName[pkg.Module]
+		if !ok {
+			module = &Module{Name: pkg.Module, Counters: Counters{}}
+			modulesByName[pkg.Module] = module
+			modules = append(modules, module)
+		}
+		module.Counters.Add(pkg.Counters)
+	}
+	return modules
+}
+
+type File struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// SourcePath is the workspace relative path of the source file, when
+	// the PathMapper found it.
+	SourcePath string   `json:"sourcePath,omitempty"`
+	Counters   Counters `json:"counters"`
+	Lines      []Line   `json:"lines,omitempty"`
+}
+
+// GetSourcePath returns the workspace relative path of the file if known
+// and its report path otherwise.
+func (f *File) GetSourcePath() string {
+	if f.SourcePath != "" {
+		return f.SourcePath
+	}
+	return f.Path
+}
+
+// GetUncoveredRanges returns the ranges of the lines of the file no test
+// executed.
+func (f *File) GetUncoveredRanges() []LineRange {
+	return GetLineRanges(f.Lines, func(line Line) bool {
+		return !line.IsCovered()
+	})
+}
+
+// GetPartiallyCoveredLines returns the executed lines of the file with
+// branches no test took.
+func (f *File) GetPartiallyCoveredLines() []Line {
+	var partialLines []Line
+	for _, line := range f.Lines {
+		if line.IsPartiallyCovered() {
+			partialLines = append(partialLines, line)
+		}
+	}
+	return partialLines
+}
+
+type Line struct {
+	Number          int `json:"number"`
+	Hits            int `json:"hits"`
+	Branches        int `json:"branches,omitempty"`
+	CoveredBranches int `json:"coveredBranches,omitempty"`
+}
+
+func (l Line) IsCovered() bool {
+	return l.Hits > 0
+}
+
+func (l Line) IsPartiallyCovered() bool {
+	return l.Hits > 0 && l.Branches > 0 && l.CoveredBranches < l.Branches
+}
+
+type CounterType string
+
+const (
+	InstructionCounter CounterType = "INSTRUCTION"
+	BranchCounter      CounterType = "BRANCH"
+	LineCounter        CounterType = "LINE"
+	ComplexityCounter  CounterType = "COMPLEXITY"
+	MethodCounter      CounterType = "METHOD"
+	ClassCounter       CounterType = "CLASS"
+)
+
+// DisplayName returns the counter type as shown in summaries, e.g. "Line".
+func (c CounterType) DisplayName() string {
+	name := strings.ToLower(string(c))
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// AllCounterTypes lists the counter types in the order they are displayed.
+var AllCounterTypes = []CounterType{
+	InstructionCounter, BranchCounter, LineCounter, ComplexityCounter, MethodCounter, ClassCounter,
+}
+
+type Counter struct {
+	Covered int `json:"covered"`
+	Missed  int `json:"missed"`
+}
+
+func (c Counter) Total() int {
+	return c.Covered + c.Missed
+}
+
+func (c Counter) Percentage() float64 {
+	if c.Total() == 0 {
+		return 0.0
+	}
+	return float64(c.Covered) / float64(c.Total()) * 100
+}
+
+type Counters map[CounterType]Counter
+
+func (c Counters) Get(counterType CounterType) Counter {
+	return c[counterType]
+}
+
+func (c Counters) Has(counterType CounterType) bool {
+	_, ok := c[counterType]
+	return ok
+}
+
+func (c Counters) Add(other Counters) {
+	for counterType, counter := range other {
+		current := c[counterType]
+		current.Covered += counter.Covered
+		current.Missed += counter.Missed
+		c[counterType] = current
+	}
+}
+
+// Recompute rebuilds the line and branch counters of every file from its
+// lines, and the package and report counters from their children. Elements
+// without children keep the counters reported by the tool.
+func (r *Report) Recompute() {
+	reportCounters := Counters{}
+	for _, pkg := range r.Packages {
+		pkgCounters := Counters{}
+		for _, file := range pkg.Files {
+			if len(file.Lines) > 0 {
+				if file.Counters == nil {
+					file.Counters = Counters{}
+				}
+				file.Counters[LineCounter], file.Counters[BranchCounter] = GetLineCounters(file.Lines)
+			}
+			pkgCounters.Add(file.Counters)
+		}
+		if len(pkg.Files) > 0 {
+			pkg.Counters = pkgCounters
+		}
+		reportCounters.Add(pkg.Counters)
+	}
+	if len(r.Packages) > 0 || r.Counters == nil {
+		r.Counters = reportCounters
+	}
+}
+
+func GetLineCounters(lines []Line) (Counter, Counter) {
+	var lineCounter, branchCounter Counter
+	for _, line := range lines {
+		if line.IsCovered() {
+			lineCounter.Covered++
+		} else {
+			lineCounter.Missed++
+		}
+		branchCounter.Covered += line.CoveredBranches
+		branchCounter.Missed += line.Branches - line.CoveredBranches
+	}
+	return lineCounter, branchCounter
+}
+
+// SortLines orders lines by number and merges duplicate entries, which
+// Cobertura emits when several classes share a source file.
+func SortLines(lines []Line) []Line {
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].Number < lines[j].Number
+	})
+
+	var merged []Line
+	for _, line := range lines {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].Number == line.Number {
+			merged[last].Hits += line.Hits
+			if line.Branches > merged[last].Branches {
+				merged[last].Branches = line.Branches
+			}
+			if line.CoveredBranches > merged[last].CoveredBranches {
+				merged[last].CoveredBranches = line.CoveredBranches
+			}
+			continue
+		}
+		merged = append(merged, line)
+	}
+	return merged
+}
+
+// PackagePath returns the package name as a slash separated path, which is
+// how source files are laid out below a source directory.
+func PackagePath(packageName string) string {
+	return strings.ReplaceAll(packageName, ".", "/")
+}
+
+func GetFilePath(packageName, fileName string) string {
+	if strings.Contains(fileName, "/") {
+		return fileName
+	}
+	return path.Join(PackagePath(packageName), fileName)
+}
+
+// LineRange is an inclusive range of source line numbers.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return strconv.Itoa(r.Start) + "-" + strconv.Itoa(r.End)
+}
+
+// GetLineRanges groups the sorted lines matching the filter into ranges of
+// consecutive reported lines. Lines without code, which are not reported,
+// do not break a range.
+func GetLineRanges(lines []Line, filter func(Line) bool) []LineRange {
+	var ranges []LineRange
+	inRange := false
+	for _, line := range lines {
+		if !filter(line) {
+			inRange = false
+			continue
+		}
+		if inRange {
+			ranges[len(ranges)-1].End = line.Number
+			continue
+		}
+		ranges = append(ranges, LineRange{Start: line.Number, End: line.Number})
+		inRange = true
+	}
+	return ranges
+}
@@ -0,0 +1,193 @@
+package coverage
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	IgnoreMarker      = "coverage:ignore"
+	IgnoreStartMarker = "coverage:ignore-start"
+	IgnoreEndMarker   = "coverage:ignore-end"
+	NoCoverPragma     = "pragma: no cover"
+)
+
+var generatedAnnotationRegexp = regexp.MustCompile(`(^|\s)@([\w.]+\.)?Generated\b`)
+
+// GetExcludedLines returns the numbers of the source lines excluded from
+// coverage by markers in the source:
+//   - coverage:ignore in a comment after code excludes that line, or the
+//     whole block when the line opens one. On a line of its own it excludes
+//     the declaration or statement that follows.
+//   - coverage:ignore-start and coverage:ignore-end exclude the lines
+//     between them.
+//   - # pragma: no cover excludes the line, and the indented block that
+//     follows when the line ends with a colon.
+//   - a Java @Generated annotation excludes the annotated declaration.
+func GetExcludedLines(sourceLines []string) map[int]bool {
+
+	excluded := map[int]bool{}
+	excludeRange := func(start, end int) {
+		for i := start; i <= end; i++ {
+			excluded[i+1] = true
+		}
+	}
+
+	ignoreStart := -1
+	for i := 0; i < len(sourceLines); i++ {
+		line := sourceLines[i]
+		switch {
+		case strings.Contains(line, IgnoreStartMarker):
+			if ignoreStart < 0 {
+				ignoreStart = i
+			}
+		case strings.Contains(line, IgnoreEndMarker):
+			if ignoreStart >= 0 {
+				excludeRange(ignoreStart, i)
+				ignoreStart = -1
+			}
+		case ignoreStart >= 0:
+		case strings.Contains(line, NoCoverPragma):
+			start := i
+			if isCommentLine(line) {
+				start = getNextCodeLine(sourceLines, i)
+			}
+			excludeRange(i, getIndentedBlockEnd(sourceLines, start))
+		case strings.Contains(line, IgnoreMarker):
+			start := i
+			if isCommentLine(line) {
+				start = getNextCodeLine(sourceLines, i)
+			}
+			excludeRange(i, getBraceBlockEnd(sourceLines, start))
+		case !isCommentLine(line) && generatedAnnotationRegexp.MatchString(line):
+			excludeRange(i, getBraceBlockEnd(sourceLines, i))
+		}
+	}
+
+	return excluded
+}
+
+func isCommentLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "#", "/*", "*"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// getNextCodeLine returns the index of the first line after i that is
+// neither blank nor a comment, or i when there is none.
+func getNextCodeLine(sourceLines []string, i int) int {
+	for j := i + 1; j < len(sourceLines); j++ {
+		if strings.TrimSpace(sourceLines[j]) != "" && !isCommentLine(sourceLines[j]) {
+			return j
+		}
+	}
+	return i
+}
+
+// getBraceBlockEnd returns the index of the line closing the first brace
+// block opened from line start on, or of the line ending the statement with
+// a semicolon when no block is opened before it.
+func getBraceBlockEnd(sourceLines []string, start int) int {
+	depth, opened, inBlockComment := 0, false, false
+	for j := start; j < len(sourceLines); j++ {
+		var code string
+		code, inBlockComment = stripStringsAndComments(sourceLines[j], inBlockComment)
+		for _, c := range code {
+			switch c {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+			}
+			if opened && depth <= 0 {
+				return j
+			}
+		}
+		if !opened && strings.HasSuffix(strings.TrimSpace(code), ";") {
+			return j
+		}
+	}
+	if opened {
+		return len(sourceLines) - 1
+	}
+	return start
+}
+
+// getIndentedBlockEnd returns the index of the last line of the block
+// indented below line start when the line ends with a colon.
+func getIndentedBlockEnd(sourceLines []string, start int) int {
+	code := strings.TrimSpace(sourceLines[start])
+	if i := strings.Index(code, "#"); i >= 0 {
+		code = strings.TrimSpace(code[:i])
+	}
+	if !strings.HasSuffix(code, ":") {
+		return start
+	}
+
+	indent := getIndent(sourceLines[start])
+	end := start
+	for j := start + 1; j < len(sourceLines); j++ {
+		if strings.TrimSpace(sourceLines[j]) == "" {
+			continue
+		}
+		if getIndent(sourceLines[j]) <= indent {
+			break
+		}
+		end = j
+	}
+	return end
+}
+
+func getIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// stripStringsAndComments drops string and character literals and
+// comments, so braces inside them are not counted. Block comments may span
+// lines: inBlockComment tells whether the line starts inside one, and the
+// returned flag whether the next line does.
+func stripStringsAndComments(line string, inBlockComment bool) (string, bool) {
+	var code strings.Builder
+	var quote rune
+	escaped := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if inBlockComment {
+			if c == '*' && i+1 < len(runes) && runes[i+1] == '/' {
+				inBlockComment = false
+				i++
+			}
+			continue
+		}
+		if quote != 0 {
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '/' && i+1 < len(runes) && runes[i+1] == '/' {
+			break
+		}
+		if c == '/' && i+1 < len(runes) && runes[i+1] == '*' {
+			inBlockComment = true
+			i++
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+			continue
+		}
+		code.WriteRune(c)
+	}
+	return code.String(), inBlockComment
+}
@@ -0,0 +1,66 @@
+package coverage
+
+// MergeReports combines the reports of several test runs or modules into one.
+// Packages are matched by name and files by path. A file found in several
+// reports gets the union of its lines, with the hits added up and the most
+// covered branches of each line kept; its other counters are taken from the
+// report that covers the most.
+func MergeReports(reports []*Report) *Report {
+
+	merged := &Report{}
+	packagesByName := map[string]*Package{}
+	filesByPath := map[string]*File{}
+	sources := map[string]bool{}
+
+	for _, report := range reports {
+		if merged.Tool == "" {
+			merged.Tool, merged.Name = report.Tool, report.Name
+		}
+		for _, source := range report.Sources {
+			if !sources[source] {
+				sources[source] = true
+				merged.Sources = append(merged.Sources, source)
+			}
+		}
+
+		for _, pkg := range report.Packages {
+			mergedPkg, ok := packagesByName[pkg.Name]
+			if !ok {
+				mergedPkg = &Package{Name: pkg.Name, Module: pkg.Module, Counters: Counters{}}
+				packagesByName[pkg.Name] = mergedPkg
+				merged.Packages = append(merged.Packages, mergedPkg)
+			}
+			mergedPkg.Counters = getMostCovered(mergedPkg.Counters, pkg.Counters)
+
+			for _, file := range pkg.Files {
+				mergedFile, ok := filesByPath[pkg.Name+"\x00"+file.Path]
+				if !ok {
+					mergedFile = &File{Name: file.Name, Path: file.Path, SourcePath: file.SourcePath,
+						Counters: Counters{}}
+					filesByPath[pkg.Name+"\x00"+file.Path] = mergedFile
+					mergedPkg.Files = append(mergedPkg.Files, mergedFile)
+				}
+				mergedFile.Counters = getMostCovered(mergedFile.Counters, file.Counters)
+				mergedFile.Lines = SortLines(append(append([]Line{}, mergedFile.Lines...), file.Lines...))
+			}
+		}
+	}
+
+	merged.Recompute()
+	return merged
+}
+
+// getMostCovered returns, per counter type, the counter with the most
+// covered items.
+func getMostCovered(counters, other Counters) Counters {
+	result := Counters{}
+	for counterType, counter := range counters {
+		result[counterType] = counter
+	}
+	for counterType, counter := range other {
+		if current, ok := result[counterType]; !ok || counter.Covered > current.Covered {
+			result[counterType] = counter
+		}
+	}
+	return result
+}
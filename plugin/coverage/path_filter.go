@@ -0,0 +1,77 @@
+package coverage
+
+import (
+	"fmt"
+	"github.com/bmatcuk/doublestar/v4"
+	"strings"
+)
+
+// PathFilter selects report elements by glob patterns matched against their
+// slash separated paths, e.g. com/example/dto/UserDto.class for a class.
+type PathFilter struct {
+	Inclusions []string
+	Exclusions []string
+}
+
+// GetNewPathFilter validates the inclusion and exclusion patterns. Empty
+// patterns are ignored.
+func GetNewPathFilter(inclusions, exclusions []string) (*PathFilter, error) {
+	validInclusions, err := getValidPatterns(inclusions)
+	if err != nil {
+		return nil, err
+	}
+	validExclusions, err := getValidPatterns(exclusions)
+	if err != nil {
+		return nil, err
+	}
+	return &PathFilter{Inclusions: validInclusions, Exclusions: validExclusions}, nil
+}
+
+func getValidPatterns(patterns []string) ([]string, error) {
+	var validPatterns []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid glob pattern %q", pattern)
+		}
+		validPatterns = append(validPatterns, pattern)
+	}
+	return validPatterns, nil
+}
+
+// IsEmpty reports whether the filter includes every path.
+func (f *PathFilter) IsEmpty() bool {
+	return f == nil || (len(f.Inclusions) == 0 && len(f.Exclusions) == 0)
+}
+
+// IsIncluded reports whether the path matches an inclusion pattern, or
+// there are none, and matches no exclusion pattern.
+func (f *PathFilter) IsIncluded(path string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	path = strings.TrimPrefix(path, "/")
+	for _, pattern := range f.Exclusions {
+		if matched, _ := doublestar.Match(pattern, path); matched {
+			return false
+		}
+	}
+	if len(f.Inclusions) == 0 {
+		return true
+	}
+	for _, pattern := range f.Inclusions {
+		if matched, _ := doublestar.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClassPath returns the path class patterns are matched against, from a
+// class name with dots or slashes between its packages.
+func GetClassPath(className string) string {
+	return strings.ReplaceAll(className, ".", "/") + ".class"
+}
@@ -0,0 +1,263 @@
+package coverage
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathMapping replaces the From prefix of the paths in a report, usually a
+// directory of the machine that ran the tests, with the To directory. A
+// relative To is relative to the workspace.
+type PathMapping struct {
+	From string
+	To   string
+}
+
+// ParsePathMappings reads mappings given as "from=to" pairs.
+func ParsePathMappings(values []string) ([]PathMapping, error) {
+	var mappings []PathMapping
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		from, to, found := strings.Cut(value, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !found || from == "" {
+			return nil, fmt.Errorf("invalid path mapping %q, expected from=to", value)
+		}
+		mappings = append(mappings, PathMapping{From: from, To: to})
+	}
+	return mappings, nil
+}
+
+// PathMapper finds the files of a report in the workspace. The report paths,
+// below each report source root and alone, are tried with the configured
+// prefix substitutions first. Paths still not found are matched by their
+// trailing path segments against the files of the search dirs.
+type PathMapper struct {
+	WorkSpaceDir string
+	Mappings     []PathMapping
+	// SearchDirs are the directories below the workspace whose files are
+	// matched by suffix, the whole workspace when empty
+	SearchDirs []string
+
+	// workspace relative paths of the workspace files by base name, read on
+	// the first suffix match
+	filesByName map[string][]string
+}
+
+func GetNewPathMapper(workSpaceDir string, mappings []PathMapping) *PathMapper {
+	return &PathMapper{WorkSpaceDir: workSpaceDir, Mappings: mappings}
+}
+
+// Apply sets the SourcePath of the report files found in the workspace and
+// returns their number.
+func (m *PathMapper) Apply(report *Report) (int, error) {
+	mapped := 0
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			sourcePath, ok, err := m.MapPath(file.Path, report.Sources)
+			if err != nil {
+				return mapped, err
+			}
+			if ok {
+				file.SourcePath = sourcePath
+				mapped++
+			}
+		}
+	}
+	return mapped, nil
+}
+
+// MapPath returns the workspace relative path of the report path.
+func (m *PathMapper) MapPath(reportPath string, sourceRoots []string) (string, bool, error) {
+
+	var candidates []string
+	if !isAbsPath(reportPath) {
+		for _, root := range sourceRoots {
+			if root != "" {
+				candidates = append(candidates, path.Join(filepath.ToSlash(root), reportPath))
+			}
+		}
+	}
+	candidates = append(candidates, reportPath)
+
+	for _, candidate := range candidates {
+		for _, mapped := range m.getMappedPaths(filepath.ToSlash(candidate)) {
+			if relPath, ok := m.getWorkSpaceFile(mapped); ok {
+				return relPath, true, nil
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		relPath, ok, err := m.matchSuffix(filepath.ToSlash(candidate))
+		if err != nil || ok {
+			return relPath, ok, err
+		}
+	}
+	return "", false, nil
+}
+
+// getMappedPaths returns the path after each matching prefix substitution,
+// followed by the path itself.
+func (m *PathMapper) getMappedPaths(candidate string) []string {
+	var mappedPaths []string
+	for _, mapping := range m.Mappings {
+		from := strings.TrimSuffix(filepath.ToSlash(mapping.From), "/")
+		if candidate != from && !strings.HasPrefix(candidate, from+"/") {
+			continue
+		}
+		mappedPaths = append(mappedPaths, path.Join(filepath.ToSlash(mapping.To),
+			strings.TrimPrefix(candidate, from)))
+	}
+	return append(mappedPaths, candidate)
+}
+
+// getWorkSpaceFile returns the workspace relative path of an existing file
+// below the workspace.
+func (m *PathMapper) getWorkSpaceFile(candidate string) (string, bool) {
+	completePath := filepath.FromSlash(candidate)
+	if !filepath.IsAbs(completePath) {
+		completePath = filepath.Join(m.WorkSpaceDir, completePath)
+	}
+	info, err := os.Stat(completePath)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	relPath, err := filepath.Rel(m.WorkSpaceDir, completePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(relPath), true
+}
+
+// matchSuffix looks for the workspace file whose path ends with the report
+// path, or the end of which the report path ends with. When several files
+// match, the one sharing the most trailing segments wins; ties stay
+// unmapped.
+func (m *PathMapper) matchSuffix(candidate string) (string, bool, error) {
+	if m.filesByName == nil {
+		err := m.readWorkSpaceFiles()
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	candidate = strings.TrimPrefix(candidate, "/")
+	bestPath, bestSegments, tie := "", 0, false
+	for _, relPath := range m.filesByName[path.Base(candidate)] {
+		if relPath != candidate && !strings.HasSuffix(relPath, "/"+candidate) &&
+			!strings.HasSuffix(candidate, "/"+relPath) {
+			continue
+		}
+		segments := getCommonTrailingSegments(relPath, candidate)
+		switch {
+		case segments > bestSegments:
+			bestPath, bestSegments, tie = relPath, segments, false
+		case segments == bestSegments:
+			tie = true
+		}
+	}
+	if bestPath == "" || tie {
+		return "", false, nil
+	}
+	return bestPath, true, nil
+}
+
+// readWorkSpaceFiles lists the files of the search dirs, skipping the
+// directories of dependencies and build output.
+func (m *PathMapper) readWorkSpaceFiles() error {
+	m.filesByName = map[string][]string{}
+
+	searchDirs := m.SearchDirs
+	if len(searchDirs) == 0 {
+		searchDirs = []string{m.WorkSpaceDir}
+	}
+
+	seen := map[string]bool{}
+	for _, searchDir := range searchDirs {
+		if _, ok := m.getWorkSpaceDir(searchDir); !ok {
+			continue
+		}
+		err := filepath.WalkDir(searchDir, func(completePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if completePath != searchDir && isSkippedSearchDir(completePath, d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			relPath, err := filepath.Rel(m.WorkSpaceDir, completePath)
+			if err != nil {
+				return err
+			}
+			relPath = filepath.ToSlash(relPath)
+			// nested search dirs list their files once
+			if !seen[relPath] {
+				seen[relPath] = true
+				m.filesByName[d.Name()] = append(m.filesByName[d.Name()], relPath)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getWorkSpaceDir returns the workspace relative path of a directory below
+// the workspace.
+func (m *PathMapper) getWorkSpaceDir(completePath string) (string, bool) {
+	relPath, err := filepath.Rel(m.WorkSpaceDir, completePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	info, err := os.Stat(completePath)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	return filepath.ToSlash(relPath), true
+}
+
+// isSkippedSearchDir tells whether a directory holds no sources: hidden
+// directories, dependencies and the output directories of Maven and Gradle
+// modules.
+func isSkippedSearchDir(completePath, name string) bool {
+	switch name {
+	case "node_modules", "bower_components":
+		return true
+	case "target":
+		return isFile(filepath.Join(filepath.Dir(completePath), "pom.xml"))
+	case "build":
+		return isFile(filepath.Join(filepath.Dir(completePath), "build.gradle")) ||
+			isFile(filepath.Join(filepath.Dir(completePath), "build.gradle.kts"))
+	}
+	return strings.HasPrefix(name, ".")
+}
+
+func isFile(completePath string) bool {
+	info, err := os.Stat(completePath)
+	return err == nil && !info.IsDir()
+}
+
+func getCommonTrailingSegments(a, b string) int {
+	aSegments, bSegments := strings.Split(a, "/"), strings.Split(b, "/")
+	count := 0
+	for count < len(aSegments) && count < len(bSegments) &&
+		aSegments[len(aSegments)-1-count] == bSegments[len(bSegments)-1-count] {
+		count++
+	}
+	return count
+}
+
+func isAbsPath(p string) bool {
+	return strings.HasPrefix(p, "/") || filepath.IsAbs(p)
+}
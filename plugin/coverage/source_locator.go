@@ -0,0 +1,96 @@
+package coverage
+
+import (
+	"github.com/bmatcuk/doublestar/v4"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SourceLocator finds the source files referenced by a coverage report
+// below a list of source root directories.
+type SourceLocator struct {
+	WorkSpaceDir string
+	Roots        []string
+}
+
+// GetNewSourceLocator expands the source directory glob patterns relative to
+// the workspace and adds the source roots listed in the report that exist on
+// this machine.
+func GetNewSourceLocator(workSpaceDir string, sourceDirGlobs []string, reportSources []string) (*SourceLocator, error) {
+
+	locator := &SourceLocator{WorkSpaceDir: workSpaceDir}
+	seen := map[string]bool{}
+
+	addRoot := func(root string) {
+		if seen[root] {
+			return
+		}
+		info, err := os.Stat(root)
+		if err != nil || !info.IsDir() {
+			return
+		}
+		seen[root] = true
+		locator.Roots = append(locator.Roots, root)
+	}
+
+	for _, pattern := range sourceDirGlobs {
+		if pattern == "" {
+			continue
+		}
+		relPattern := strings.TrimPrefix(pattern, workSpaceDir+"/")
+		matchedDirs, err := doublestar.Glob(os.DirFS(workSpaceDir), relPattern)
+		if err != nil {
+			return locator, err
+		}
+		for _, match := range matchedDirs {
+			addRoot(filepath.Join(workSpaceDir, match))
+		}
+	}
+
+	for _, source := range reportSources {
+		if filepath.IsAbs(source) {
+			addRoot(source)
+		} else {
+			addRoot(filepath.Join(workSpaceDir, source))
+		}
+	}
+
+	return locator, nil
+}
+
+// Locate returns the complete path of the source file, the one the path
+// mapping found or else the first one matching the report relative path.
+func (s *SourceLocator) Locate(file *File) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	if file.SourcePath != "" {
+		completePath := filepath.Join(s.WorkSpaceDir, filepath.FromSlash(file.SourcePath))
+		if info, err := os.Stat(completePath); err == nil && !info.IsDir() {
+			return completePath, true
+		}
+	}
+	for _, root := range s.Roots {
+		completePath := filepath.Join(root, filepath.FromSlash(file.Path))
+		info, err := os.Stat(completePath)
+		if err == nil && !info.IsDir() {
+			return completePath, true
+		}
+	}
+	return "", false
+}
+
+// ReadSourceLines returns the lines of the source file of the report file.
+func (s *SourceLocator) ReadSourceLines(file *File) ([]string, bool) {
+	completePath, ok := s.Locate(file)
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(completePath)
+	if err != nil {
+		return nil, false
+	}
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n"), true
+}
@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/history"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/harness-community/drone-coverage-report/plugin/ratchet"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadRatchetFloors reads the floors of the coverage ratchet into args, from
+// the ratchet file or, without one, from the history store. Floors already
+// read are kept.
+func LoadRatchetFloors(args *pd.Args, workSpaceDir string) error {
+
+	if !args.Ratchet || args.RatchetFloors != nil {
+		return nil
+	}
+
+	_, err := ratchet.GetCounterTypes(getRatchetMetrics(*args))
+	if err != nil {
+		return pd.GetNewError("Error in LoadRatchetFloors: " + err.Error())
+	}
+
+	var floors ratchet.Floors
+	switch {
+	case args.RatchetPath != "":
+		floors, err = ratchet.ReadFloorsFile(pd.GetWorkSpaceRelativePath(workSpaceDir, args.RatchetPath))
+	case args.HistoryPath != "":
+		store := history.GetNewStore(pd.GetWorkSpaceRelativePath(workSpaceDir, args.HistoryPath))
+		floors, err = store.GetLatestRatchetFloors(args.Repo.Slug, args.Repo.Branch)
+	default:
+		return pd.GetNewError("Error in LoadRatchetFloors: ratchet needs ratchet_path or history_path " +
+			"to keep its floors")
+	}
+	if err != nil {
+		return pd.GetNewError("Error in LoadRatchetFloors: " + err.Error())
+	}
+
+	args.RatchetFloors = floors
+	return nil
+}
+
+// GetRatchetChecks checks the coverage of the report against the floors of
+// the ratchet.
+func GetRatchetChecks(report *coverage.Report, args pd.Args) []pd.ThresholdCheck {
+	if !args.Ratchet {
+		return nil
+	}
+	counterTypes, _ := ratchet.GetCounterTypes(getRatchetMetrics(args))
+	return pd.ApplyThresholdSeverities(ratchet.GetChecks(args.RatchetFloors, report.Counters, counterTypes),
+		args.EnvPluginInputArgs)
+}
+
+// CheckRatchetFloors fails on the ratchet floors the coverage fell below,
+// when failing on thresholds.
+func CheckRatchetFloors(p pd.Plugin, args pd.Args) error {
+	report := p.GetCoverageReport()
+	if report == nil || !args.PluginFailOnThreshold {
+		return nil
+	}
+	failedChecks := pd.GetFailedThresholdChecks(GetRatchetChecks(report, args))
+	if len(failedChecks) > 0 {
+		return pd.GetNewKindError(pd.ErrThreshold, fmt.Sprintf("%d coverage ratchet floors not met", len(failedChecks)))
+	}
+	return nil
+}
+
+// UpdateRatchetFloors raises the floors of the ratchet on builds of the
+// default branch, writing them to the ratchet file when set, and returns
+// the floors to keep in the summary and the history store.
+func UpdateRatchetFloors(summary pd.CoverageSummary, args pd.Args, workSpaceDir string) (ratchet.Floors, error) {
+
+	if !IsDefaultBranchBuild(args) {
+		logrus.Printf("Coverage ratchet floors not updated, %s is not a build of the default branch\n",
+			args.Commit.Branch)
+		return args.RatchetFloors, nil
+	}
+
+	counterTypes, err := ratchet.GetCounterTypes(getRatchetMetrics(args))
+	if err != nil {
+		return nil, err
+	}
+
+	floors, changes := ratchet.Raise(args.RatchetFloors, summary.Counters, counterTypes, args.RatchetSlack)
+	for _, change := range changes {
+		logrus.Printf("Coverage ratchet %s\n", change.String())
+	}
+	if len(changes) == 0 {
+		logrus.Printf("Coverage ratchet floors unchanged\n")
+	}
+
+	err = pd.WriteEnvVariableAsString(RatchetRaisedOutputVariableKey, len(changes))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 && args.RatchetPath != "" {
+		floorsPath := pd.GetWorkSpaceRelativePath(workSpaceDir, args.RatchetPath)
+		err := ratchet.WriteFloorsFile(floorsPath, ratchet.FloorsFile{
+			Floors:      floors,
+			Commit:      args.Commit.Rev,
+			BuildNumber: args.Build.Number,
+		})
+		if err != nil {
+			return nil, err
+		}
+		logrus.Printf("Coverage ratchet floors written to %s\n", floorsPath)
+	}
+
+	return floors, nil
+}
+
+// IsDefaultBranchBuild tells whether the build is a push to the default
+// branch of the repository, the only builds that move the ratchet.
+func IsDefaultBranchBuild(args pd.Args) bool {
+	return args.Repo.Branch != "" && args.Commit.Branch == args.Repo.Branch && args.PullRequest.Number <= 0
+}
+
+func getRatchetMetrics(args pd.Args) string {
+	if args.RatchetMetrics == "" {
+		return DefaultRatchetMetrics
+	}
+	return args.RatchetMetrics
+}
+
+const (
+	DefaultRatchetMetrics          = "line,branch"
+	RatchetRaisedOutputVariableKey = "RATCHET_RAISED"
+)
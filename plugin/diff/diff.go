@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangedLines maps the new path of every file in a diff to the sorted line
+// numbers added or modified in it. Paths are relative to the repository root.
+type ChangedLines map[string][]int
+
+// ReadDiffFile parses a unified diff written by an earlier step.
+func ReadDiffFile(diffPath string) (ChangedLines, error) {
+	file, err := os.Open(diffPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseUnifiedDiff(file)
+}
+
+// GetGitDiff returns the lines changed on HEAD since it forked from baseRef.
+func GetGitDiff(ctx context.Context, repoDir, baseRef string) (ChangedLines, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoDir, "diff", "--no-color", "--no-ext-diff",
+		"-U0", baseRef+"...HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git diff %s...HEAD failed: %s", baseRef, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	return ParseUnifiedDiff(strings.NewReader(string(output)))
+}
+
+// ParseUnifiedDiff collects the added lines of every file in a unified diff
+// as produced by git diff or diff -u. Deleted files are left out.
+func ParseUnifiedDiff(reader io.Reader) (ChangedLines, error) {
+	changedLines := ChangedLines{}
+
+	var currentPath string
+	var newLine, oldRemaining, newRemaining int
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if oldRemaining > 0 || newRemaining > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				if currentPath != "" {
+					changedLines[currentPath] = append(changedLines[currentPath], newLine)
+				}
+				newLine++
+				newRemaining--
+			case strings.HasPrefix(line, "-"):
+				oldRemaining--
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+			default:
+				newLine++
+				newRemaining--
+				oldRemaining--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			currentPath = getDiffPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@ "):
+			var err error
+			newLine, oldRemaining, newRemaining, err = parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for path, lines := range changedLines {
+		sort.Ints(lines)
+		changedLines[path] = lines
+	}
+	return changedLines, nil
+}
+
+// GetFileLines returns the diff path and changed lines of the file with the
+// given report path. Report paths are usually relative to a source root, so
+// they are matched against the end of the repository relative diff paths.
+func (c ChangedLines) GetFileLines(reportPath string) (string, []int) {
+	reportPath = strings.TrimPrefix(strings.ReplaceAll(reportPath, "\\", "/"), "/")
+	if lines, ok := c[reportPath]; ok {
+		return reportPath, lines
+	}
+
+	matchedPath := ""
+	for diffPath := range c {
+		if strings.HasSuffix(diffPath, "/"+reportPath) || strings.HasSuffix(reportPath, "/"+diffPath) {
+			if matchedPath == "" || len(diffPath) < len(matchedPath) {
+				matchedPath = diffPath
+			}
+		}
+	}
+	if matchedPath == "" {
+		return "", nil
+	}
+	return matchedPath, c[matchedPath]
+}
+
+// Contains reports whether the line is one of the sorted changed lines.
+func Contains(lines []int, line int) bool {
+	i := sort.SearchInts(lines, line)
+	return i < len(lines) && lines[i] == line
+}
+
+func getDiffPath(header string) string {
+	if i := strings.Index(header, "\t"); i >= 0 {
+		header = header[:i]
+	}
+	header = strings.Trim(header, "\"")
+	if header == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(header, "b/") {
+		return header[2:]
+	}
+	return header
+}
+
+// parseHunkHeader reads "@@ -l,s +l,s @@" and returns the first new line
+// number and the old and new line counts of the hunk.
+func parseHunkHeader(header string) (int, int, int, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header: %s", header)
+	}
+
+	_, oldCount, err := parseRange(fields[1][1:])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header: %s", header)
+	}
+	newStart, newCount, err := parseRange(fields[2][1:])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header: %s", header)
+	}
+	return newStart, oldCount, newCount, nil
+}
+
+func parseRange(value string) (int, int, error) {
+	start, count, found := strings.Cut(value, ",")
+	startLine, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return startLine, 1, nil
+	}
+	lineCount, err := strconv.Atoi(count)
+	return startLine, lineCount, err
+}
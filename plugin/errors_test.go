@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+
+	malformedWorkSpace := t.TempDir()
+	err := os.WriteFile(filepath.Join(malformedWorkSpace, "coverage.xml"), []byte("<coverage><packages>"), 0644)
+	if err != nil {
+		t.Fatalf("Error in TestErrorKinds: %s", err.Error())
+	}
+	err = os.WriteFile(filepath.Join(malformedWorkSpace, "jacoco.xml"), []byte("<report><package"), 0644)
+	if err != nil {
+		t.Fatalf("Error in TestErrorKinds: %s", err.Error())
+	}
+
+	missingArgs := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	missingArgs.ExecFilesPathPattern = "**/missing.xml"
+
+	thresholdArgs := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	thresholdArgs.MinimumLineCoverage = 99
+	thresholdArgs.MinimumComplexityCoverage = 100
+
+	jacocoXmlMissingArgs := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	jacocoXmlMissingArgs.ExecFilesPathPattern = "**/missing.xml"
+
+	jacocoXmlMalformedArgs := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	jacocoXmlMalformedArgs.ExecFilesPathPattern = "jacoco.xml"
+
+	coberturaMalformedArgs := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	coberturaMalformedArgs.ExecFilesPathPattern = "coverage.xml"
+
+	testCases := []struct {
+		name             string
+		args             pd.Args
+		workSpaceDir     string
+		expectedKind     error
+		expectedExitCode int
+		expectedResult   string
+	}{
+		{"cobertura missing", missingArgs, pd.TestWorkSpaceDir, pd.ErrNoReports, pd.ExitCodeNoReports,
+			pd.ResultNoReports},
+		{"cobertura malformed", coberturaMalformedArgs, malformedWorkSpace, pd.ErrParse, pd.ExitCodeParseError,
+			pd.ResultParseError},
+		{"cobertura thresholds", thresholdArgs, pd.TestWorkSpaceDir, pd.ErrThreshold, pd.ExitCodeThresholdsNotMet,
+			pd.ResultThresholdsNotMet},
+		{"jacoco-xml missing", jacocoXmlMissingArgs, pd.TestWorkSpaceDir, pd.ErrNoReports, pd.ExitCodeNoReports,
+			pd.ResultNoReports},
+		{"jacoco-xml malformed", jacocoXmlMalformedArgs, malformedWorkSpace, pd.ErrParse, pd.ExitCodeParseError,
+			pd.ResultParseError},
+	}
+
+	for _, testCase := range testCases {
+		outputPath := filepath.Join(t.TempDir(), "drone-output")
+		t.Setenv("DRONE_OUTPUT", outputPath)
+		t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, testCase.workSpaceDir)
+
+		_, err := Exec(context.TODO(), testCase.args)
+		if !errors.Is(err, testCase.expectedKind) {
+			t.Errorf("Error in TestErrorKinds: %s returned %v, expected %v", testCase.name, err,
+				testCase.expectedKind)
+			continue
+		}
+		if pd.GetExitCode(err) != testCase.expectedExitCode {
+			t.Errorf("Error in TestErrorKinds: %s exit code %d, expected %d", testCase.name,
+				pd.GetExitCode(err), testCase.expectedExitCode)
+		}
+
+		output, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Error in TestErrorKinds: %s", err.Error())
+		}
+		expectedOutput := pd.CoverageResultOutputVariable + "=" + testCase.expectedResult
+		if !strings.Contains(string(output), expectedOutput) {
+			t.Errorf("Error in TestErrorKinds: %s output %q does not contain %q", testCase.name, output,
+				expectedOutput)
+		}
+	}
+}
+
+func TestGetExitCode(t *testing.T) {
+
+	wrapped := errors.Join(errors.New("Error in Run"), pd.GetNewKindError(pd.ErrToolExecution, "java failed"))
+	if pd.GetExitCode(wrapped) != pd.ExitCodeToolError || pd.GetCoverageResult(wrapped) != pd.ResultToolError {
+		t.Errorf("Error in TestGetExitCode: wrapped tool error not recognized")
+	}
+	if pd.GetExitCode(pd.GetNewError("invalid settings")) != pd.ExitCodeError {
+		t.Errorf("Error in TestGetExitCode: plain errors should exit with %d", pd.ExitCodeError)
+	}
+	if pd.GetExitCode(nil) != pd.ExitCodeOk || pd.GetCoverageResult(nil) != pd.ResultPassed {
+		t.Errorf("Error in TestGetExitCode: nil error should pass")
+	}
+}
@@ -0,0 +1,196 @@
+package plugin
+
+import (
+	"context"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// CopyToWorkSpace copies a workspace file to the same relative path below
+// another workspace, replacing the given lines.
+func CopyToWorkSpace(t *testing.T, workSpaceDir, relPath string, replacedLines map[int]string) {
+	data, err := os.ReadFile(filepath.Join(pd.TestWorkSpaceDir, relPath))
+	if err != nil {
+		t.Fatalf("Error in CopyToWorkSpace: %s", err.Error())
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for number, line := range replacedLines {
+		lines[number-1] = line
+	}
+
+	completePath := filepath.Join(workSpaceDir, relPath)
+	err = pd.CreateDir(filepath.Dir(completePath))
+	if err != nil {
+		t.Fatalf("Error in CopyToWorkSpace: %s", err.Error())
+	}
+	err = os.WriteFile(completePath, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		t.Fatalf("Error in CopyToWorkSpace: %s", err.Error())
+	}
+}
+
+func TestCoberturaSourceExclusionMarkers(t *testing.T) {
+
+	workSpaceDir := t.TempDir()
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, workSpaceDir)
+
+	projectDir := "cobertura-sample/bad-metrics-project"
+	CopyToWorkSpace(t, workSpaceDir, projectDir+"/coverage.xml", nil)
+	CopyToWorkSpace(t, workSpaceDir, projectDir+"/src/main/java/com/example/package1/Calculator.java",
+		map[int]string{
+			7:  "    // coverage:ignore",
+			11: "    @Generated",
+		})
+
+	for _, skipMarkers := range []bool{false, true} {
+		args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+		args.PluginFailOnThreshold = false
+		args.SkipExclusionMarkers = skipMarkers
+		args.SummaryJsonPath = filepath.Join(t.TempDir(), "summary.json")
+
+		_, err := Exec(context.TODO(), args)
+		if err != nil {
+			t.Fatalf("Error in TestCoberturaSourceExclusionMarkers: %s", err.Error())
+		}
+
+		summary, err := pd.ReadCoverageSummary(args.SummaryJsonPath)
+		if err != nil {
+			t.Fatalf("Error in TestCoberturaSourceExclusionMarkers: %s", err.Error())
+		}
+
+		expectedLines := coverage.Counter{Covered: 2, Missed: 13}
+		expectedMethods := coverage.Counter{Covered: 2, Missed: 5}
+		if skipMarkers {
+			expectedLines = coverage.Counter{Covered: 2, Missed: 15}
+			expectedMethods = coverage.Counter{Covered: 2, Missed: 7}
+		}
+		if summary.Counters.Get(coverage.LineCounter) != expectedLines ||
+			summary.Counters.Get(coverage.MethodCounter) != expectedMethods {
+			t.Errorf("Error in TestCoberturaSourceExclusionMarkers: skip markers %v, unexpected line %v method %v",
+				skipMarkers, summary.Counters.Get(coverage.LineCounter), summary.Counters.Get(coverage.MethodCounter))
+		}
+	}
+}
+
+func TestJacocoXmlSourceExclusionMarkers(t *testing.T) {
+
+	workSpaceDir := t.TempDir()
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, workSpaceDir)
+
+	projectDir := "game-of-life/gameoflife-core"
+	CopyToWorkSpace(t, workSpaceDir, projectDir+"/target/site/jacoco/jacoco.xml", nil)
+	CopyToWorkSpace(t, workSpaceDir, projectDir+"/src/main/java/com/wakaleo/gameoflife/domain/Cell.java",
+		map[int]string{
+			22: "    @Generated",
+			27: "\t// coverage:ignore",
+		})
+	CopyToWorkSpace(t, workSpaceDir, projectDir+"/src/main/java/com/wakaleo/gameoflife/domain/GridWriter.java",
+		map[int]string{
+			2:  "// coverage:ignore-start",
+			30: "} // coverage:ignore-end",
+		})
+
+	args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	args.ExecFilesPathPattern = "**/jacoco.xml"
+	args.SummaryJsonPath = filepath.Join(t.TempDir(), "summary.json")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlSourceExclusionMarkers: %s", err.Error())
+	}
+
+	summary, err := pd.ReadCoverageSummary(args.SummaryJsonPath)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlSourceExclusionMarkers: %s", err.Error())
+	}
+
+	// Cell.toString and Cell.fromSymbol are removed, and GridWriter with
+	// all its methods
+	expectedCounters := map[coverage.CounterType]coverage.Counter{
+		coverage.InstructionCounter: {Covered: 531, Missed: 0},
+		coverage.BranchCounter:      {Covered: 48, Missed: 0},
+		coverage.LineCounter:        {Covered: 106, Missed: 0},
+		coverage.ComplexityCounter:  {Covered: 61, Missed: 0},
+		coverage.MethodCounter:      {Covered: 37, Missed: 0},
+		coverage.ClassCounter:       {Covered: 4, Missed: 0},
+	}
+	for counterType, expected := range expectedCounters {
+		if summary.Counters.Get(counterType) != expected {
+			t.Errorf("Error in TestJacocoXmlSourceExclusionMarkers: %s is %v, expected %v",
+				counterType, summary.Counters.Get(counterType), expected)
+		}
+	}
+}
+
+func TestGetExcludedLines(t *testing.T) {
+
+	javaSource := `public class Sample {
+    public int a() { return 1; } // coverage:ignore
+
+    // coverage:ignore
+    public int b() {
+        return 2;
+    }
+
+    @lombok.Generated
+    @Override
+    public String toString() {
+        return "{";
+    }
+
+    /* coverage:ignore-start */
+    private int c;
+    private int d;
+    /* coverage:ignore-end */
+    private int e;
+}`
+
+	blockCommentSource := `public class Other {
+    @Generated
+    public int f() {
+        /* a } closing brace
+           and a { opening one */
+        return 1; /* } */
+    }
+
+    public int g() {
+        return 2;
+    }
+}`
+
+	pythonSource := `def f(x):  # pragma: no cover
+    if x:
+        return 1
+
+    return 2
+
+def g():
+    return 3  # pragma: no cover
+`
+
+	testCases := []struct {
+		source   string
+		expected []int
+	}{
+		{javaSource, []int{2, 4, 5, 6, 7, 9, 10, 11, 12, 13, 15, 16, 17, 18}},
+		{blockCommentSource, []int{2, 3, 4, 5, 6, 7}},
+		{pythonSource, []int{1, 2, 3, 4, 5, 8}},
+	}
+
+	for _, testCase := range testCases {
+		excludedLines := coverage.GetExcludedLines(strings.Split(testCase.source, "\n"))
+		expected := map[int]bool{}
+		for _, line := range testCase.expected {
+			expected[line] = true
+		}
+		if !reflect.DeepEqual(excludedLines, expected) {
+			t.Errorf("Error in TestGetExcludedLines: expected %v, found %v", testCase.expected, excludedLines)
+		}
+	}
+}
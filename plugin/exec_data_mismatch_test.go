@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"errors"
+	jc "github.com/harness-community/drone-coverage-report/plugin/jacoco"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// GetTestExecDataMismatchPlugin copies the classes of gameoflife-core to a
+// new workspace, the class Cell recompiled after its tests ran.
+func GetTestExecDataMismatchPlugin(t *testing.T, warnOnMismatch, failOnMismatch bool) jc.JacocoPlugin {
+
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, t.TempDir())
+	coreDir := filepath.Join(pd.TestWorkSpaceDir, "game-of-life", "gameoflife-core")
+
+	p := jc.GetNewJacocoPlugin()
+	p.InputArgs = &pd.Args{EnvPluginInputArgs: pd.EnvPluginInputArgs{WarnOnExecDataMismatch: warnOnMismatch,
+		FailOnExecDataMismatch: failOnMismatch}}
+	p.ExecFilesFinalCompletePath = []string{filepath.Join(coreDir, "target", "jacoco.exec")}
+
+	classesDir := filepath.Join(p.GetClassesWorkSpaceDir(), "com", "wakaleo", "gameoflife", "domain")
+	err := os.MkdirAll(classesDir, 0755)
+	if err != nil {
+		t.Fatalf("Error in GetTestExecDataMismatchPlugin: %s", err.Error())
+	}
+	for _, className := range []string{"Cell", "Grid"} {
+		classBytes, err := os.ReadFile(filepath.Join(coreDir, "target", "classes", "com", "wakaleo", "gameoflife",
+			"domain", className+".class"))
+		if err == nil && className == "Cell" {
+			classBytes = append(classBytes, 0)
+		}
+		if err == nil {
+			err = os.WriteFile(filepath.Join(classesDir, className+".class"), classBytes, 0644)
+		}
+		if err != nil {
+			t.Fatalf("Error in GetTestExecDataMismatchPlugin: %s", err.Error())
+		}
+	}
+	return p
+}
+
+func TestExecDataMismatch(t *testing.T) {
+
+	logs := CaptureLogs(t, "info", pd.TextLogFormat)
+
+	p := GetTestExecDataMismatchPlugin(t, false, false)
+	err := p.CheckExecDataClassIds()
+	if err != nil || p.ExecDataMismatches != nil {
+		t.Errorf("Error in TestExecDataMismatch: class files checked without being asked to, got %v %+v", err,
+			p.ExecDataMismatches)
+	}
+
+	p = GetTestExecDataMismatchPlugin(t, true, false)
+	err = p.CheckExecDataClassIds()
+	if err != nil {
+		t.Fatalf("Error in TestExecDataMismatch: %s", err.Error())
+	}
+	if len(p.ExecDataMismatches) != 1 ||
+		p.ExecDataMismatches[0].ClassFilePath != "com/wakaleo/gameoflife/domain/Cell.class" {
+		t.Errorf("Error in TestExecDataMismatch: unexpected mismatches %+v", p.ExecDataMismatches)
+	}
+	expected := "Execution data for class com/wakaleo/gameoflife/domain/Cell does not match"
+	if !strings.Contains(logs.String(), expected) {
+		t.Errorf("Error in TestExecDataMismatch: %q not logged in\n%s", expected, logs.String())
+	}
+
+	p = GetTestExecDataMismatchPlugin(t, false, true)
+	err = p.CheckExecDataClassIds()
+	if !errors.Is(err, pd.ErrExecDataMismatch) || !strings.Contains(err.Error(), "execution data does not match 1 classes") {
+		t.Errorf("Error in TestExecDataMismatch: step should fail on the mismatch, got %v", err)
+	}
+	if pd.GetExitCode(err) != pd.ExitCodeExecDataMismatch || pd.GetCoverageResult(err) != pd.ResultExecDataMismatch {
+		t.Errorf("Error in TestExecDataMismatch: unexpected exit code %d and result %s", pd.GetExitCode(err),
+			pd.GetCoverageResult(err))
+	}
+}
+
+func TestGetClassId(t *testing.T) {
+
+	classBytes, err := os.ReadFile(filepath.Join(pd.TestWorkSpaceDir, "game-of-life", "gameoflife-core", "target",
+		"classes", "com", "wakaleo", "gameoflife", "domain", "Universe.class"))
+	if err != nil {
+		t.Fatalf("Error in TestGetClassId: %s", err.Error())
+	}
+	name, err := jc.GetClassName(classBytes)
+	if err != nil || name != "com/wakaleo/gameoflife/domain/Universe" {
+		t.Errorf("Error in TestGetClassId: unexpected class name %q, %v", name, err)
+	}
+
+	data, err := jc.ReadExecFile(filepath.Join(pd.TestWorkSpaceDir, "game-of-life", "gameoflife-core", "target",
+		"jacoco.exec"))
+	if err != nil {
+		t.Fatalf("Error in TestGetClassId: %s", err.Error())
+	}
+	class, ok := data.GetClass(jc.GetClassId(classBytes))
+	if !ok || class.Name != name {
+		t.Errorf("Error in TestGetClassId: id %016x of %s not found in the execution data", jc.GetClassId(classBytes),
+			name)
+	}
+
+	_, err = jc.GetClassName(classBytes[:20])
+	if err == nil {
+		t.Errorf("Error in TestGetClassId: truncated class file accepted")
+	}
+}
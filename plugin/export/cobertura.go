@@ -0,0 +1,135 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"path"
+	"strings"
+	"time"
+)
+
+type CoberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      string             `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []CoberturaPackage `xml:"packages>package"`
+}
+
+type CoberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity string           `xml:"complexity,attr"`
+	Classes    []CoberturaClass `xml:"classes>class"`
+}
+
+type CoberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity string          `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []CoberturaLine `xml:"lines>line"`
+}
+
+type CoberturaLine struct {
+	Number            int    `xml:"number,attr"`
+	Hits              int    `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr,omitempty"`
+}
+
+// GetCoberturaCoverage converts the report into Cobertura XML with one class
+// per source file. When file paths are resolved to the repository root the
+// only source is ".", which is how GitLab matches files to the diff.
+func GetCoberturaCoverage(report *coverage.Report, resolvePath PathResolver) CoberturaCoverage {
+
+	lineCounter := report.Counters.Get(coverage.LineCounter)
+	branchCounter := report.Counters.Get(coverage.BranchCounter)
+
+	coberturaCoverage := CoberturaCoverage{
+		LineRate:        formatRate(lineCounter),
+		BranchRate:      formatRate(branchCounter),
+		LinesCovered:    lineCounter.Covered,
+		LinesValid:      lineCounter.Total(),
+		BranchesCovered: branchCounter.Covered,
+		BranchesValid:   branchCounter.Total(),
+		Complexity:      formatComplexity(report.Counters),
+		Version:         "drone-coverage-report",
+		Timestamp:       time.Now().UnixMilli(),
+	}
+
+	resolved := false
+	for _, pkg := range report.Packages {
+		coberturaPackage := CoberturaPackage{
+			Name:       strings.ReplaceAll(pkg.Name, "/", "."),
+			LineRate:   formatRate(pkg.Counters.Get(coverage.LineCounter)),
+			BranchRate: formatRate(pkg.Counters.Get(coverage.BranchCounter)),
+			Complexity: formatComplexity(pkg.Counters),
+		}
+		for _, file := range pkg.Files {
+			filename := resolvePath(file)
+			resolved = resolved || filename != file.Path
+
+			className := strings.TrimSuffix(file.Name, path.Ext(file.Name))
+			if coberturaPackage.Name != "" {
+				className = coberturaPackage.Name + "." + className
+			}
+
+			coberturaClass := CoberturaClass{
+				Name:       className,
+				Filename:   filename,
+				LineRate:   formatRate(file.Counters.Get(coverage.LineCounter)),
+				BranchRate: formatRate(file.Counters.Get(coverage.BranchCounter)),
+				Complexity: formatComplexity(file.Counters),
+			}
+			for _, line := range file.Lines {
+				coberturaLine := CoberturaLine{Number: line.Number, Hits: line.Hits, Branch: line.Branches > 0}
+				if line.Branches > 0 {
+					coberturaLine.ConditionCoverage = fmt.Sprintf("%d%% (%d/%d)",
+						line.CoveredBranches*100/line.Branches, line.CoveredBranches, line.Branches)
+				}
+				coberturaClass.Lines = append(coberturaClass.Lines, coberturaLine)
+			}
+			coberturaPackage.Classes = append(coberturaPackage.Classes, coberturaClass)
+		}
+		coberturaCoverage.Packages = append(coberturaCoverage.Packages, coberturaPackage)
+	}
+
+	if resolved {
+		coberturaCoverage.Sources = []string{"."}
+	} else {
+		coberturaCoverage.Sources = report.Sources
+	}
+	return coberturaCoverage
+}
+
+func (c CoberturaCoverage) Xml() ([]byte, error) {
+	data, err := xml.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	doctype := `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">` + "\n"
+	return append([]byte(xml.Header+doctype), append(data, '\n')...), nil
+}
+
+func formatRate(counter coverage.Counter) string {
+	if counter.Total() == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%.4f", float64(counter.Covered)/float64(counter.Total()))
+}
+
+func formatComplexity(counters coverage.Counters) string {
+	return fmt.Sprintf("%d", counters.Get(coverage.ComplexityCounter).Total())
+}
@@ -0,0 +1,100 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CodecovReport is Codecov's JSON coverage format. Every file maps line
+// numbers to hits, or to "covered/total" branches for lines with branches.
+type CodecovReport struct {
+	Coverage map[string]map[string]interface{} `json:"coverage"`
+}
+
+func GetCodecovReport(report *coverage.Report, resolvePath PathResolver) CodecovReport {
+	codecovReport := CodecovReport{Coverage: map[string]map[string]interface{}{}}
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			lines := codecovReport.Coverage[resolvePath(file)]
+			if lines == nil {
+				lines = map[string]interface{}{}
+				codecovReport.Coverage[resolvePath(file)] = lines
+			}
+			for _, line := range file.Lines {
+				if line.Branches > 0 {
+					lines[strconv.Itoa(line.Number)] = fmt.Sprintf("%d/%d", line.CoveredBranches, line.Branches)
+				} else {
+					lines[strconv.Itoa(line.Number)] = line.Hits
+				}
+			}
+		}
+	}
+	return codecovReport
+}
+
+func (c CodecovReport) Json() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// CodecovUpload holds the build metadata sent with an upload.
+type CodecovUpload struct {
+	URL         string
+	Token       string
+	Slug        string
+	Commit      string
+	Branch      string
+	Build       string
+	BuildURL    string
+	PullRequest int
+}
+
+// UploadToCodecov uploads the report with the v4 upload protocol: the
+// upload is announced to the Codecov server, which answers with the report
+// URL and the storage URL the report is then put to. The token is sent in
+// the Authorization header, never in the URL.
+func UploadToCodecov(ctx context.Context, httpClient *http.Client, upload CodecovUpload,
+	codecovJson []byte) (string, error) {
+
+	query := url.Values{}
+	query.Set("package", "drone-coverage-report")
+	query.Set("service", "drone.io")
+	query.Set("commit", upload.Commit)
+	query.Set("branch", upload.Branch)
+	query.Set("build", upload.Build)
+	query.Set("build_url", upload.BuildURL)
+	query.Set("slug", upload.Slug)
+	if upload.PullRequest > 0 {
+		query.Set("pr", strconv.Itoa(upload.PullRequest))
+	}
+
+	announceUrl := strings.TrimRight(upload.URL, "/") + "/upload/v4?" + query.Encode()
+	authorization := ""
+	if upload.Token != "" {
+		authorization = "token " + upload.Token
+	}
+	response, err := Upload(ctx, httpClient, http.MethodPost, announceUrl, authorization, "text/plain", nil)
+	if err != nil {
+		return "", err
+	}
+
+	responseLines := strings.Split(strings.TrimSpace(string(response)), "\n")
+	if len(responseLines) < 2 {
+		return "", fmt.Errorf("unexpected codecov upload response of %d lines", len(responseLines))
+	}
+	reportUrl, storageUrl := strings.TrimSpace(responseLines[0]), strings.TrimSpace(responseLines[1])
+
+	payload := "# path=" + CodecovReportName + "\n" + string(codecovJson) + "\n<<<<<< EOF\n"
+	_, err = Upload(ctx, httpClient, http.MethodPut, storageUrl, "", "text/plain", []byte(payload))
+	if err != nil {
+		return "", err
+	}
+	return reportUrl, nil
+}
+
+const CodecovReportName = "codecov.json"
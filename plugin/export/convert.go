@@ -0,0 +1,54 @@
+package export
+
+import (
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"strings"
+)
+
+// Convert writes the report in one of the supported output formats.
+func Convert(report *coverage.Report, format string, resolvePath PathResolver) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case CoberturaFormat:
+		return GetCoberturaCoverage(report, resolvePath).Xml()
+	case LcovFormat:
+		return []byte(GetLcov(report, resolvePath)), nil
+	case JacocoFormat:
+		return GetJacocoReport(report).Xml()
+	case SonarFormat:
+		return GetSonarGenericCoverage(report, resolvePath).Xml()
+	case CodecovFormat:
+		return GetCodecovReport(report, resolvePath).Json()
+	default:
+		return nil, fmt.Errorf("unknown conversion format %q, expected one of %s", format,
+			strings.Join(ConvertFormats, ", "))
+	}
+}
+
+// GetDefaultOutputName returns the file name the converted report is written
+// to when no output path is configured.
+func GetDefaultOutputName(format string) string {
+	switch strings.ToLower(format) {
+	case CoberturaFormat:
+		return "cobertura.xml"
+	case LcovFormat:
+		return "lcov.info"
+	case JacocoFormat:
+		return "jacoco.xml"
+	case SonarFormat:
+		return "sonar-coverage.xml"
+	case CodecovFormat:
+		return CodecovReportName
+	}
+	return ""
+}
+
+const (
+	CoberturaFormat = "cobertura"
+	LcovFormat      = "lcov"
+	JacocoFormat    = "jacoco"
+	SonarFormat     = "sonar"
+	CodecovFormat   = "codecov"
+)
+
+var ConvertFormats = []string{CoberturaFormat, LcovFormat, JacocoFormat, SonarFormat, CodecovFormat}
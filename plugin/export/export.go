@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PathResolver returns the path a file is published under, which other
+// systems expect to be relative to the repository root.
+type PathResolver func(file *coverage.File) string
+
+// GetSourcePathResolver resolves files to their location below the
+// workspace when the source locator finds them, and keeps the report path
+// otherwise.
+func GetSourcePathResolver(locator *coverage.SourceLocator, workSpaceDir string) PathResolver {
+	return func(file *coverage.File) string {
+		completePath, ok := locator.Locate(file)
+		if !ok {
+			return file.Path
+		}
+		relPath, err := filepath.Rel(workSpaceDir, completePath)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			return file.Path
+		}
+		return filepath.ToSlash(relPath)
+	}
+}
+
+// Upload sends the exported report to a URL with an optional Authorization
+// header. Errors never contain the query of the URL, which may hold
+// credentials.
+func Upload(ctx context.Context, httpClient *http.Client, method, uploadUrl, authorization, contentType string,
+	data []byte) ([]byte, error) {
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultUploadTimeout}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, uploadUrl, bytes.NewReader(data))
+	if err != nil {
+		return nil, getRedactedUrlError(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, getRedactedUrlError(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("upload to %s failed with status %d: %s", GetRedactedUrl(uploadUrl), resp.StatusCode,
+			strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// GetBearerAuthorization returns the Authorization header value of a bearer
+// token, or an empty string without a token.
+func GetBearerAuthorization(token string) string {
+	if token == "" {
+		return ""
+	}
+	return "Bearer " + token
+}
+
+// GetRedactedUrl returns the URL without its query, fragment and userinfo
+// password, so it can be logged.
+func GetRedactedUrl(rawUrl string) string {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return "<invalid url>"
+	}
+	parsedUrl.RawQuery = ""
+	parsedUrl.ForceQuery = false
+	parsedUrl.Fragment = ""
+	parsedUrl.RawFragment = ""
+	return parsedUrl.Redacted()
+}
+
+func getRedactedUrlError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return &url.Error{Op: urlErr.Op, URL: GetRedactedUrl(urlErr.URL), Err: urlErr.Err}
+	}
+	return err
+}
+
+const (
+	DefaultUploadTimeout = 60 * time.Second
+	maxResponseSize      = 1024 * 1024
+)
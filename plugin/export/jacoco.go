@@ -0,0 +1,121 @@
+package export
+
+import (
+	"encoding/xml"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"path"
+	"strings"
+)
+
+type JacocoReport struct {
+	XMLName  xml.Name        `xml:"report"`
+	Name     string          `xml:"name,attr"`
+	Packages []JacocoPackage `xml:"package"`
+	Counters []JacocoCounter `xml:"counter"`
+}
+
+type JacocoPackage struct {
+	Name        string             `xml:"name,attr"`
+	Classes     []JacocoClass      `xml:"class"`
+	SourceFiles []JacocoSourceFile `xml:"sourcefile"`
+	Counters    []JacocoCounter    `xml:"counter"`
+}
+
+type JacocoClass struct {
+	Name           string          `xml:"name,attr"`
+	SourceFileName string          `xml:"sourcefilename,attr"`
+	Counters       []JacocoCounter `xml:"counter"`
+}
+
+type JacocoSourceFile struct {
+	Name     string          `xml:"name,attr"`
+	Lines    []JacocoLine    `xml:"line"`
+	Counters []JacocoCounter `xml:"counter"`
+}
+
+type JacocoLine struct {
+	Number              int `xml:"nr,attr"`
+	MissedInstructions  int `xml:"mi,attr"`
+	CoveredInstructions int `xml:"ci,attr"`
+	MissedBranches      int `xml:"mb,attr"`
+	CoveredBranches     int `xml:"cb,attr"`
+}
+
+type JacocoCounter struct {
+	Type    string `xml:"type,attr"`
+	Missed  int    `xml:"missed,attr"`
+	Covered int    `xml:"covered,attr"`
+}
+
+// GetJacocoReport converts the report into JaCoCo XML with one class per
+// source file. Line instructions are only known for JaCoCo inputs, others
+// are written with one instruction per line.
+func GetJacocoReport(report *coverage.Report) JacocoReport {
+
+	name := report.Name
+	if name == "" {
+		name = report.Tool
+	}
+	jacocoReport := JacocoReport{Name: name, Counters: toJacocoCounters(report.Counters)}
+
+	for _, pkg := range report.Packages {
+		jacocoPackage := JacocoPackage{
+			Name:     coverage.PackagePath(pkg.Name),
+			Counters: toJacocoCounters(pkg.Counters),
+		}
+		for _, file := range pkg.Files {
+			fileName := path.Base(file.Path)
+			jacocoPackage.Classes = append(jacocoPackage.Classes, JacocoClass{
+				Name:           path.Join(jacocoPackage.Name, strings.TrimSuffix(fileName, path.Ext(fileName))),
+				SourceFileName: fileName,
+				Counters:       toJacocoCounters(file.Counters),
+			})
+
+			sourceFile := JacocoSourceFile{Name: fileName, Counters: toJacocoCounters(file.Counters)}
+			for _, line := range file.Lines {
+				jacocoLine := JacocoLine{
+					Number:          line.Number,
+					MissedBranches:  line.Branches - line.CoveredBranches,
+					CoveredBranches: line.CoveredBranches,
+				}
+				switch {
+				case !line.IsCovered():
+					jacocoLine.MissedInstructions = 1
+				case report.Counters.Has(coverage.InstructionCounter):
+					// JaCoCo inputs keep the covered instructions as hits
+					jacocoLine.CoveredInstructions = line.Hits
+				default:
+					jacocoLine.CoveredInstructions = 1
+				}
+				sourceFile.Lines = append(sourceFile.Lines, jacocoLine)
+			}
+			jacocoPackage.SourceFiles = append(jacocoPackage.SourceFiles, sourceFile)
+		}
+		jacocoReport.Packages = append(jacocoReport.Packages, jacocoPackage)
+	}
+	return jacocoReport
+}
+
+func (r JacocoReport) Xml() ([]byte, error) {
+	data, err := xml.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	doctype := `<!DOCTYPE report PUBLIC "-//JACOCO//DTD Report 1.1//EN" "report.dtd">` + "\n"
+	return append([]byte(xml.Header+doctype), append(data, '\n')...), nil
+}
+
+func toJacocoCounters(counters coverage.Counters) []JacocoCounter {
+	var jacocoCounters []JacocoCounter
+	for _, counterType := range coverage.AllCounterTypes {
+		if counters.Has(counterType) {
+			counter := counters.Get(counterType)
+			jacocoCounters = append(jacocoCounters, JacocoCounter{
+				Type:    string(counterType),
+				Missed:  counter.Missed,
+				Covered: counter.Covered,
+			})
+		}
+	}
+	return jacocoCounters
+}
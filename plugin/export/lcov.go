@@ -0,0 +1,51 @@
+package export
+
+import (
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"strings"
+)
+
+// GetLcov converts the report into an LCOV tracefile. Branches are written
+// as one block per line, since the report does not keep which branch of a
+// line was taken.
+func GetLcov(report *coverage.Report, resolvePath PathResolver) string {
+
+	var builder strings.Builder
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			builder.WriteString("TN:\n")
+			builder.WriteString("SF:" + resolvePath(file) + "\n")
+
+			linesFound, linesHit, branchesFound, branchesHit := 0, 0, 0, 0
+			for _, line := range file.Lines {
+				for branch := 0; branch < line.Branches; branch++ {
+					taken := "0"
+					switch {
+					case !line.IsCovered():
+						taken = "-"
+					case branch < line.CoveredBranches:
+						taken = "1"
+					}
+					builder.WriteString(fmt.Sprintf("BRDA:%d,0,%d,%s\n", line.Number, branch, taken))
+				}
+				branchesFound += line.Branches
+				branchesHit += line.CoveredBranches
+			}
+			if branchesFound > 0 {
+				builder.WriteString(fmt.Sprintf("BRF:%d\nBRH:%d\n", branchesFound, branchesHit))
+			}
+
+			for _, line := range file.Lines {
+				builder.WriteString(fmt.Sprintf("DA:%d,%d\n", line.Number, line.Hits))
+				linesFound++
+				if line.IsCovered() {
+					linesHit++
+				}
+			}
+			builder.WriteString(fmt.Sprintf("LF:%d\nLH:%d\n", linesFound, linesHit))
+			builder.WriteString("end_of_record\n")
+		}
+	}
+	return builder.String()
+}
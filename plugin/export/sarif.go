@@ -0,0 +1,173 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/diff"
+)
+
+// SarifLog is the subset of SARIF 2.1.0 needed to report coverage gaps to
+// code scanning dashboards.
+type SarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool    SarifTool     `json:"tool"`
+	Results []SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     SarifMessage       `json:"shortDescription"`
+	DefaultConfiguration SarifConfiguration `json:"defaultConfiguration"`
+}
+
+type SarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   SarifMessage    `json:"message"`
+	Locations []SarifLocation `json:"locations"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           SarifRegion           `json:"region"`
+}
+
+type SarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	UriBaseID string `json:"uriBaseId"`
+}
+
+type SarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// GetSarifLog reports every range of uncovered lines and every partially
+// covered line as a result. With changed lines only the results in the
+// changed lines are kept, located by their path in the diff.
+func GetSarifLog(report *coverage.Report, resolvePath PathResolver, changedLines diff.ChangedLines) SarifLog {
+
+	run := SarifRun{
+		Tool: SarifTool{Driver: SarifDriver{
+			Name:           SarifToolName,
+			InformationUri: SarifToolInformationUri,
+			Rules:          SarifRules,
+		}},
+		Results: []SarifResult{},
+	}
+
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			filePath := resolvePath(file)
+			include := func(lineNumber int) bool { return true }
+			if changedLines != nil {
+				diffPath, lines := changedLines.GetFileLines(file.GetSourcePath())
+				if len(lines) == 0 {
+					continue
+				}
+				filePath = diffPath
+				include = func(lineNumber int) bool { return diff.Contains(lines, lineNumber) }
+			}
+
+			uncoveredRanges := coverage.GetLineRanges(file.Lines, func(line coverage.Line) bool {
+				return !line.IsCovered() && include(line.Number)
+			})
+			for _, lineRange := range uncoveredRanges {
+				run.Results = append(run.Results, getSarifResult(0, GetUncoveredLinesMessage(lineRange),
+					filePath, lineRange))
+			}
+
+			for _, line := range file.Lines {
+				if line.IsPartiallyCovered() && include(line.Number) {
+					run.Results = append(run.Results, getSarifResult(1, GetPartiallyCoveredLineMessage(line),
+						filePath, coverage.LineRange{Start: line.Number, End: line.Number}))
+				}
+			}
+		}
+	}
+
+	return SarifLog{Schema: SarifSchema, Version: SarifVersion, Runs: []SarifRun{run}}
+}
+
+func GetUncoveredLinesMessage(lineRange coverage.LineRange) string {
+	if lineRange.Start == lineRange.End {
+		return fmt.Sprintf("Line %d is not covered by tests.", lineRange.Start)
+	}
+	return fmt.Sprintf("Lines %s are not covered by tests.", lineRange.String())
+}
+
+func GetPartiallyCoveredLineMessage(line coverage.Line) string {
+	return fmt.Sprintf("Line %d has %d of %d branches covered.", line.Number, line.CoveredBranches, line.Branches)
+}
+
+func (s SarifLog) Json() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func getSarifResult(ruleIndex int, message, filePath string, lineRange coverage.LineRange) SarifResult {
+	rule := SarifRules[ruleIndex]
+	return SarifResult{
+		RuleID:    rule.ID,
+		RuleIndex: ruleIndex,
+		Level:     rule.DefaultConfiguration.Level,
+		Message:   SarifMessage{Text: message},
+		Locations: []SarifLocation{{PhysicalLocation: SarifPhysicalLocation{
+			ArtifactLocation: SarifArtifactLocation{URI: filePath, UriBaseID: "%SRCROOT%"},
+			Region:           SarifRegion{StartLine: lineRange.Start, EndLine: lineRange.End},
+		}}},
+	}
+}
+
+// SarifRules has a rule per metric, uncovered lines and partially covered
+// branches.
+var SarifRules = []SarifRule{
+	{
+		ID:                   "coverage/line",
+		Name:                 "UncoveredLines",
+		ShortDescription:     SarifMessage{Text: "Lines not covered by tests"},
+		DefaultConfiguration: SarifConfiguration{Level: "warning"},
+	},
+	{
+		ID:                   "coverage/branch",
+		Name:                 "PartiallyCoveredBranches",
+		ShortDescription:     SarifMessage{Text: "Lines with branches not covered by tests"},
+		DefaultConfiguration: SarifConfiguration{Level: "note"},
+	},
+}
+
+const (
+	SarifSchema             = "https://json.schemastore.org/sarif-2.1.0.json"
+	SarifVersion            = "2.1.0"
+	SarifToolName           = "drone-coverage-report"
+	SarifToolInformationUri = "https://github.com/harness-community/drone-coverage-report"
+)
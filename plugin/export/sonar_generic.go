@@ -0,0 +1,67 @@
+package export
+
+import (
+	"encoding/xml"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"sort"
+)
+
+// SonarGenericCoverage is SonarQube's Generic Test Coverage format, read by
+// the scanner through sonar.coverageReportPaths.
+type SonarGenericCoverage struct {
+	XMLName xml.Name           `xml:"coverage"`
+	Version int                `xml:"version,attr"`
+	Files   []SonarGenericFile `xml:"file"`
+}
+
+type SonarGenericFile struct {
+	Path  string             `xml:"path,attr"`
+	Lines []SonarLineToCover `xml:"lineToCover"`
+}
+
+type SonarLineToCover struct {
+	LineNumber      int  `xml:"lineNumber,attr"`
+	Covered         bool `xml:"covered,attr"`
+	BranchesToCover *int `xml:"branchesToCover,attr"`
+	CoveredBranches *int `xml:"coveredBranches,attr"`
+}
+
+func GetSonarGenericCoverage(report *coverage.Report, resolvePath PathResolver) SonarGenericCoverage {
+	filesByPath := map[string]*SonarGenericFile{}
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			path := resolvePath(file)
+			sonarFile := filesByPath[path]
+			if sonarFile == nil {
+				sonarFile = &SonarGenericFile{Path: path}
+				filesByPath[path] = sonarFile
+			}
+			for _, line := range file.Lines {
+				lineToCover := SonarLineToCover{LineNumber: line.Number, Covered: line.IsCovered()}
+				if line.Branches > 0 {
+					branches, coveredBranches := line.Branches, line.CoveredBranches
+					lineToCover.BranchesToCover = &branches
+					lineToCover.CoveredBranches = &coveredBranches
+				}
+				sonarFile.Lines = append(sonarFile.Lines, lineToCover)
+			}
+		}
+	}
+
+	sonarCoverage := SonarGenericCoverage{Version: 1}
+	for _, sonarFile := range filesByPath {
+		sonarCoverage.Files = append(sonarCoverage.Files, *sonarFile)
+	}
+	sort.Slice(sonarCoverage.Files, func(i, j int) bool {
+		return sonarCoverage.Files[i].Path < sonarCoverage.Files[j].Path
+	})
+	return sonarCoverage
+}
+
+func (s SonarGenericCoverage) Xml() ([]byte, error) {
+	data, err := xml.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
@@ -0,0 +1,182 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/diff"
+	"github.com/harness-community/drone-coverage-report/plugin/export"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+// WriteExports converts the report into the Codecov and SonarQube generic
+// coverage formats, writing each to its path and uploading it when an
+// upload URL is configured.
+func WriteExports(report *coverage.Report, args pd.Args, workSpaceDir string) error {
+
+	resolvePath, err := GetExportPathResolver(report, args, workSpaceDir)
+	if err != nil {
+		return err
+	}
+
+	if args.CodecovPath != "" || args.CodecovUrl != "" {
+		err = ExportCodecovReport(report, resolvePath, args, workSpaceDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	if args.SonarCoveragePath != "" || args.SonarUploadUrl != "" {
+		err = ExportSonarGenericCoverage(report, resolvePath, args, workSpaceDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteConvertedReport writes the report in the conversion format, which
+// lets the step convert between coverage formats.
+func WriteConvertedReport(report *coverage.Report, args pd.Args, workSpaceDir string) error {
+
+	resolvePath, err := GetExportPathResolver(report, args, workSpaceDir)
+	if err != nil {
+		return err
+	}
+
+	data, err := export.Convert(report, args.ConvertFormat, resolvePath)
+	if err != nil {
+		return err
+	}
+
+	outputPath := args.ConvertOutputPath
+	if outputPath == "" {
+		outputPath = export.GetDefaultOutputName(args.ConvertFormat)
+	}
+	outputPath = pd.GetWorkSpaceRelativePath(workSpaceDir, outputPath)
+
+	err = pd.WriteFileAsString(outputPath, string(data))
+	if err != nil {
+		return err
+	}
+
+	logrus.Printf("Coverage report converted to %s and written to %s\n", args.ConvertFormat, outputPath)
+	return nil
+}
+
+// WriteSarifReport writes the uncovered line ranges as SARIF, limited to the
+// changed lines when configured.
+func WriteSarifReport(report *coverage.Report, args pd.Args, workSpaceDir string) error {
+
+	resolvePath, err := GetExportPathResolver(report, args, workSpaceDir)
+	if err != nil {
+		return err
+	}
+
+	var changedLines diff.ChangedLines
+	if args.SarifChangedLinesOnly {
+		changedLines, err = GetChangedLines(args, workSpaceDir)
+		if err != nil {
+			return err
+		}
+		if changedLines == nil {
+			return fmt.Errorf("sarif_changed_lines_only needs diff_base or diff_file")
+		}
+	}
+
+	data, err := export.GetSarifLog(report, resolvePath, changedLines).Json()
+	if err != nil {
+		return err
+	}
+
+	sarifPath := pd.GetWorkSpaceRelativePath(workSpaceDir, args.SarifPath)
+	err = pd.WriteFileAsString(sarifPath, string(data))
+	if err != nil {
+		return err
+	}
+
+	logrus.Printf("SARIF coverage report written to %s\n", sarifPath)
+	return nil
+}
+
+// GetExportPathResolver publishes files under their path relative to the
+// workspace, found through the source directories.
+func GetExportPathResolver(report *coverage.Report, args pd.Args, workSpaceDir string) (export.PathResolver, error) {
+	locator, err := coverage.GetNewSourceLocator(workSpaceDir,
+		pd.ToStringArrayFromCsvString(args.SourcePattern), report.Sources)
+	if err != nil {
+		return nil, err
+	}
+	return export.GetSourcePathResolver(locator, workSpaceDir), nil
+}
+
+func ExportCodecovReport(report *coverage.Report, resolvePath export.PathResolver, args pd.Args,
+	workSpaceDir string) error {
+
+	codecovJson, err := export.GetCodecovReport(report, resolvePath).Json()
+	if err != nil {
+		return err
+	}
+
+	if args.CodecovPath != "" {
+		codecovPath := pd.GetWorkSpaceRelativePath(workSpaceDir, args.CodecovPath)
+		err = pd.WriteFileAsString(codecovPath, string(codecovJson))
+		if err != nil {
+			return err
+		}
+		logrus.Printf("Codecov report written to %s\n", codecovPath)
+	}
+
+	if args.CodecovUrl != "" {
+		reportUrl, err := export.UploadToCodecov(context.Background(), nil, export.CodecovUpload{
+			URL:         args.CodecovUrl,
+			Token:       args.CodecovToken,
+			Slug:        args.Repo.Slug,
+			Commit:      args.Commit.Rev,
+			Branch:      args.Commit.Branch,
+			Build:       strconv.Itoa(args.Build.Number),
+			BuildURL:    args.Build.Link,
+			PullRequest: args.PullRequest.Number,
+		}, codecovJson)
+		if err != nil {
+			return err
+		}
+		logrus.Printf("Codecov report uploaded: %s\n", reportUrl)
+	}
+
+	return nil
+}
+
+func ExportSonarGenericCoverage(report *coverage.Report, resolvePath export.PathResolver, args pd.Args,
+	workSpaceDir string) error {
+
+	sonarXml, err := export.GetSonarGenericCoverage(report, resolvePath).Xml()
+	if err != nil {
+		return err
+	}
+
+	if args.SonarCoveragePath != "" {
+		sonarPath := pd.GetWorkSpaceRelativePath(workSpaceDir, args.SonarCoveragePath)
+		err = pd.WriteFileAsString(sonarPath, string(sonarXml))
+		if err != nil {
+			return err
+		}
+		logrus.Printf("SonarQube generic coverage report written to %s\n", sonarPath)
+	}
+
+	if args.SonarUploadUrl != "" {
+		_, err = export.Upload(context.Background(), nil, http.MethodPost, args.SonarUploadUrl,
+			export.GetBearerAuthorization(args.SonarToken), "application/xml", sonarXml)
+		if err != nil {
+			return err
+		}
+		logrus.Printf("SonarQube generic coverage report uploaded to %s\n",
+			export.GetRedactedUrl(args.SonarUploadUrl))
+	}
+
+	return nil
+}
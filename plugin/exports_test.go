@@ -0,0 +1,262 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/harness-community/drone-coverage-report/plugin/cobertura"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/export"
+	"github.com/harness-community/drone-coverage-report/plugin/jacoco"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const TestCalculatorPath = "cobertura-sample/bad-metrics-project/src/main/java/com/example/package1/Calculator.java"
+
+func TestCoberturaCodecovAndSonarExports(t *testing.T) {
+
+	outputDir := t.TempDir()
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.SourcePattern = "**/bad-metrics-project/src/main/java"
+	args.CodecovPath = filepath.Join(outputDir, "codecov.json")
+	args.SonarCoveragePath = filepath.Join(outputDir, "sonar-coverage.xml")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaCodecovAndSonarExports: %s", err.Error())
+	}
+
+	data, err := os.ReadFile(args.CodecovPath)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaCodecovAndSonarExports: %s", err.Error())
+	}
+	var codecovReport struct {
+		Coverage map[string]map[string]interface{} `json:"coverage"`
+	}
+	err = json.Unmarshal(data, &codecovReport)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaCodecovAndSonarExports: %s", err.Error())
+	}
+	calculatorLines := codecovReport.Coverage[TestCalculatorPath]
+	if calculatorLines["5"] != 1.0 || calculatorLines["9"] != 0.0 || calculatorLines["17"] != "0/2" {
+		t.Errorf("Error in TestCoberturaCodecovAndSonarExports: unexpected codecov lines %v", calculatorLines)
+	}
+	if len(codecovReport.Coverage) != 3 {
+		t.Errorf("Error in TestCoberturaCodecovAndSonarExports: expected 3 files, found %d",
+			len(codecovReport.Coverage))
+	}
+
+	sonarXml, err := pd.ReadFileAsString(args.SonarCoveragePath)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaCodecovAndSonarExports: %s", err.Error())
+	}
+	expectedContents := []string{
+		`<coverage version="1">`,
+		`<file path="` + TestCalculatorPath + `">`,
+		`<lineToCover lineNumber="5" covered="true"></lineToCover>`,
+		`<lineToCover lineNumber="17" covered="false" branchesToCover="2" coveredBranches="0"></lineToCover>`,
+	}
+	for _, expected := range expectedContents {
+		if !strings.Contains(sonarXml, expected) {
+			t.Errorf("Error in TestCoberturaCodecovAndSonarExports: sonar xml does not contain %s", expected)
+		}
+	}
+}
+
+func TestCoberturaCodecovAndSonarUploads(t *testing.T) {
+
+	var announceQuery, announceAuth, codecovPayload, sonarPayload, sonarAuth string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/v4":
+			announceQuery = r.URL.RawQuery
+			announceAuth = r.Header.Get("Authorization")
+			_, _ = w.Write([]byte("https://codecov.example.com/report/1\n" + server.URL + "/storage/1"))
+		case r.Method == http.MethodPut && r.URL.Path == "/storage/1":
+			codecovPayload = string(body)
+		case r.Method == http.MethodPost && r.URL.Path == "/sonar":
+			sonarPayload = string(body)
+			sonarAuth = r.Header.Get("Authorization")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.Repo.Slug = "octocat/hello-world"
+	args.Commit.Rev = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+	args.Build.Number = 12
+	args.CodecovUrl = server.URL
+	args.CodecovToken = "codecov-token"
+	args.SonarUploadUrl = server.URL + "/sonar"
+	args.SonarToken = "sonar-token"
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaCodecovAndSonarUploads: %s", err.Error())
+	}
+
+	for _, expected := range []string{"commit=" + args.Commit.Rev, "build=12", "slug=octocat%2Fhello-world"} {
+		if !strings.Contains(announceQuery, expected) {
+			t.Errorf("Error in TestCoberturaCodecovAndSonarUploads: upload query does not contain %s", expected)
+		}
+	}
+	if strings.Contains(announceQuery, "codecov-token") || announceAuth != "token codecov-token" {
+		t.Errorf("Error in TestCoberturaCodecovAndSonarUploads: codecov token not sent in the header, query %s",
+			announceQuery)
+	}
+	if !strings.HasPrefix(codecovPayload, "# path=codecov.json\n{") ||
+		!strings.Contains(codecovPayload, `"`+TestCalculatorPath+`"`) ||
+		!strings.HasSuffix(codecovPayload, "<<<<<< EOF\n") {
+		t.Errorf("Error in TestCoberturaCodecovAndSonarUploads: unexpected codecov payload %s", codecovPayload)
+	}
+	if !strings.Contains(sonarPayload, `<file path="`+TestCalculatorPath+`">`) ||
+		sonarAuth != "Bearer sonar-token" {
+		t.Errorf("Error in TestCoberturaCodecovAndSonarUploads: unexpected sonar upload %s", sonarPayload)
+	}
+}
+
+func TestUploadErrorsRedactUrls(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	serverUrl := server.URL
+
+	_, err := export.Upload(context.TODO(), nil, http.MethodPut, serverUrl+"/storage?signature=secret",
+		"", "text/plain", nil)
+	if err == nil || strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Error in TestUploadErrorsRedactUrls: unexpected status error %v", err)
+	}
+
+	server.Close()
+	_, err = export.Upload(context.TODO(), nil, http.MethodPut, serverUrl+"/storage?signature=secret",
+		"", "text/plain", nil)
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Error in TestUploadErrorsRedactUrls: unexpected network error %v", err)
+	}
+}
+
+// GetTestGameOfLifeCoreWorkSpace copies the JaCoCo report and the sources
+// of gameoflife-core to a new workspace, so the copies other tests leave in
+// the test workspace do not match its report paths.
+func GetTestGameOfLifeCoreWorkSpace(t *testing.T) string {
+	workSpaceDir := t.TempDir()
+	for _, relDir := range []string{"game-of-life/gameoflife-core/target/site/jacoco",
+		"game-of-life/gameoflife-core/src/main/java"} {
+		srcDir := filepath.Join(pd.TestWorkSpaceDir, relDir)
+		err := filepath.WalkDir(srcDir, func(completePath string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			relPath, _ := filepath.Rel(pd.TestWorkSpaceDir, completePath)
+			dstPath := filepath.Join(workSpaceDir, relPath)
+			err = os.MkdirAll(filepath.Dir(dstPath), 0755)
+			if err != nil {
+				return err
+			}
+			return pd.CopyFile(completePath, dstPath)
+		})
+		if err != nil {
+			t.Fatalf("Error in GetTestGameOfLifeCoreWorkSpace: %s", err.Error())
+		}
+	}
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, workSpaceDir)
+	return workSpaceDir
+}
+
+func TestJacocoXmlConvertToCobertura(t *testing.T) {
+
+	GetTestGameOfLifeCoreWorkSpace(t)
+
+	args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	args.ExecFilesPathPattern = "**/gameoflife-core/**/jacoco.xml"
+	args.ConvertFormat = "cobertura"
+	args.ConvertOutputPath = filepath.Join(t.TempDir(), "cobertura.xml")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlConvertToCobertura: %s", err.Error())
+	}
+
+	converted, err := cobertura.ParseCoberturaCoverageXml(args.ConvertOutputPath)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlConvertToCobertura: %s", err.Error())
+	}
+	report := converted.ToCoverageReport()
+
+	lineCounter := report.Counters.Get(coverage.LineCounter)
+	branchCounter := report.Counters.Get(coverage.BranchCounter)
+	if lineCounter.Covered != 122 || lineCounter.Missed != 0 || branchCounter.Covered != 57 || branchCounter.Missed != 1 {
+		t.Errorf("Error in TestJacocoXmlConvertToCobertura: unexpected counters line %v branch %v",
+			lineCounter, branchCounter)
+	}
+	if report.Packages[0].Name != "com.wakaleo.gameoflife.domain" ||
+		report.Packages[0].Files[0].Path != "game-of-life/gameoflife-core/src/main/java/com/wakaleo/gameoflife/domain/Universe.java" {
+		t.Errorf("Error in TestJacocoXmlConvertToCobertura: unexpected package %s", report.Packages[0].Name)
+	}
+}
+
+func TestCoberturaConvertToLcovAndJacoco(t *testing.T) {
+
+	outputDir := t.TempDir()
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.SourcePattern = "**/bad-metrics-project/src/main/java"
+	args.ConvertFormat = "lcov"
+	args.ConvertOutputPath = filepath.Join(outputDir, "lcov.info")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaConvertToLcovAndJacoco: %s", err.Error())
+	}
+
+	lcov, err := pd.ReadFileAsString(args.ConvertOutputPath)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaConvertToLcovAndJacoco: %s", err.Error())
+	}
+	expectedRecord := "TN:\nSF:" + TestCalculatorPath + "\nBRDA:17,0,0,-\nBRDA:17,0,1,-\nBRF:2\nBRH:0\n" +
+		"DA:3,1\nDA:5,1\nDA:9,0\nDA:13,0\nDA:17,0\nDA:18,0\nLF:6\nLH:2\nend_of_record\n"
+	if !strings.Contains(lcov, expectedRecord) || strings.Count(lcov, "end_of_record") != 3 {
+		t.Errorf("Error in TestCoberturaConvertToLcovAndJacoco: unexpected lcov %s", lcov)
+	}
+
+	args.ConvertFormat = "jacoco"
+	args.ConvertOutputPath = filepath.Join(outputDir, "jacoco.xml")
+
+	_, err = Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaConvertToLcovAndJacoco: %s", err.Error())
+	}
+
+	jacocoReport, err := jacoco.ParseXMLReport(args.ConvertOutputPath)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaConvertToLcovAndJacoco: %s", err.Error())
+	}
+	covered, missed := jacoco.GetCounterValues(jacocoReport.Counters, "LINE")
+	if covered != 2 || missed != 15 {
+		t.Errorf("Error in TestCoberturaConvertToLcovAndJacoco: unexpected line counter %d/%d", covered, missed)
+	}
+	if jacocoReport.Packages[0].Name != "com/example/package1" ||
+		jacocoReport.Packages[0].SourceFiles[0].Name != "Calculator.java" {
+		t.Errorf("Error in TestCoberturaConvertToLcovAndJacoco: unexpected package %s", jacocoReport.Packages[0].Name)
+	}
+
+	args.ConvertFormat = "clover"
+	_, err = Exec(context.TODO(), args)
+	if err == nil || !strings.Contains(err.Error(), `unknown conversion format "clover"`) {
+		t.Errorf("Error in TestCoberturaConvertToLcovAndJacoco: expected unknown format error, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilterFileOrDirUsingGlobPatterns(t *testing.T) {
+
+	rootDir := filepath.Join(pd.TestWorkSpaceDir, "game-of-life")
+	filesInfoStore, err := pd.FilterFileOrDirUsingGlobPatterns(context.TODO(), rootDir,
+		[]string{"**/target/classes", "**/WEB-INF/classes"}, "**/*.class, **/*.xml", "**/controllers/*.class", "")
+	if err != nil {
+		t.Fatalf("Error in TestFilterFileOrDirUsingGlobPatterns: %s", err.Error())
+	}
+
+	var relPaths []string
+	for _, merged := range pd.MergeIncludeExcludeFileCompletePaths(filesInfoStore) {
+		for _, pathWithPrefix := range merged.CompletePathsWithPrefixList {
+			relPaths = append(relPaths, pathWithPrefix.RelativePath)
+		}
+	}
+
+	// gameoflife-build comes first, then gameoflife-core
+	expected := []string{
+		"custom-checkstyle.xml",
+		"pmd-rules.xml",
+		"com/wakaleo/gameoflife/domain/Cell.class",
+		"com/wakaleo/gameoflife/domain/Grid.class",
+		"com/wakaleo/gameoflife/domain/GridReader.class",
+		"com/wakaleo/gameoflife/domain/GridWriter.class",
+		"com/wakaleo/gameoflife/domain/Universe.class",
+	}
+	if !reflect.DeepEqual(relPaths, expected) {
+		t.Errorf("Error in TestFilterFileOrDirUsingGlobPatterns: expected %v, got %v", expected, relPaths)
+	}
+
+	_, err = pd.FilterFileOrDirUsingGlobPatterns(context.TODO(), rootDir, []string{"**/target/classes"},
+		"**/[*.class", "", "")
+	if err == nil {
+		t.Errorf("Error in TestFilterFileOrDirUsingGlobPatterns: bad include pattern accepted")
+	}
+
+	_, err = pd.FilterFileOrDirUsingGlobPatterns(context.TODO(), filepath.Join(t.TempDir(), "missing"),
+		[]string{"**/target/classes"}, "**/*.class", "", "")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Error in TestFilterFileOrDirUsingGlobPatterns: unreadable root dir not reported, got %v", err)
+	}
+}
+
+func TestFileDiscoveryFollowsSymlinks(t *testing.T) {
+
+	workSpaceDir, outputDir := t.TempDir(), t.TempDir()
+	classFilePath := filepath.Join(outputDir, "classes", "com", "shop", "Cart.class")
+	err := os.MkdirAll(filepath.Dir(classFilePath), 0755)
+	if err == nil {
+		err = os.WriteFile(classFilePath, []byte("class"), 0644)
+	}
+	if err == nil {
+		err = os.MkdirAll(filepath.Join(workSpaceDir, "mod", "target"), 0755)
+	}
+	if err == nil {
+		err = os.Symlink(filepath.Join(outputDir, "classes"), filepath.Join(workSpaceDir, "mod", "target", "classes"))
+	}
+	if err == nil {
+		err = os.Symlink(filepath.Join(workSpaceDir, "mod"), filepath.Join(workSpaceDir, "mod", "loop"))
+	}
+	if err != nil {
+		t.Fatalf("Error in TestFileDiscoveryFollowsSymlinks: %s", err.Error())
+	}
+
+	for _, dirPattern := range []string{"mod/target/classes", "**/target/classes"} {
+		filesInfoStore, err := pd.FilterFileOrDirUsingGlobPatterns(context.TODO(), workSpaceDir,
+			[]string{dirPattern}, "**/*.class", "", "")
+		if err != nil {
+			t.Fatalf("Error in TestFileDiscoveryFollowsSymlinks: %s", err.Error())
+		}
+
+		var relPaths []string
+		for _, merged := range pd.MergeIncludeExcludeFileCompletePaths(filesInfoStore) {
+			for _, pathWithPrefix := range merged.CompletePathsWithPrefixList {
+				relPaths = append(relPaths, pathWithPrefix.RelativePath)
+			}
+		}
+		if !reflect.DeepEqual(relPaths, []string{"com/shop/Cart.class"}) {
+			t.Errorf("Error in TestFileDiscoveryFollowsSymlinks: %s found %v", dirPattern, relPaths)
+		}
+	}
+}
+
+func TestFileDiscoveryCancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := pd.FilterFileOrDirUsingGlobPatterns(ctx, pd.TestWorkSpaceDir, []string{"**/target/classes"},
+		"**/*.class", "", "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Error in TestFileDiscoveryCancelled: walk not cancelled, got %v", err)
+	}
+
+	dstDir := t.TempDir()
+	err = pd.CopyFiles(ctx, []pd.FileCopy{{
+		SrcPath: filepath.Join(pd.TestWorkSpaceDir, "game-of-life", "pom.xml"),
+		DstPath: filepath.Join(dstDir, "pom.xml"),
+	}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Error in TestFileDiscoveryCancelled: copy not cancelled, got %v", err)
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+
+	workSpaceDir := GetTestFileDiscoveryWorkSpace(t, 3, 20)
+	filesInfoStore, err := pd.FilterFileOrDirUsingGlobPatterns(context.TODO(), workSpaceDir,
+		[]string{"**/target/classes"}, "**/*.class", "**/internal/*.class", "")
+	if err != nil {
+		t.Fatalf("Error in TestCopyTo: %s", err.Error())
+	}
+
+	dstDir := t.TempDir()
+	for _, merged := range pd.MergeIncludeExcludeFileCompletePaths(filesInfoStore) {
+		err = merged.CopyTo(context.TODO(), dstDir, workSpaceDir)
+		if err != nil {
+			t.Fatalf("Error in TestCopyTo: %s", err.Error())
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		_, err = os.Stat(filepath.Join(dstDir, "com", "shop", fmt.Sprintf("Class%d.class", i)))
+		if err != nil {
+			t.Errorf("Error in TestCopyTo: %s", err.Error())
+		}
+	}
+	_, err = os.Stat(filepath.Join(dstDir, "com", "shop", "internal"))
+	if !os.IsNotExist(err) {
+		t.Errorf("Error in TestCopyTo: excluded classes copied")
+	}
+
+	logs := CaptureLogs(t, "", "")
+	err = pd.CopyFiles(context.TODO(), []pd.FileCopy{{
+		SrcPath: filepath.Join(workSpaceDir, "missing.class"),
+		DstPath: filepath.Join(dstDir, "missing.class"),
+	}})
+	if err != nil || !strings.Contains(logs.String(), "Error in copying file") {
+		t.Errorf("Error in TestCopyTo: copy failure not skipped with a warning, got %v, logs %s", err, logs.String())
+	}
+}
+
+// GetTestFileDiscoveryWorkSpace writes a workspace of modules with class
+// and source files, some of them in an internal package, and returns its
+// directory.
+func GetTestFileDiscoveryWorkSpace(tb testing.TB, modules, classes int) string {
+	workSpaceDir := tb.TempDir()
+	for m := 0; m < modules; m++ {
+		moduleDir := filepath.Join(workSpaceDir, fmt.Sprintf("module-%d", m))
+		for c := 0; c < classes; c++ {
+			for _, filePath := range []string{
+				filepath.Join(moduleDir, "target", "classes", "com", "shop", fmt.Sprintf("Class%d.class", c)),
+				filepath.Join(moduleDir, "target", "classes", "com", "shop", "internal", fmt.Sprintf("Class%d.class", c)),
+				filepath.Join(moduleDir, "src", "main", "java", "com", "shop", fmt.Sprintf("Class%d.java", c)),
+			} {
+				err := os.MkdirAll(filepath.Dir(filePath), 0755)
+				if err == nil {
+					err = os.WriteFile(filePath, []byte("class"), 0644)
+				}
+				if err != nil {
+					tb.Fatalf("Error in GetTestFileDiscoveryWorkSpace: %s", err.Error())
+				}
+			}
+		}
+	}
+	return workSpaceDir
+}
+
+func BenchmarkFilterFileOrDirUsingGlobPatterns(b *testing.B) {
+
+	workSpaceDir := GetTestFileDiscoveryWorkSpace(b, 40, 250)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := pd.FilterFileOrDirUsingGlobPatterns(context.TODO(), workSpaceDir,
+			[]string{"**/target/classes", "**/src/main/java"}, "**/*.class, **/*.java", "**/internal/*.class", "")
+		if err != nil {
+			b.Fatalf("Error in BenchmarkFilterFileOrDirUsingGlobPatterns: %s", err.Error())
+		}
+	}
+}
+
+func BenchmarkCopyTo(b *testing.B) {
+
+	workSpaceDir := GetTestFileDiscoveryWorkSpace(b, 10, 250)
+	filesInfoStore, err := pd.FilterFileOrDirUsingGlobPatterns(context.TODO(), workSpaceDir,
+		[]string{"**/target/classes"}, "**/*.class", "", "")
+	if err != nil {
+		b.Fatalf("Error in BenchmarkCopyTo: %s", err.Error())
+	}
+	mergedList := pd.MergeIncludeExcludeFileCompletePaths(filesInfoStore)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dstDir := b.TempDir()
+		for _, merged := range mergedList {
+			err = merged.CopyTo(context.TODO(), dstDir, workSpaceDir)
+			if err != nil {
+				b.Fatalf("Error in BenchmarkCopyTo: %s", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Entry is the coverage of one build in the history store.
+type Entry struct {
+	Repo        string            `json:"repo"`
+	Branch      string            `json:"branch"`
+	Commit      string            `json:"commit"`
+	BuildNumber int               `json:"buildNumber"`
+	Timestamp   int64             `json:"timestamp"`
+	Tool        string            `json:"tool"`
+	Counters    coverage.Counters `json:"counters"`
+	Passed      bool              `json:"passed"`
+
+	RatchetFloors map[coverage.CounterType]float64 `json:"ratchetFloors,omitempty"`
+}
+
+func GetEntry(summary pd.CoverageSummary, timestamp time.Time) Entry {
+	return Entry{
+		Repo:        summary.Repo,
+		Branch:      summary.Branch,
+		Commit:      summary.Commit,
+		BuildNumber: summary.BuildNumber,
+		Timestamp:   timestamp.Unix(),
+		Tool:        summary.Tool,
+		Counters:    summary.Counters,
+		Passed:      summary.Passed,
+
+		RatchetFloors: summary.RatchetFloors,
+	}
+}
+
+// Key identifies the build of the entry. A build that runs again replaces
+// its earlier entry.
+func (e Entry) Key() string {
+	return e.Repo + "/" + e.Branch + "/" + e.Commit + "/" + strconv.Itoa(e.BuildNumber)
+}
+
+// Store keeps one JSON entry per line in a file, which is meant to live on a
+// volume that is cached between builds.
+type Store struct {
+	Path string
+}
+
+func GetNewStore(storePath string) *Store {
+	return &Store{Path: storePath}
+}
+
+func (s *Store) Append(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.Path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// ReadAll returns the entries in the order they were added. A store that
+// does not exist yet has no entries.
+func (s *Store) ReadAll() ([]Entry, error) {
+	file, err := os.Open(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	positions := map[string]int{}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid history entry in %s line %d: %w", s.Path, lineNumber, err)
+		}
+
+		if position, ok := positions[entry.Key()]; ok {
+			entries[position] = entry
+			continue
+		}
+		positions[entry.Key()] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// GetLastEntries returns up to count of the latest entries of the repo
+// branch, oldest first.
+func (s *Store) GetLastEntries(repo, branch string, count int) ([]Entry, error) {
+	entries, err := s.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var branchEntries []Entry
+	for _, entry := range entries {
+		if entry.Repo == repo && entry.Branch == branch {
+			branchEntries = append(branchEntries, entry)
+		}
+	}
+	if count > 0 && len(branchEntries) > count {
+		branchEntries = branchEntries[len(branchEntries)-count:]
+	}
+	return branchEntries, nil
+}
+
+// GetLatestRatchetFloors returns the ratchet floors of the latest entry of
+// the repo branch that has them.
+func (s *Store) GetLatestRatchetFloors(repo, branch string) (map[coverage.CounterType]float64, error) {
+	entries, err := s.GetLastEntries(repo, branch, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if len(entries[i].RatchetFloors) > 0 {
+			return entries[i].RatchetFloors, nil
+		}
+	}
+	return map[coverage.CounterType]float64{}, nil
+}
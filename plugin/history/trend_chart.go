@@ -0,0 +1,112 @@
+package history
+
+import (
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"html"
+	"strings"
+)
+
+// TrendCounterTypes are the metrics drawn in the trend chart and table.
+var TrendCounterTypes = []coverage.CounterType{coverage.LineCounter, coverage.BranchCounter}
+
+var trendColors = map[coverage.CounterType]string{
+	coverage.LineCounter:   "#0969da",
+	coverage.BranchCounter: "#bf8700",
+}
+
+// GetTrendCounterTypes returns the trend metrics present in the entries.
+func GetTrendCounterTypes(entries []Entry) []coverage.CounterType {
+	var counterTypes []coverage.CounterType
+	for _, counterType := range TrendCounterTypes {
+		for _, entry := range entries {
+			if entry.Counters.Has(counterType) {
+				counterTypes = append(counterTypes, counterType)
+				break
+			}
+		}
+	}
+	return counterTypes
+}
+
+// GetBuildLabel names the build of the entry, e.g. "#12".
+func (e Entry) GetBuildLabel() string {
+	if e.BuildNumber > 0 {
+		return fmt.Sprintf("#%d", e.BuildNumber)
+	}
+	if len(e.Commit) > 7 {
+		return e.Commit[:7]
+	}
+	return e.Commit
+}
+
+// RenderTrendChart draws the coverage percentage of every trend metric over
+// the entries as an SVG line chart.
+func RenderTrendChart(entries []Entry) string {
+
+	const width, height = 640, 220
+	const left, right, top, bottom = 44, 16, 16, 40
+	plotWidth, plotHeight := float64(width-left-right), float64(height-top-bottom)
+
+	x := func(i int) float64 {
+		if len(entries) < 2 {
+			return float64(left) + plotWidth/2
+		}
+		return float64(left) + plotWidth*float64(i)/float64(len(entries)-1)
+	}
+	y := func(percentage float64) float64 {
+		return float64(top) + plotHeight*(100-percentage)/100
+	}
+
+	var svg strings.Builder
+	svg.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="Coverage trend">`,
+		width, height, width, height))
+	svg.WriteString("\n")
+	svg.WriteString(`<g font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11" fill="#57606a">` + "\n")
+
+	for _, gridPercentage := range []float64{0, 25, 50, 75, 100} {
+		svg.WriteString(fmt.Sprintf(`<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#d0d7de" stroke-width="1"/>`+"\n",
+			left, y(gridPercentage), width-right, y(gridPercentage)))
+		svg.WriteString(fmt.Sprintf(`<text x="%d" y="%.1f" text-anchor="end">%.0f%%</text>`+"\n",
+			left-6, y(gridPercentage)+4, gridPercentage))
+	}
+
+	labelStep := 1 + len(entries)/10
+	for i, entry := range entries {
+		if i%labelStep == 0 || i == len(entries)-1 {
+			svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n",
+				x(i), height-bottom+16, html.EscapeString(entry.GetBuildLabel())))
+		}
+	}
+
+	counterTypes := GetTrendCounterTypes(entries)
+	for legendIndex, counterType := range counterTypes {
+		color := trendColors[counterType]
+
+		var points []string
+		for i, entry := range entries {
+			if entry.Counters.Has(counterType) {
+				points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(entry.Counters.Get(counterType).Percentage())))
+			}
+		}
+		svg.WriteString(fmt.Sprintf(`<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n",
+			strings.Join(points, " "), color))
+
+		for i, entry := range entries {
+			if !entry.Counters.Has(counterType) {
+				continue
+			}
+			percentage := entry.Counters.Get(counterType).Percentage()
+			svg.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %s: %.2f%%</title></circle>`+"\n",
+				x(i), y(percentage), color, html.EscapeString(entry.GetBuildLabel()), counterType.DisplayName(), percentage))
+		}
+
+		legendX := left + legendIndex*90
+		svg.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`+"\n",
+			legendX, height-14, color))
+		svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d">%s</text>`+"\n", legendX+14, height-5, counterType.DisplayName()))
+	}
+
+	svg.WriteString("</g>\n</svg>\n")
+	return svg.String()
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"context"
+	"github.com/harness-community/drone-coverage-report/plugin/history"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoberturaHistoryTrend(t *testing.T) {
+
+	outputDir := t.TempDir()
+	historyPath := filepath.Join(outputDir, "cache", "coverage-history.jsonl")
+
+	builds := []struct {
+		branch      string
+		buildNumber int
+	}{
+		{"main", 1}, {"main", 2}, {"feature", 3}, {"main", 4}, {"main", 4},
+	}
+
+	var args pd.Args
+	for _, build := range builds {
+		args = GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+		args.PluginFailOnThreshold = false
+		args.Repo.Slug = "octocat/hello-world"
+		args.Commit.Branch = build.branch
+		args.Commit.Rev = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+		args.Build.Number = build.buildNumber
+		args.HistoryPath = historyPath
+		args.HistoryBuilds = 2
+		args.HtmlReportDir = filepath.Join(outputDir, "html")
+		args.Card.Path = filepath.Join(outputDir, "card.json")
+
+		_, err := Exec(context.TODO(), args)
+		if err != nil {
+			t.Fatalf("Error in TestCoberturaHistoryTrend: %s", err.Error())
+		}
+	}
+
+	store := history.GetNewStore(historyPath)
+	entries, err := store.ReadAll()
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaHistoryTrend: %s", err.Error())
+	}
+	if len(entries) != 4 {
+		t.Errorf("Error in TestCoberturaHistoryTrend: expected 4 builds in the history, found %d", len(entries))
+	}
+
+	mainEntries, err := store.GetLastEntries("octocat/hello-world", "main", 2)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaHistoryTrend: %s", err.Error())
+	}
+	if len(mainEntries) != 2 || mainEntries[0].BuildNumber != 2 || mainEntries[1].BuildNumber != 4 {
+		t.Errorf("Error in TestCoberturaHistoryTrend: unexpected main branch builds %v", mainEntries)
+	}
+
+	CheckHtmlReportPage(t, args.HtmlReportDir, "index.html",
+		"Coverage over the last 2 builds", `<polyline points=`, "<td>#4</td>", "<td>11.76%</td>")
+
+	card, err := pd.ReadFileAsString(args.Card.Path)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaHistoryTrend: %s", err.Error())
+	}
+	if !strings.Contains(card, `"history":[{"name":"#4","value":"Line 11.76%, Branch 0.00%"},{"name":"#2"`) {
+		t.Errorf("Error in TestCoberturaHistoryTrend: card does not list the last builds: %s", card)
+	}
+}
@@ -0,0 +1,26 @@
+// Sorts the coverage tables by the clicked column.
+document.addEventListener("DOMContentLoaded", function () {
+  document.querySelectorAll("table.sortable").forEach(function (table) {
+    table.querySelectorAll("th").forEach(function (th, column) {
+      var ascending = true;
+      th.addEventListener("click", function () {
+        var body = table.tBodies[0];
+        var rows = Array.prototype.slice.call(body.rows);
+        rows.sort(function (a, b) {
+          var x = a.cells[column], y = b.cells[column];
+          if (!x || !y) {
+            return 0;
+          }
+          var xv = x.dataset.value !== undefined ? parseFloat(x.dataset.value) : x.textContent.trim();
+          var yv = y.dataset.value !== undefined ? parseFloat(y.dataset.value) : y.textContent.trim();
+          var result = xv < yv ? -1 : xv > yv ? 1 : 0;
+          return ascending ? result : -result;
+        });
+        rows.forEach(function (row) {
+          body.appendChild(row);
+        });
+        ascending = !ascending;
+      });
+    });
+  });
+});
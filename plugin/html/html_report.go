@@ -0,0 +1,384 @@
+package html
+
+import (
+	"embed"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/history"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+//go:embed assets/*
+var assetsFS embed.FS
+
+// HtmlReport renders a navigable project -> package -> file coverage report
+// with annotated source lines.
+type HtmlReport struct {
+	Report        *coverage.Report
+	SourceLocator *coverage.SourceLocator
+	Title         string
+	OutputDir     string
+	History       []history.Entry
+
+	templates   *template.Template
+	columns     []coverage.CounterType
+	generatedAt string
+}
+
+type CounterCell struct {
+	Type       coverage.CounterType
+	Covered    int
+	Missed     int
+	Total      int
+	Percentage string
+	Level      string
+}
+
+type Row struct {
+	Name  string
+	Link  string
+	Cells []CounterCell
+}
+
+type Crumb struct {
+	Name string
+	Link string
+}
+
+type SourceLineView struct {
+	Number int
+	Text   string
+	Class  string
+	Title  string
+}
+
+type TrendRow struct {
+	Build  string
+	Commit string
+	Cells  []string
+	Passed bool
+}
+
+type PageData struct {
+	Title       string
+	Tool        string
+	GeneratedAt string
+	Crumbs      []Crumb
+	Heading     string
+	Columns     []coverage.CounterType
+	Totals      []CounterCell
+	Rows        []Row
+	RowsLabel   string
+	Lines       []SourceLineView
+	IsFilePage  bool
+	SourceFound bool
+
+	TrendChart   template.HTML
+	TrendColumns []coverage.CounterType
+	TrendRows    []TrendRow
+}
+
+func GetNewHtmlReport(report *coverage.Report, locator *coverage.SourceLocator, title, outputDir string) *HtmlReport {
+	if title == "" {
+		title = "Coverage Report"
+	}
+	return &HtmlReport{
+		Report:        report,
+		SourceLocator: locator,
+		Title:         title,
+		OutputDir:     outputDir,
+	}
+}
+
+// Write renders the complete report into the output dir and returns the path
+// of the index page.
+func (h *HtmlReport) Write() (string, error) {
+
+	templates, err := template.New("").Funcs(template.FuncMap{
+		"lower": func(c coverage.CounterType) string { return strings.ToLower(string(c)) },
+	}).ParseFS(templatesFS, "templates/*.html")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse html templates: %w", err)
+	}
+	h.templates = templates
+	h.columns = GetColumns(h.Report)
+	h.generatedAt = time.Now().UTC().Format(time.RFC1123)
+
+	err = os.MkdirAll(h.OutputDir, os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("failed to create html report dir %s: %w", h.OutputDir, err)
+	}
+
+	err = h.writeAssets()
+	if err != nil {
+		return "", err
+	}
+
+	err = h.writeIndexPage()
+	if err != nil {
+		return "", err
+	}
+
+	for _, pkg := range h.Report.Packages {
+		err = h.writePackagePage(pkg)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range pkg.Files {
+			err = h.writeFilePage(pkg, file)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return filepath.Join(h.OutputDir, IndexPageName), nil
+}
+
+func (h *HtmlReport) writeAssets() error {
+	return fs.WalkDir(assetsFS, "assets", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := assetsFS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(h.OutputDir, d.Name()), data, 0644)
+	})
+}
+
+func (h *HtmlReport) writeIndexPage() error {
+	data := h.newPageData(h.Title)
+	data.Crumbs = []Crumb{{Name: h.Title}}
+	data.Totals = h.getCells(h.Report.Counters)
+	data.RowsLabel = "Package"
+
+	for _, pkg := range SortedPackages(h.Report.Packages) {
+		data.Rows = append(data.Rows, Row{
+			Name:  GetPackageDisplayName(pkg.Name),
+			Link:  GetPackagePageName(pkg.Name),
+			Cells: h.getCells(pkg.Counters),
+		})
+	}
+
+	if len(h.History) > 0 {
+		data.TrendChart = template.HTML(history.RenderTrendChart(h.History))
+		data.TrendColumns = history.GetTrendCounterTypes(h.History)
+		data.TrendRows = GetTrendRows(h.History, data.TrendColumns)
+	}
+
+	return h.writePage(IndexPageName, "summary.html", data)
+}
+
+func (h *HtmlReport) writePackagePage(pkg *coverage.Package) error {
+	data := h.newPageData(GetPackageDisplayName(pkg.Name))
+	data.Crumbs = []Crumb{
+		{Name: h.Title, Link: IndexPageName},
+		{Name: GetPackageDisplayName(pkg.Name)},
+	}
+	data.Totals = h.getCells(pkg.Counters)
+	data.RowsLabel = "File"
+
+	for _, file := range SortedFiles(pkg.Files) {
+		data.Rows = append(data.Rows, Row{
+			Name:  file.Name,
+			Link:  GetFilePageName(pkg.Name, file.Name),
+			Cells: h.getCells(file.Counters),
+		})
+	}
+
+	return h.writePage(GetPackagePageName(pkg.Name), "summary.html", data)
+}
+
+func (h *HtmlReport) writeFilePage(pkg *coverage.Package, file *coverage.File) error {
+	data := h.newPageData(file.Name)
+	data.Crumbs = []Crumb{
+		{Name: h.Title, Link: IndexPageName},
+		{Name: GetPackageDisplayName(pkg.Name), Link: GetPackagePageName(pkg.Name)},
+		{Name: file.Name},
+	}
+	data.Totals = h.getCells(file.Counters)
+	data.IsFilePage = true
+
+	sourceLines, found := h.SourceLocator.ReadSourceLines(file)
+	data.SourceFound = found
+	data.Lines = GetSourceLineViews(file.Lines, sourceLines)
+
+	return h.writePage(GetFilePageName(pkg.Name, file.Name), "file.html", data)
+}
+
+func (h *HtmlReport) writePage(pageName, templateName string, data PageData) error {
+	pageFile, err := os.Create(filepath.Join(h.OutputDir, pageName))
+	if err != nil {
+		return fmt.Errorf("failed to create html page %s: %w", pageName, err)
+	}
+	defer pageFile.Close()
+
+	err = h.templates.ExecuteTemplate(pageFile, templateName, data)
+	if err != nil {
+		return fmt.Errorf("failed to render html page %s: %w", pageName, err)
+	}
+	return nil
+}
+
+func (h *HtmlReport) newPageData(heading string) PageData {
+	return PageData{
+		Title:       h.Title,
+		Tool:        h.Report.Tool,
+		GeneratedAt: h.generatedAt,
+		Heading:     heading,
+		Columns:     h.columns,
+	}
+}
+
+func (h *HtmlReport) getCells(counters coverage.Counters) []CounterCell {
+	var cells []CounterCell
+	for _, counterType := range h.columns {
+		counter := counters.Get(counterType)
+		cells = append(cells, CounterCell{
+			Type:       counterType,
+			Covered:    counter.Covered,
+			Missed:     counter.Missed,
+			Total:      counter.Total(),
+			Percentage: fmt.Sprintf("%.2f%%", counter.Percentage()),
+			Level:      GetCoverageLevel(counter),
+		})
+	}
+	return cells
+}
+
+// GetTrendRows lists the builds of the history newest first.
+func GetTrendRows(entries []history.Entry, counterTypes []coverage.CounterType) []TrendRow {
+	var rows []TrendRow
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		row := TrendRow{Build: entry.GetBuildLabel(), Commit: entry.Commit, Passed: entry.Passed}
+		if len(row.Commit) > 7 {
+			row.Commit = row.Commit[:7]
+		}
+		for _, counterType := range counterTypes {
+			cell := "-"
+			if entry.Counters.Has(counterType) {
+				cell = fmt.Sprintf("%.2f%%", entry.Counters.Get(counterType).Percentage())
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// GetSourceLineViews annotates every source line with the coverage data of
+// the report. Without source only the lines known to the report are listed.
+func GetSourceLineViews(lines []coverage.Line, sourceLines []string) []SourceLineView {
+
+	linesMap := map[int]coverage.Line{}
+	for _, line := range lines {
+		linesMap[line.Number] = line
+	}
+
+	var views []SourceLineView
+	if len(sourceLines) == 0 {
+		for _, line := range lines {
+			views = append(views, GetSourceLineView(line.Number, "", line, true))
+		}
+		return views
+	}
+
+	for i, text := range sourceLines {
+		line, ok := linesMap[i+1]
+		views = append(views, GetSourceLineView(i+1, text, line, ok))
+	}
+	return views
+}
+
+func GetSourceLineView(number int, text string, line coverage.Line, hasCoverage bool) SourceLineView {
+	view := SourceLineView{Number: number, Text: text}
+	if !hasCoverage {
+		return view
+	}
+
+	switch {
+	case line.IsPartiallyCovered():
+		view.Class = "pc"
+	case line.IsCovered():
+		view.Class = "fc"
+	default:
+		view.Class = "nc"
+	}
+
+	view.Title = fmt.Sprintf("hits: %d", line.Hits)
+	if line.Branches > 0 {
+		view.Title += fmt.Sprintf(", branches: %d of %d covered", line.CoveredBranches, line.Branches)
+	}
+	return view
+}
+
+// GetColumns returns the counter types reported by the tool.
+func GetColumns(report *coverage.Report) []coverage.CounterType {
+	var columns []coverage.CounterType
+	for _, counterType := range coverage.AllCounterTypes {
+		if report.Counters.Has(counterType) {
+			columns = append(columns, counterType)
+		}
+	}
+	return columns
+}
+
+func GetCoverageLevel(counter coverage.Counter) string {
+	if counter.Total() == 0 {
+		return "none"
+	}
+	switch percentage := counter.Percentage(); {
+	case percentage >= HighCoveragePercentage:
+		return "high"
+	case percentage >= MediumCoveragePercentage:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+func GetPackageDisplayName(packageName string) string {
+	if packageName == "" {
+		return DefaultPackageName
+	}
+	return strings.ReplaceAll(packageName, "/", ".")
+}
+
+func GetPackagePageName(packageName string) string {
+	return GetPackageDisplayName(packageName) + ".html"
+}
+
+func GetFilePageName(packageName, fileName string) string {
+	return GetPackageDisplayName(packageName) + "." + strings.ReplaceAll(fileName, "/", ".") + ".html"
+}
+
+func SortedPackages(packages []*coverage.Package) []*coverage.Package {
+	sorted := append([]*coverage.Package{}, packages...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+func SortedFiles(files []*coverage.File) []*coverage.File {
+	sorted := append([]*coverage.File{}, files...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+const (
+	IndexPageName            = "index.html"
+	DefaultPackageName       = "(default)"
+	HighCoveragePercentage   = 80.0
+	MediumCoveragePercentage = 50.0
+)
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"context"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoberturaHtmlReport(t *testing.T) {
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.SourcePattern = "**/bad-metrics-project/src/main/java"
+	args.HtmlReportDir = t.TempDir()
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaHtmlReport: %s", err.Error())
+	}
+
+	CheckHtmlReportPage(t, args.HtmlReportDir, "index.html", `href="com.example.package1.html"`)
+	CheckHtmlReportPage(t, args.HtmlReportDir, "com.example.package1.html",
+		`href="com.example.package1.Calculator.java.html"`)
+	CheckHtmlReportPage(t, args.HtmlReportDir, "com.example.package1.Calculator.java.html",
+		`<tr id="L5" class="fc"`, `<tr id="L17" class="nc"`, "public int add")
+	CheckHtmlReportPage(t, args.HtmlReportDir, "report.css", "tr.fc")
+}
+
+func TestJacocoXmlHtmlReport(t *testing.T) {
+
+	args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	args.ExecFilesPathPattern = "**/gameoflife-core/**/jacoco.xml"
+	args.SourcePattern = "**/gameoflife-core/src/main/java"
+	args.HtmlReportDir = t.TempDir()
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlHtmlReport: %s", err.Error())
+	}
+
+	CheckHtmlReportPage(t, args.HtmlReportDir, "index.html", "INSTRUCTION", "com.wakaleo.gameoflife.domain")
+	CheckHtmlReportPage(t, args.HtmlReportDir, "com.wakaleo.gameoflife.domain.Grid.java.html",
+		`<tr id="L21" class="fc"`, "package com.wakaleo.gameoflife.domain;")
+}
+
+func CheckHtmlReportPage(t *testing.T, reportDir, pageName string, expectedContents ...string) {
+	data, err := os.ReadFile(filepath.Join(reportDir, pageName))
+	if err != nil {
+		t.Fatalf("Error in CheckHtmlReportPage: %s", err.Error())
+	}
+	for _, expected := range expectedContents {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Error in CheckHtmlReportPage: %s does not contain %s", pageName, expected)
+		}
+	}
+}
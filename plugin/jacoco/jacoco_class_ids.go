@@ -0,0 +1,198 @@
+package jacoco
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExecDataMismatch is a class file whose id is not among the ids of the
+// execution data of its class: the class was recompiled after the tests
+// ran, and JaCoCo reports it as not covered at all.
+type ExecDataMismatch struct {
+	Name          string
+	ClassFilePath string
+	ClassId       uint64
+	ExecIds       []uint64
+}
+
+func (m ExecDataMismatch) String() string {
+	return fmt.Sprintf("Execution data for class %s does not match, class file %s has id %016x", m.Name,
+		m.ClassFilePath, m.ClassId)
+}
+
+// GetClassId returns the id JaCoCo gives to a class file, the CRC64 of its
+// bytes.
+func GetClassId(classBytes []byte) uint64 {
+	// JaCoCo computes the id of Java 9 class files as if they were Java 8
+	// ones, a leftover of its early Java 9 support
+	if len(classBytes) > 7 && classBytes[6] == 0 && classBytes[7] == classFileVersionJava9 {
+		sum := updateCrc64(0, classBytes[:7])
+		sum = updateCrc64(sum, []byte{classFileVersionJava8})
+		return updateCrc64(sum, classBytes[8:])
+	}
+	return updateCrc64(0, classBytes)
+}
+
+func updateCrc64(sum uint64, data []byte) uint64 {
+	for _, b := range data {
+		sum = (sum >> 8) ^ crc64Table[byte(sum)^b]
+	}
+	return sum
+}
+
+var crc64Table = func() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		value := uint64(i)
+		for j := 0; j < 8; j++ {
+			if value&1 == 1 {
+				value = (value >> 1) ^ crc64Polynomial
+			} else {
+				value >>= 1
+			}
+		}
+		table[i] = value
+	}
+	return table
+}()
+
+// GetClassName returns the internal name of the class of a class file, like
+// com/example/Cart, read from its constant pool.
+func GetClassName(classBytes []byte) (string, error) {
+
+	if len(classBytes) < 10 || binary.BigEndian.Uint32(classBytes) != classFileMagic {
+		return "", errors.New("not a class file")
+	}
+
+	count := int(binary.BigEndian.Uint16(classBytes[8:]))
+	utf8Entries := map[int]string{}
+	classEntries := map[int]int{}
+	offset := 10
+	for index := 1; index < count; index++ {
+		if offset >= len(classBytes) {
+			return "", errors.New("truncated constant pool")
+		}
+		tag := classBytes[offset]
+		size, ok := constantPoolEntrySizes[tag]
+		if !ok {
+			return "", fmt.Errorf("unknown constant pool tag %d", tag)
+		}
+		if tag == constantUtf8 && offset+3 <= len(classBytes) {
+			size = 3 + int(binary.BigEndian.Uint16(classBytes[offset+1:]))
+		}
+		if size == 0 || offset+size > len(classBytes) {
+			return "", errors.New("truncated constant pool")
+		}
+
+		switch tag {
+		case constantUtf8:
+			utf8Entries[index] = string(classBytes[offset+3 : offset+size])
+		case constantClass:
+			classEntries[index] = int(binary.BigEndian.Uint16(classBytes[offset+1:]))
+		}
+		offset += size
+		// longs and doubles take two entries of the constant pool
+		if tag == constantLong || tag == constantDouble {
+			index++
+		}
+	}
+
+	if offset+4 > len(classBytes) {
+		return "", errors.New("truncated class file")
+	}
+	thisClass := int(binary.BigEndian.Uint16(classBytes[offset+2:]))
+	name, ok := utf8Entries[classEntries[thisClass]]
+	if !ok {
+		return "", errors.New("class name not found in the constant pool")
+	}
+	return name, nil
+}
+
+// GetExecDataMismatches compares the class files found in classesDir with
+// the execution data. Classes without execution data were not loaded by the
+// tests and are not mismatches.
+func GetExecDataMismatches(classesDir string, data *ExecData) ([]ExecDataMismatch, error) {
+
+	execIdsByName := map[string][]uint64{}
+	for _, class := range data.Classes {
+		execIdsByName[class.Name] = append(execIdsByName[class.Name], class.Id)
+	}
+
+	var mismatches []ExecDataMismatch
+	err := filepath.WalkDir(classesDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(entry.Name(), ".class") {
+			return err
+		}
+
+		classBytes, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := GetClassName(classBytes)
+		if err != nil {
+			return fmt.Errorf("invalid class file %s: %w", path, err)
+		}
+
+		execIds, ok := execIdsByName[name]
+		if !ok {
+			return nil
+		}
+		classId := GetClassId(classBytes)
+		if _, ok := data.GetClass(classId); ok {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(classesDir, path)
+		mismatches = append(mismatches, ExecDataMismatch{Name: name, ClassFilePath: filepath.ToSlash(relPath),
+			ClassId: classId, ExecIds: execIds})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].ClassFilePath < mismatches[j].ClassFilePath
+	})
+	return mismatches, nil
+}
+
+const (
+	crc64Polynomial       = 0xd800000000000000
+	classFileMagic        = 0xcafebabe
+	classFileVersionJava8 = 52
+	classFileVersionJava9 = 53
+
+	constantUtf8   = 1
+	constantLong   = 5
+	constantDouble = 6
+	constantClass  = 7
+)
+
+// constantPoolEntrySizes are the sizes of the constant pool entries, tag
+// included, except for the utf8 ones whose size is in the entry.
+var constantPoolEntrySizes = map[byte]int{
+	constantUtf8:   0,
+	3:              5, // integer
+	4:              5, // float
+	constantLong:   9,
+	constantDouble: 9,
+	constantClass:  3,
+	8:              3, // string
+	9:              5, // field ref
+	10:             5, // method ref
+	11:             5, // interface method ref
+	12:             5, // name and type
+	15:             4, // method handle
+	16:             3, // method type
+	17:             5, // dynamic
+	18:             5, // invoke dynamic
+	19:             3, // module
+	20:             3, // package
+}
@@ -9,10 +9,30 @@ import (
 	"os"
 )
 
+// Report is a JaCoCo xml report. Aggregated reports nest their packages in
+// groups, one per module, instead of listing them at the top.
 type Report struct {
-	XMLName  xml.Name  `xml:"report"`
-	Counters []Counter `xml:"counter"`
+	XMLName      xml.Name      `xml:"report"`
+	Name         string        `xml:"name,attr"`
+	SessionInfos []SessionInfo `xml:"sessioninfo"`
+	Groups       []Group       `xml:"group"`
+	Packages     []Package     `xml:"package"`
+	Counters     []Counter     `xml:"counter"`
+}
+
+// SessionInfo is a test run whose execution data is in the report, with
+// its start and dump times in milliseconds.
+type SessionInfo struct {
+	Id    string `xml:"id,attr"`
+	Start int64  `xml:"start,attr"`
+	Dump  int64  `xml:"dump,attr"`
+}
+
+type Group struct {
+	Name     string    `xml:"name,attr"`
+	Groups   []Group   `xml:"group"`
 	Packages []Package `xml:"package"`
+	Counters []Counter `xml:"counter"`
 }
 
 type Counter struct {
@@ -22,8 +42,38 @@ type Counter struct {
 }
 
 type Package struct {
-	Name     string    `xml:"name,attr"`
-	Counters []Counter `xml:"counter"`
+	Name        string       `xml:"name,attr"`
+	Counters    []Counter    `xml:"counter"`
+	Classes     []Class      `xml:"class"`
+	SourceFiles []SourceFile `xml:"sourcefile"`
+}
+
+type Class struct {
+	Name           string    `xml:"name,attr"`
+	SourceFileName string    `xml:"sourcefilename,attr"`
+	Methods        []Method  `xml:"method"`
+	Counters       []Counter `xml:"counter"`
+}
+
+type Method struct {
+	Name       string    `xml:"name,attr"`
+	Descriptor string    `xml:"desc,attr"`
+	Line       int       `xml:"line,attr"`
+	Counters   []Counter `xml:"counter"`
+}
+
+type SourceFile struct {
+	Name     string       `xml:"name,attr"`
+	Lines    []SourceLine `xml:"line"`
+	Counters []Counter    `xml:"counter"`
+}
+
+type SourceLine struct {
+	Number              int `xml:"nr,attr"`
+	MissedInstructions  int `xml:"mi,attr"`
+	CoveredInstructions int `xml:"ci,attr"`
+	MissedBranches      int `xml:"mb,attr"`
+	CoveredBranches     int `xml:"cb,attr"`
 }
 
 func (j *JacocoCoverageThresholds) ToFloat64() JacocoCoverageThresholdsValues {
@@ -83,28 +133,35 @@ func CalculateCoverageMetrics(report Report) JacocoCoverageThresholds {
 	}
 }
 
-func ParseXMLReport(filename string) Report {
+func ParseXMLReport(filename string) (Report, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		logrus.Fatalf("Error opening XML file: %v", err)
+		return Report{}, plg.GetNewKindError(plg.ErrParse, "Error opening XML file: "+err.Error())
 	}
 	defer file.Close()
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		logrus.Fatalf("Error reading XML file: %v", err)
+		return Report{}, plg.GetNewKindError(plg.ErrParse, "Error reading XML file: "+err.Error())
 	}
 
 	var report Report
 	err = xml.Unmarshal(data, &report)
 	if err != nil {
-		logrus.Fatalf("Error unmarshalling XML: %v", err)
+		return Report{}, plg.GetNewKindError(plg.ErrParse, "Error unmarshalling XML "+filename+": "+err.Error())
+	}
+	return report, nil
+}
+
+func GetJacocoCoverageThresholds(completeXmlPath string) (JacocoCoverageThresholdsValues, error) {
+	report, err := ParseXMLReport(completeXmlPath)
+	if err != nil {
+		return JacocoCoverageThresholdsValues{}, err
 	}
-	return report
+	return GetJacocoCoverageThresholdsFromReport(report), nil
 }
 
-func GetJacocoCoverageThresholds(completeXmlPath string) JacocoCoverageThresholdsValues {
-	report := ParseXMLReport(completeXmlPath)
+func GetJacocoCoverageThresholdsFromReport(report Report) JacocoCoverageThresholdsValues {
 	coverageThresholds := CalculateCoverageMetrics(report)
 
 	plg.LogPrintf(nil, "Coverage Metrics:")
@@ -0,0 +1,476 @@
+package jacoco
+
+import (
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"path"
+	"sort"
+	"strings"
+)
+
+func ToCoverageCounters(counters []Counter) coverage.Counters {
+	coverageCounters := coverage.Counters{}
+	for _, counter := range counters {
+		coverageCounters[coverage.CounterType(counter.Type)] = coverage.Counter{
+			Covered: counter.Covered,
+			Missed:  counter.Missed,
+		}
+	}
+	return coverageCounters
+}
+
+func ToCoverageLines(lines []SourceLine) []coverage.Line {
+	var coverageLines []coverage.Line
+	for _, line := range lines {
+		coverageLines = append(coverageLines, coverage.Line{
+			Number:          line.Number,
+			Hits:            line.CoveredInstructions,
+			Branches:        line.MissedBranches + line.CoveredBranches,
+			CoveredBranches: line.CoveredBranches,
+		})
+	}
+	return coverage.SortLines(coverageLines)
+}
+
+// ToCoverageReport converts the parsed JaCoCo xml report into the tool
+// independent coverage model. The packages of groups are listed with those
+// at the top of the report, with the group as their module.
+func (r *Report) ToCoverageReport(toolType string) *coverage.Report {
+
+	coverageReport := &coverage.Report{
+		Tool:     toolType,
+		Name:     r.Name,
+		Counters: ToCoverageCounters(r.Counters),
+	}
+
+	r.ForEachPackage(func(module string, pkg Package) {
+		coveragePackage := &coverage.Package{
+			Name:     pkg.Name,
+			Module:   module,
+			Counters: ToCoverageCounters(pkg.Counters),
+		}
+		for _, sourceFile := range pkg.SourceFiles {
+			coveragePackage.Files = append(coveragePackage.Files, &coverage.File{
+				Name:     sourceFile.Name,
+				Path:     coverage.GetFilePath(pkg.Name, sourceFile.Name),
+				Counters: ToCoverageCounters(sourceFile.Counters),
+				Lines:    ToCoverageLines(sourceFile.Lines),
+			})
+		}
+		coverageReport.Packages = append(coverageReport.Packages, coveragePackage)
+	})
+
+	return coverageReport
+}
+
+// GetPackages returns the packages of the report, those of its groups
+// included, in document order.
+func (r *Report) GetPackages() []Package {
+	var packages []Package
+	r.ForEachPackage(func(module string, pkg Package) {
+		packages = append(packages, pkg)
+	})
+	return packages
+}
+
+// ForEachPackage calls fn with the packages of the report in document
+// order, together with their module: the names of the groups they are
+// nested in, joined by slashes, or "" for packages outside groups.
+func (r *Report) ForEachPackage(fn func(module string, pkg Package)) {
+	for _, pkg := range r.Packages {
+		fn("", pkg)
+	}
+	forEachGroupPackage(r.Groups, "", fn)
+}
+
+func forEachGroupPackage(groups []Group, parentModule string, fn func(module string, pkg Package)) {
+	for _, group := range groups {
+		module := group.Name
+		if parentModule != "" {
+			module = parentModule + "/" + group.Name
+		}
+		for _, pkg := range group.Packages {
+			fn(module, pkg)
+		}
+		forEachGroupPackage(group.Groups, module, fn)
+	}
+}
+
+// Filter removes the classes whose class path or source file path is not
+// selected by the filters, the source files left without classes and the
+// packages and groups left empty. Package, group and report counters are
+// recomputed from the remaining classes. It returns the number of classes
+// removed.
+func (r *Report) Filter(classFilter, sourceFilter *coverage.PathFilter) int {
+
+	if classFilter.IsEmpty() && sourceFilter.IsEmpty() {
+		return 0
+	}
+
+	var removed int
+	r.Packages, removed = filterPackages(r.Packages, classFilter, sourceFilter)
+	r.Counters = nil
+	for _, pkg := range r.Packages {
+		r.Counters = SumCounters(r.Counters, pkg.Counters)
+	}
+
+	var groupsRemoved int
+	r.Groups, groupsRemoved = filterGroups(r.Groups, classFilter, sourceFilter)
+	for _, group := range r.Groups {
+		r.Counters = SumCounters(r.Counters, group.Counters)
+	}
+	return removed + groupsRemoved
+}
+
+func filterGroups(groups []Group, classFilter, sourceFilter *coverage.PathFilter) ([]Group, int) {
+
+	removed := 0
+	var filtered []Group
+	for _, group := range groups {
+		var packagesRemoved, groupsRemoved int
+		group.Packages, packagesRemoved = filterPackages(group.Packages, classFilter, sourceFilter)
+		group.Groups, groupsRemoved = filterGroups(group.Groups, classFilter, sourceFilter)
+		removed += packagesRemoved + groupsRemoved
+		if len(group.Packages) == 0 && len(group.Groups) == 0 {
+			continue
+		}
+
+		group.Counters = nil
+		for _, pkg := range group.Packages {
+			group.Counters = SumCounters(group.Counters, pkg.Counters)
+		}
+		for _, subGroup := range group.Groups {
+			group.Counters = SumCounters(group.Counters, subGroup.Counters)
+		}
+		filtered = append(filtered, group)
+	}
+	return filtered, removed
+}
+
+func filterPackages(packages []Package, classFilter, sourceFilter *coverage.PathFilter) ([]Package, int) {
+
+	removed := 0
+	var filtered []Package
+	for _, pkg := range packages {
+		var classes []Class
+		includedClasses := map[int]bool{}
+		includedSourceFiles, excludedSourceFiles := map[string]bool{}, map[string]bool{}
+		for i, class := range pkg.Classes {
+			if classFilter.IsIncluded(coverage.GetClassPath(class.Name)) &&
+				sourceFilter.IsIncluded(coverage.GetFilePath(pkg.Name, class.SourceFileName)) {
+				classes = append(classes, class)
+				includedClasses[i] = true
+				includedSourceFiles[class.SourceFileName] = true
+			} else {
+				excludedSourceFiles[class.SourceFileName] = true
+			}
+		}
+		removed += len(pkg.Classes) - len(classes)
+
+		var sourceFiles []SourceFile
+		for _, sourceFile := range pkg.SourceFiles {
+			if len(pkg.Classes) > 0 && !includedSourceFiles[sourceFile.Name] {
+				continue
+			}
+			if excludedSourceFiles[sourceFile.Name] {
+				sourceFile = filterSourceFileClasses(pkg.Classes, sourceFile, includedClasses)
+			}
+			className := strings.TrimSuffix(sourceFile.Name, path.Ext(sourceFile.Name))
+			if len(pkg.Classes) == 0 && (!classFilter.IsIncluded(coverage.GetClassPath(path.Join(pkg.Name, className))) ||
+				!sourceFilter.IsIncluded(coverage.GetFilePath(pkg.Name, sourceFile.Name))) {
+				removed++
+				continue
+			}
+			sourceFiles = append(sourceFiles, sourceFile)
+		}
+
+		pkg.Classes, pkg.SourceFiles = classes, sourceFiles
+		if len(classes) == 0 && len(sourceFiles) == 0 {
+			continue
+		}
+		pkg.Counters = nil
+		for _, class := range classes {
+			pkg.Counters = SumCounters(pkg.Counters, class.Counters)
+		}
+		if len(classes) == 0 {
+			for _, sourceFile := range sourceFiles {
+				pkg.Counters = SumCounters(pkg.Counters, sourceFile.Counters)
+			}
+		}
+		filtered = append(filtered, pkg)
+	}
+	return filtered, removed
+}
+
+// filterSourceFileClasses returns the source file shared by included and
+// excluded classes with the lines of the excluded classes removed, and its
+// counters summed from the included classes.
+func filterSourceFileClasses(classes []Class, sourceFile SourceFile, includedClasses map[int]bool) SourceFile {
+
+	lineMethods := getLineMethods(classes, sourceFile)
+	var lines []SourceLine
+	for _, line := range sourceFile.Lines {
+		owner, ok := lineMethods[line.Number]
+		if !ok || includedClasses[owner.class] {
+			lines = append(lines, line)
+		}
+	}
+
+	filtered := SourceFile{Name: sourceFile.Name, Lines: lines}
+	for i, class := range classes {
+		if includedClasses[i] && class.SourceFileName == sourceFile.Name {
+			filtered.Counters = SumCounters(filtered.Counters, class.Counters)
+		}
+	}
+	return filtered
+}
+
+// SumCounters adds the other counters to the counters of the same type,
+// keeping the order the types first appear in.
+func SumCounters(counters []Counter, other []Counter) []Counter {
+	for _, counter := range other {
+		found := false
+		for i := range counters {
+			if counters[i].Type == counter.Type {
+				counters[i].Covered += counter.Covered
+				counters[i].Missed += counter.Missed
+				found = true
+				break
+			}
+		}
+		if !found {
+			counters = append(counters, counter)
+		}
+	}
+	return counters
+}
+
+// ExcludeLines removes the excluded lines, given by report file path, from
+// the source files and takes their instructions, branches and lines off the
+// class, source file, package, group and report counters. Methods left
+// without lines are removed with their METHOD and COMPLEXITY counters, and
+// classes left without methods with their CLASS counter, like the Cobertura
+// report does. It returns the number of lines removed.
+func (r *Report) ExcludeLines(excludedLines map[string]map[int]bool) int {
+
+	reportRemoved := map[string]Counter{}
+	removed := excludePackageLines(r.Packages, excludedLines, reportRemoved)
+	removed += excludeGroupLines(r.Groups, excludedLines, reportRemoved)
+	r.Counters = SubtractCounters(r.Counters, reportRemoved)
+
+	return removed
+}
+
+// excludeGroupLines removes the excluded lines of the groups and adds the
+// counters taken off them to removedCounters.
+func excludeGroupLines(groups []Group, excludedLines map[string]map[int]bool,
+	removedCounters map[string]Counter) int {
+
+	removed := 0
+	for i := range groups {
+		group := &groups[i]
+		groupRemoved := map[string]Counter{}
+		removed += excludePackageLines(group.Packages, excludedLines, groupRemoved)
+		removed += excludeGroupLines(group.Groups, excludedLines, groupRemoved)
+		group.Counters = SubtractCounters(group.Counters, groupRemoved)
+		AddCounters(removedCounters, groupRemoved)
+	}
+	return removed
+}
+
+// excludePackageLines removes the excluded lines of the packages and adds
+// the counters taken off them to removedCounters.
+func excludePackageLines(packages []Package, excludedLines map[string]map[int]bool,
+	removedCounters map[string]Counter) int {
+
+	removed := 0
+	for i := range packages {
+		pkg := &packages[i]
+		packageRemoved := map[string]Counter{}
+		removedClasses := map[int]bool{}
+		for j := range pkg.SourceFiles {
+			sourceFile := &pkg.SourceFiles[j]
+			excluded := excludedLines[coverage.GetFilePath(pkg.Name, sourceFile.Name)]
+			if len(excluded) == 0 {
+				continue
+			}
+
+			var lines, removedLines []SourceLine
+			for _, line := range sourceFile.Lines {
+				if excluded[line.Number] {
+					removedLines = append(removedLines, line)
+				} else {
+					lines = append(lines, line)
+				}
+			}
+			removed += len(removedLines)
+
+			fileRemoved := GetLineCounters(removedLines)
+			AddCounters(fileRemoved, excludeMethods(pkg, sourceFile, excluded, removedClasses))
+			sourceFile.Lines = lines
+			sourceFile.Counters = SubtractCounters(sourceFile.Counters, fileRemoved)
+			AddCounters(packageRemoved, fileRemoved)
+		}
+
+		if len(removedClasses) > 0 {
+			var classes []Class
+			for j, class := range pkg.Classes {
+				if !removedClasses[j] {
+					classes = append(classes, class)
+				}
+			}
+			pkg.Classes = classes
+		}
+		pkg.Counters = SubtractCounters(pkg.Counters, packageRemoved)
+		AddCounters(removedCounters, packageRemoved)
+	}
+	return removed
+}
+
+// excludeMethods takes the excluded lines of the source file off the
+// counters of the classes of the methods they belong to. Methods
+// whose lines are all excluded are removed, and classes left without
+// methods are added to removedClasses by index. It returns the METHOD,
+// COMPLEXITY and CLASS counters removed.
+func excludeMethods(pkg *Package, sourceFile *SourceFile, excluded map[int]bool,
+	removedClasses map[int]bool) map[string]Counter {
+
+	// the lines of each method, and the excluded ones
+	lineCount, excludedLines := map[methodIndex]int{}, map[methodIndex][]SourceLine{}
+	lineMethods := getLineMethods(pkg.Classes, *sourceFile)
+	for _, line := range sourceFile.Lines {
+		owner, ok := lineMethods[line.Number]
+		if !ok {
+			continue
+		}
+		lineCount[owner]++
+		if excluded[line.Number] {
+			excludedLines[owner] = append(excludedLines[owner], line)
+		}
+	}
+
+	removedCounters := map[string]Counter{}
+	for i := range pkg.Classes {
+		class := &pkg.Classes[i]
+		if class.SourceFileName != sourceFile.Name {
+			continue
+		}
+
+		classRemoved := map[string]Counter{}
+		var keptMethods []Method
+		for j, method := range class.Methods {
+			index := methodIndex{i, j}
+			AddCounters(classRemoved, GetLineCounters(excludedLines[index]))
+			if lineCount[index] == 0 || len(excludedLines[index]) < lineCount[index] {
+				keptMethods = append(keptMethods, method)
+				continue
+			}
+			methodRemoved := GetCounters(method.Counters, "METHOD", "COMPLEXITY")
+			AddCounters(classRemoved, methodRemoved)
+			AddCounters(removedCounters, methodRemoved)
+		}
+
+		if len(class.Methods) > 0 && len(keptMethods) == 0 {
+			removedClasses[i] = true
+			AddCounters(removedCounters, GetCounters(class.Counters, "CLASS"))
+		}
+		class.Methods = keptMethods
+		class.Counters = SubtractCounters(class.Counters, classRemoved)
+	}
+	return removedCounters
+}
+
+// methodIndex is a method of a package, by class and method index.
+type methodIndex struct{ class, method int }
+
+// getLineMethods returns the method of the classes each line of the source
+// file belongs to, a method owning the lines from its first line to the
+// first line of the next method of the file. Lines before the first method
+// belong to none.
+func getLineMethods(classes []Class, sourceFile SourceFile) map[int]methodIndex {
+
+	var methods []methodIndex
+	for i, class := range classes {
+		if class.SourceFileName != sourceFile.Name {
+			continue
+		}
+		for j, method := range class.Methods {
+			if method.Line > 0 {
+				methods = append(methods, methodIndex{i, j})
+			}
+		}
+	}
+	getLine := func(m methodIndex) int {
+		return classes[m.class].Methods[m.method].Line
+	}
+	sort.SliceStable(methods, func(i, j int) bool {
+		return getLine(methods[i]) < getLine(methods[j])
+	})
+
+	lineMethods := map[int]methodIndex{}
+	for _, line := range sourceFile.Lines {
+		owner := sort.Search(len(methods), func(i int) bool {
+			return getLine(methods[i]) > line.Number
+		}) - 1
+		if owner < 0 {
+			continue
+		}
+		// methods starting on the same line, like lambdas, share its lines
+		for owner > 0 && getLine(methods[owner-1]) == getLine(methods[owner]) {
+			owner--
+		}
+		lineMethods[line.Number] = methods[owner]
+	}
+	return lineMethods
+}
+
+// GetCounters returns the counters of the given types.
+func GetCounters(counters []Counter, counterTypes ...string) map[string]Counter {
+	selected := map[string]Counter{}
+	for _, counter := range counters {
+		for _, counterType := range counterTypes {
+			if counter.Type == counterType {
+				AddCounters(selected, map[string]Counter{counterType: counter})
+			}
+		}
+	}
+	return selected
+}
+
+// GetLineCounters returns the INSTRUCTION, BRANCH and LINE counters of the
+// lines.
+func GetLineCounters(lines []SourceLine) map[string]Counter {
+	counters := map[string]Counter{}
+	for _, line := range lines {
+		AddCounters(counters, map[string]Counter{
+			"INSTRUCTION": {Covered: line.CoveredInstructions, Missed: line.MissedInstructions},
+			"BRANCH":      {Covered: line.CoveredBranches, Missed: line.MissedBranches},
+		})
+		lineCounter := Counter{Missed: 1}
+		if line.CoveredInstructions > 0 {
+			lineCounter = Counter{Covered: 1}
+		}
+		AddCounters(counters, map[string]Counter{"LINE": lineCounter})
+	}
+	return counters
+}
+
+func AddCounters(counters map[string]Counter, other map[string]Counter) {
+	for counterType, counter := range other {
+		sum := counters[counterType]
+		sum.Type = counterType
+		sum.Covered += counter.Covered
+		sum.Missed += counter.Missed
+		counters[counterType] = sum
+	}
+}
+
+// SubtractCounters returns the counters less the removed ones.
+func SubtractCounters(counters []Counter, removed map[string]Counter) []Counter {
+	var result []Counter
+	for _, counter := range counters {
+		counter.Covered -= removed[counter.Type].Covered
+		counter.Missed -= removed[counter.Type].Missed
+		result = append(result, counter)
+	}
+	return result
+}
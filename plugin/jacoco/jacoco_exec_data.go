@@ -0,0 +1,412 @@
+package jacoco
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/sirupsen/logrus"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ExecData is the content of JaCoCo exec files: the sessions that dumped
+// execution data and the probes hit in every class. Classes are keyed by
+// their id, the CRC64 of the class file, so two versions of a class have
+// separate probes.
+type ExecData struct {
+	Sessions []SessionInfo
+	Classes  []*ExecClass
+
+	classesById map[uint64]*ExecClass
+}
+
+type ExecClass struct {
+	Id     uint64
+	Name   string
+	Probes []bool
+}
+
+// ExecClassIdMismatch is a class with execution data of several class
+// files, usually recompiled between the test runs that dumped them.
+type ExecClassIdMismatch struct {
+	Name string
+	Ids  []uint64
+}
+
+func (m ExecClassIdMismatch) String() string {
+	ids := ""
+	for i, id := range m.Ids {
+		if i > 0 {
+			ids += ", "
+		}
+		ids += fmt.Sprintf("%016x", id)
+	}
+	return fmt.Sprintf("class %s has execution data of %d different class files (ids %s)", m.Name, len(m.Ids), ids)
+}
+
+func GetNewExecData() *ExecData {
+	return &ExecData{classesById: map[uint64]*ExecClass{}}
+}
+
+// ReadExecFile reads a JaCoCo exec file. Files written by several test runs
+// to the same path hold one header and data block per run.
+func ReadExecFile(execFilePath string) (*ExecData, error) {
+	file, err := os.Open(execFilePath)
+	if err != nil {
+		return nil, pd.GetNewKindError(pd.ErrParse, "Error opening exec file: "+err.Error())
+	}
+	defer file.Close()
+
+	data := GetNewExecData()
+	err = data.Read(bufio.NewReader(file))
+	if err != nil {
+		return nil, pd.GetNewKindError(pd.ErrParse, "Error reading exec file "+execFilePath+": "+err.Error())
+	}
+	return data, nil
+}
+
+// Read adds the blocks of exec data read from r.
+func (d *ExecData) Read(r io.ByteReader) error {
+
+	in := execDataReader{r: r}
+	for {
+		blockType, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch blockType {
+		case execBlockHeader:
+			magic, version := in.readChar(), in.readChar()
+			if in.err == nil && magic != execMagicNumber {
+				return errors.New("invalid execution data file")
+			}
+			if in.err == nil && version != execFormatVersion {
+				return fmt.Errorf("incompatible version %x of the execution data, expected %x", version,
+					execFormatVersion)
+			}
+		case execBlockSessionInfo:
+			session := SessionInfo{Id: in.readUTF(), Start: in.readLong(), Dump: in.readLong()}
+			if in.err == nil {
+				d.Sessions = append(d.Sessions, session)
+			}
+		case execBlockExecutionData:
+			class := &ExecClass{Id: uint64(in.readLong()), Name: in.readUTF(), Probes: in.readBooleanArray()}
+			if in.err == nil {
+				err = d.AddClass(class)
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unknown block type %x", blockType)
+		}
+		if in.err != nil {
+			return fmt.Errorf("truncated execution data: %w", in.err)
+		}
+	}
+}
+
+// AddClass adds the probes of a class, or-ing them with the probes already
+// known for the class id, the way JaCoCo merges execution data.
+func (d *ExecData) AddClass(class *ExecClass) error {
+
+	if d.classesById == nil {
+		d.classesById = map[uint64]*ExecClass{}
+	}
+
+	known, ok := d.classesById[class.Id]
+	if !ok {
+		added := &ExecClass{Id: class.Id, Name: class.Name, Probes: append([]bool(nil), class.Probes...)}
+		d.classesById[class.Id] = added
+		d.Classes = append(d.Classes, added)
+		return nil
+	}
+
+	if known.Name != class.Name {
+		return fmt.Errorf("different class names %s and %s for id %016x", known.Name, class.Name, class.Id)
+	}
+	if len(known.Probes) != len(class.Probes) {
+		return fmt.Errorf("incompatible execution data for class %s with id %016x", class.Name, class.Id)
+	}
+	for i, probe := range class.Probes {
+		known.Probes[i] = known.Probes[i] || probe
+	}
+	return nil
+}
+
+// Merge adds the sessions and the probes of other.
+func (d *ExecData) Merge(other *ExecData) error {
+	d.Sessions = append(d.Sessions, other.Sessions...)
+	for _, class := range other.Classes {
+		err := d.AddClass(class)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetClass returns the execution data of the class id.
+func (d *ExecData) GetClass(id uint64) (*ExecClass, bool) {
+	class, ok := d.classesById[id]
+	return class, ok
+}
+
+// GetClassIdMismatches returns the classes with execution data of more than
+// one class file, sorted by name.
+func (d *ExecData) GetClassIdMismatches() []ExecClassIdMismatch {
+
+	idsByName := map[string][]uint64{}
+	for _, class := range d.Classes {
+		idsByName[class.Name] = append(idsByName[class.Name], class.Id)
+	}
+
+	var mismatches []ExecClassIdMismatch
+	for name, ids := range idsByName {
+		if len(ids) > 1 {
+			mismatches = append(mismatches, ExecClassIdMismatch{Name: name, Ids: ids})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Name < mismatches[j].Name
+	})
+	return mismatches
+}
+
+// Write writes the exec data in the format of JaCoCo, one header followed by
+// the sessions and the classes sorted by name.
+func (d *ExecData) Write(w io.Writer) error {
+
+	out := execDataWriter{w: bufio.NewWriter(w)}
+	out.writeByte(execBlockHeader)
+	out.writeChar(execMagicNumber)
+	out.writeChar(execFormatVersion)
+
+	for _, session := range d.Sessions {
+		out.writeByte(execBlockSessionInfo)
+		out.writeUTF(session.Id)
+		out.writeLong(uint64(session.Start))
+		out.writeLong(uint64(session.Dump))
+	}
+
+	classes := append([]*ExecClass(nil), d.Classes...)
+	sort.SliceStable(classes, func(i, j int) bool {
+		if classes[i].Name != classes[j].Name {
+			return classes[i].Name < classes[j].Name
+		}
+		return classes[i].Id < classes[j].Id
+	})
+	for _, class := range classes {
+		out.writeByte(execBlockExecutionData)
+		out.writeLong(class.Id)
+		out.writeUTF(class.Name)
+		out.writeBooleanArray(class.Probes)
+	}
+
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// WriteExecFile writes the exec data to a new exec file.
+func WriteExecFile(execFilePath string, data *ExecData) error {
+
+	err := pd.CreateDir(filepath.Dir(execFilePath))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(execFilePath)
+	if err != nil {
+		return err
+	}
+	err = data.Write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// MergeExecFiles reads the exec files into one exec data, warning about the
+// classes whose execution data comes from different class files.
+func MergeExecFiles(execFilePaths []string) (*ExecData, error) {
+
+	merged := GetNewExecData()
+	for _, execFilePath := range execFilePaths {
+		data, err := ReadExecFile(execFilePath)
+		if err != nil {
+			return nil, err
+		}
+		err = merged.Merge(data)
+		if err != nil {
+			return nil, pd.GetNewKindError(pd.ErrParse, "Error merging exec file "+execFilePath+": "+err.Error())
+		}
+	}
+
+	for _, mismatch := range merged.GetClassIdMismatches() {
+		logrus.Warnf("Jacoco exec data CRC mismatch: %s\n", mismatch.String())
+	}
+	return merged, nil
+}
+
+// execDataReader reads the values of the exec format, written by the
+// DataOutputStream of Java, keeping the first error.
+type execDataReader struct {
+	r   io.ByteReader
+	err error
+}
+
+func (in *execDataReader) readBytes(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		if in.err != nil {
+			return nil
+		}
+		data[i], in.err = in.r.ReadByte()
+	}
+	if errors.Is(in.err, io.EOF) {
+		in.err = io.ErrUnexpectedEOF
+	}
+	return data
+}
+
+func (in *execDataReader) readChar() uint16 {
+	data := in.readBytes(2)
+	if in.err != nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(data)
+}
+
+func (in *execDataReader) readLong() int64 {
+	data := in.readBytes(8)
+	if in.err != nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(data))
+}
+
+// readUTF reads a string of modified UTF-8, kept as its raw bytes so it is
+// written back unchanged.
+func (in *execDataReader) readUTF() string {
+	return string(in.readBytes(int(in.readChar())))
+}
+
+// readVarInt reads a probe count, which as the length of a Java array fits
+// in an int32.
+func (in *execDataReader) readVarInt() int {
+	value, shift := int64(0), 0
+	for in.err == nil && shift < 35 {
+		data := in.readBytes(1)
+		if in.err != nil {
+			return 0
+		}
+		value |= int64(data[0]&0x7f) << shift
+		if data[0]&0x80 == 0 {
+			if value > math.MaxInt32 {
+				break
+			}
+			return int(value)
+		}
+		shift += 7
+	}
+	if in.err == nil {
+		in.err = errors.New("invalid probe count")
+	}
+	return 0
+}
+
+// readBooleanArray grows the probes while they are read, so a corrupt count
+// fails on the end of the data instead of allocating the whole array.
+func (in *execDataReader) readBooleanArray() []bool {
+	count := in.readVarInt()
+	capacity := count
+	if capacity > maxPreallocatedProbes {
+		capacity = maxPreallocatedProbes
+	}
+	probes := make([]bool, 0, capacity)
+	var buffer byte
+	for i := 0; i < count; i++ {
+		if i%8 == 0 {
+			data := in.readBytes(1)
+			if in.err != nil {
+				return nil
+			}
+			buffer = data[0]
+		}
+		probes = append(probes, buffer&(1<<(i%8)) != 0)
+	}
+	return probes
+}
+
+type execDataWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (out *execDataWriter) writeBytes(data []byte) {
+	if out.err == nil {
+		_, out.err = out.w.Write(data)
+	}
+}
+
+func (out *execDataWriter) writeByte(value byte) {
+	out.writeBytes([]byte{value})
+}
+
+func (out *execDataWriter) writeChar(value uint16) {
+	out.writeBytes(binary.BigEndian.AppendUint16(nil, value))
+}
+
+func (out *execDataWriter) writeLong(value uint64) {
+	out.writeBytes(binary.BigEndian.AppendUint64(nil, value))
+}
+
+func (out *execDataWriter) writeUTF(value string) {
+	out.writeChar(uint16(len(value)))
+	out.writeBytes([]byte(value))
+}
+
+func (out *execDataWriter) writeVarInt(value int) {
+	for value&^0x7f != 0 {
+		out.writeByte(byte(0x80 | value&0x7f))
+		value >>= 7
+	}
+	out.writeByte(byte(value))
+}
+
+func (out *execDataWriter) writeBooleanArray(probes []bool) {
+	out.writeVarInt(len(probes))
+	var buffer byte
+	for i, probe := range probes {
+		if probe {
+			buffer |= 1 << (i % 8)
+		}
+		if i%8 == 7 {
+			out.writeByte(buffer)
+			buffer = 0
+		}
+	}
+	if len(probes)%8 != 0 {
+		out.writeByte(buffer)
+	}
+}
+
+const (
+	execBlockHeader        = 0x01
+	execBlockSessionInfo   = 0x10
+	execBlockExecutionData = 0x11
+	execMagicNumber        = 0xc0c0
+	execFormatVersion      = 0x1007
+	maxPreallocatedProbes  = 1 << 16
+)
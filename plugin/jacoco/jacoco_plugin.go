@@ -1,8 +1,11 @@
 package jacoco
 
 import (
+	"context"
 	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
 	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/sirupsen/logrus"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,6 +16,8 @@ type JacocoPlugin struct {
 	pd.CoveragePluginArgs
 	InputArgs *pd.Args
 	JacocoPluginStateStore
+
+	ctx context.Context
 }
 
 type JacocoPluginStateStore struct {
@@ -28,8 +33,11 @@ type JacocoPluginStateStore struct {
 
 	JacocoWorkSpaceDir         string
 	ExecFilesFinalCompletePath []string
+	ExecData                   *ExecData
+	ExecDataMismatches         []ExecDataMismatch
 	JacocoJarPath              string
 	CoverageThresholds         JacocoCoverageThresholdsValues
+	ParsedReport               *Report
 }
 
 type JacocoCoverageThresholds struct {
@@ -50,6 +58,19 @@ type JacocoCoverageThresholdsValues struct {
 	ClassCoverageThreshold       float64
 }
 
+// SetContext sets the context that cancels finding and copying the files
+// and running JaCoCo.
+func (p *JacocoPlugin) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+func (p *JacocoPlugin) GetContext() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
 func (p *JacocoPlugin) Init(args *pd.Args) error {
 
 	pd.LogPrintln(p, "JacocoPlugin Init")
@@ -95,7 +116,7 @@ func (p *JacocoPlugin) SetJarPath() error {
 		_, err = os.Stat(p.JacocoJarPath)
 		if err != nil {
 			pd.LogPrintln(p, "JacocoPlugin Error in SetJarPath: "+err.Error())
-			return pd.GetNewError("Error in SetJarPath: " + err.Error())
+			return pd.GetNewKindError(pd.ErrToolExecution, "Error in SetJarPath: "+err.Error())
 		}
 	}
 
@@ -257,6 +278,18 @@ func (p *JacocoPlugin) DoPostArgsValidationSetup(args pd.Args) error {
 		return err
 	}
 
+	err = p.MergeJacocoExecFiles()
+	if err != nil {
+		pd.LogPrintln(p, "JacocoPlugin Error in DoPostArgsValidationSetup: "+err.Error())
+		return err
+	}
+
+	err = p.CheckExecDataClassIds()
+	if err != nil {
+		pd.LogPrintln(p, "JacocoPlugin Error in DoPostArgsValidationSetup: "+err.Error())
+		return err
+	}
+
 	return nil
 }
 
@@ -316,6 +349,83 @@ func (p *JacocoPlugin) CopyJacocoExecFilesToWorkspace() error {
 	return nil
 }
 
+// MergeJacocoExecFiles merges the exec files copied to the workspace into
+// one, which JaCoCo reads instead of them, and writes it to merged_exec_path
+// when set.
+func (p *JacocoPlugin) MergeJacocoExecFiles() error {
+
+	if !p.InputArgs.MergeExecFiles && p.InputArgs.MergedExecPath == "" {
+		return nil
+	}
+
+	merged, err := MergeExecFiles(p.ExecFilesFinalCompletePath)
+	if err != nil {
+		pd.LogPrintln(p, "JacocoPlugin Error in MergeJacocoExecFiles: "+err.Error())
+		return err
+	}
+
+	mergedExecFilePath := filepath.Join(p.GetExecFilesWorkSpaceDir(), MergedExecFileName)
+	err = WriteExecFile(mergedExecFilePath, merged)
+	if err != nil {
+		pd.LogPrintln(p, "JacocoPlugin Error in MergeJacocoExecFiles: "+err.Error())
+		return pd.GetNewError("Error in MergeJacocoExecFiles: " + err.Error())
+	}
+	logrus.Printf("%d jacoco exec files merged, %d sessions and %d classes\n", len(p.ExecFilesFinalCompletePath),
+		len(merged.Sessions), len(merged.Classes))
+	p.ExecFilesFinalCompletePath = []string{mergedExecFilePath}
+	p.ExecData = merged
+
+	if p.InputArgs.MergedExecPath != "" {
+		outputPath := pd.GetWorkSpaceRelativePath(p.GetWorkspaceDir(), p.InputArgs.MergedExecPath)
+		err = WriteExecFile(outputPath, merged)
+		if err != nil {
+			pd.LogPrintln(p, "JacocoPlugin Error in MergeJacocoExecFiles: "+err.Error())
+			return pd.GetNewError("Error in MergeJacocoExecFiles: " + err.Error())
+		}
+		logrus.Printf("Merged jacoco exec file written to %s\n", outputPath)
+	}
+
+	return nil
+}
+
+// CheckExecDataClassIds compares the ids of the class files copied to the
+// workspace with the ids of the execution data, reusing the merged exec
+// data. JaCoCo reports the classes recompiled after the tests ran as not
+// covered, so they are logged with warn_on_exec_data_mismatch, and fail the
+// step with fail_on_exec_data_mismatch. Without either the check is skipped.
+func (p *JacocoPlugin) CheckExecDataClassIds() error {
+
+	if !p.InputArgs.WarnOnExecDataMismatch && !p.InputArgs.FailOnExecDataMismatch {
+		return nil
+	}
+
+	var err error
+	data := p.ExecData
+	if data == nil {
+		data, err = MergeExecFiles(p.ExecFilesFinalCompletePath)
+	}
+	if err == nil {
+		p.ExecDataMismatches, err = GetExecDataMismatches(p.GetClassesWorkSpaceDir(), data)
+	}
+	if err != nil {
+		if p.InputArgs.FailOnExecDataMismatch {
+			pd.LogPrintln(p, "JacocoPlugin Error in CheckExecDataClassIds: "+err.Error())
+			return pd.GetNewKindError(pd.ErrParse, "Error in CheckExecDataClassIds: "+err.Error())
+		}
+		logrus.Warnf("Class files not checked against the execution data: %s\n", err.Error())
+		return nil
+	}
+
+	for _, mismatch := range p.ExecDataMismatches {
+		logrus.Warnf("%s\n", mismatch.String())
+	}
+	if len(p.ExecDataMismatches) > 0 && p.InputArgs.FailOnExecDataMismatch {
+		return pd.GetNewKindError(pd.ErrExecDataMismatch, fmt.Sprintf("Error in CheckExecDataClassIds: "+
+			"execution data does not match %d classes, recompiled after the tests ran", len(p.ExecDataMismatches)))
+	}
+	return nil
+}
+
 func (p *JacocoPlugin) GetJacocoExecFilesUniqueDirs() ([]string, error) {
 
 	uniqueDirMap := map[string]bool{}
@@ -351,8 +461,12 @@ func (p *JacocoPlugin) CopyClassesToWorkspace() error {
 
 	for _, classInfo := range classesList {
 
-		err := classInfo.CopyTo(dstClassesDir, p.BuildRootPath)
+		err := classInfo.CopyTo(p.GetContext(), dstClassesDir, p.BuildRootPath)
 		if err != nil {
+			if ctxErr := p.GetContext().Err(); ctxErr != nil {
+				return pd.GetNewError("Error in CopyClassesToWorkspace: " + ctxErr.Error())
+			}
+			logrus.Warnf("JacocoPlugin Error in CopyClassesToWorkspace: %s\n", err.Error())
 			continue
 		}
 	}
@@ -377,8 +491,12 @@ func (p *JacocoPlugin) CopySourcesToWorkspace() error {
 
 	sourcesList := p.GetSourcesList()
 	for _, sourceInfo := range sourcesList {
-		err := sourceInfo.CopySourceTo(dstSourcesDir, p.BuildRootPath)
+		err := sourceInfo.CopySourceTo(p.GetContext(), dstSourcesDir, p.BuildRootPath)
 		if err != nil {
+			if ctxErr := p.GetContext().Err(); ctxErr != nil {
+				return pd.GetNewError("Error in CopySourcesToWorkspace: " + ctxErr.Error())
+			}
+			logrus.Warnf("JacocoPlugin Error in CopySourcesToWorkspace: %s\n", err.Error())
 			continue
 		}
 	}
@@ -410,7 +528,7 @@ func (p *JacocoPlugin) IsSourceArgOk(args pd.Args) error {
 	p.InputArgs.SourceExclusionPattern = args.SourceExclusionPattern
 
 	sourcesInfoStoreList, err :=
-		pd.FilterFileOrDirUsingGlobPatterns(p.BuildRootPath, p.GetSourcePatternsStrArray(),
+		pd.FilterFileOrDirUsingGlobPatterns(p.GetContext(), p.BuildRootPath, p.GetSourcePatternsStrArray(),
 			p.InputArgs.SourceInclusionPattern, p.InputArgs.SourceExclusionPattern, AllSourcesAutoFillGlob)
 
 	if err != nil {
@@ -437,7 +555,7 @@ func (p *JacocoPlugin) IsClassArgOk(args pd.Args) error {
 	p.InputArgs.ClassExclusionPatterns = args.ClassExclusionPatterns
 
 	classesInfoStoreList, err :=
-		pd.FilterFileOrDirUsingGlobPatterns(p.BuildRootPath, p.GetClassPatternsStrArray(),
+		pd.FilterFileOrDirUsingGlobPatterns(p.GetContext(), p.BuildRootPath, p.GetClassPatternsStrArray(),
 			p.InputArgs.ClassInclusionPatterns, p.InputArgs.ClassExclusionPatterns, AllClassesAutoFillGlob)
 
 	if err != nil {
@@ -470,10 +588,14 @@ func (p *JacocoPlugin) IsExecFileArgOk(args pd.Args) error {
 	}
 
 	p.ExecFilePathsWithPrefixList = execFilesPathList
+	for _, execFilePath := range execFilesPathList {
+		logrus.Printf("Jacoco exec file found at %s\n",
+			filepath.Join(execFilePath.CompletePathPrefix, execFilePath.RelativePath))
+	}
 
 	if len(p.ExecFilePathsWithPrefixList) < 1 {
 		pd.LogPrintln(p, "JacocoPlugin Error in IsExecFileArgOk: No jacoco exec files found")
-		return pd.GetNewError("Error in IsExecFileArgOk: No jacoco exec files found")
+		return pd.GetNewKindError(pd.ErrNoReports, "Error in IsExecFileArgOk: No jacoco exec files found")
 	}
 
 	return nil
@@ -517,16 +639,26 @@ func (p *JacocoPlugin) AnalyzeJacocoCoverageThresholds() error {
 		_, err := os.Stat(p.GetJacocoXmlReportFilePath())
 		if err != nil {
 			pd.LogPrintln(p, "JacocoPlugin Error in AnalyzeJacocoCoverageThresholds: "+err.Error())
-			return pd.GetNewError("Error in AnalyzeJacocoCoverageThresholds: " + err.Error())
+			return pd.GetNewKindError(pd.ErrNoReports, "Error in AnalyzeJacocoCoverageThresholds: "+err.Error())
 		}
 		_, err = os.Stat(p.GetJacocoHtmlReportFilePath())
 		if err != nil {
 			pd.LogPrintln(p, "JacocoPlugin Error in AnalyzeJacocoCoverageThresholds: "+err.Error())
-			return pd.GetNewError("Error in AnalyzeJacocoCoverageThresholds: " + err.Error())
+			return pd.GetNewKindError(pd.ErrNoReports, "Error in AnalyzeJacocoCoverageThresholds: "+err.Error())
 		}
 	}
 
-	p.CoverageThresholds = GetJacocoCoverageThresholds(p.GetJacocoXmlReportFilePath())
+	report, err := ParseXMLReport(p.GetJacocoXmlReportFilePath())
+	if err != nil {
+		return err
+	}
+
+	err = p.ApplySourceExclusions(&report)
+	if err != nil {
+		return err
+	}
+	p.ParsedReport = &report
+	p.CoverageThresholds = GetJacocoCoverageThresholdsFromReport(report)
 	if p.InputArgs.PluginFailOnThreshold == false {
 		pd.LogPrintln(p, "JacocoPlugin PluginFailOnThreshold is false, so skipping threshold check")
 		return nil
@@ -534,54 +666,72 @@ func (p *JacocoPlugin) AnalyzeJacocoCoverageThresholds() error {
 
 	if p.IsThresholdValuesGood() == false {
 		pd.LogPrintln(p, "JacocoPlugin Error in AnalyzeJacocoCoverageThresholds: Threshold values not good")
-		return pd.GetNewError("Error in AnalyzeJacocoCoverageThresholds: Threshold values not good")
+		return pd.GetNewKindError(pd.ErrThreshold, "Error in AnalyzeJacocoCoverageThresholds: Threshold values not good")
 	}
 
 	return nil
 }
 
-func (p *JacocoPlugin) SetCoverageThresholds(thresholdValues JacocoCoverageThresholdsValues) {
-	p.CoverageThresholds = thresholdValues
-}
+// ApplySourceExclusions removes the lines excluded by markers in the
+// source files before the thresholds are checked.
+func (p *JacocoPlugin) ApplySourceExclusions(report *Report) error {
 
-func (p *JacocoPlugin) IsThresholdValuesGood() bool {
+	if p.InputArgs.SkipExclusionMarkers {
+		return nil
+	}
 
-	type ThresholdsCompare struct {
-		ObservedValue float64
-		ExpectedValue float64
-		ThresholdType string
+	exclusions, err := pd.GetSourceExclusions(report.ToCoverageReport(p.GetPluginType()), *p.InputArgs,
+		p.GetWorkspaceDir())
+	if err != nil {
+		return pd.GetNewError("Error in ApplySourceExclusions: " + err.Error())
 	}
 
-	thresholdsCompareList := []ThresholdsCompare{
-		{ObservedValue: p.CoverageThresholds.InstructionCoverageThreshold,
-			ExpectedValue: p.InputArgs.MinimumInstructionCoverage, ThresholdType: "InstructionCoverage"},
-		{ObservedValue: p.CoverageThresholds.BranchCoverageThreshold,
-			ExpectedValue: p.InputArgs.MinimumBranchCoverage, ThresholdType: "BranchCoverage"},
-		{ObservedValue: p.CoverageThresholds.LineCoverageThreshold,
-			ExpectedValue: p.InputArgs.MinimumLineCoverage, ThresholdType: "LineCoverage"},
-		{ObservedValue: p.CoverageThresholds.MethodCoverageThreshold,
-			ExpectedValue: p.InputArgs.MinimumMethodCoverage, ThresholdType: "MethodCoverage"},
-		{ObservedValue: p.CoverageThresholds.ClassCoverageThreshold,
-			ExpectedValue: p.InputArgs.MinimumClassCoverage, ThresholdType: "ClassCoverage"},
+	removed := report.ExcludeLines(exclusions)
+	if removed > 0 {
+		logrus.Printf("%d report lines excluded from coverage by source markers in %d files\n", removed, len(exclusions))
 	}
+	return nil
+}
+
+func (p *JacocoPlugin) SetCoverageThresholds(thresholdValues JacocoCoverageThresholdsValues) {
+	p.CoverageThresholds = thresholdValues
+}
 
-	for _, thresholdCompare := range thresholdsCompareList {
-		if thresholdCompare.ObservedValue <= thresholdCompare.ExpectedValue {
-			pd.LogPrintln(p, "JacocoPlugin "+thresholdCompare.ThresholdType+" threshold not met",
-				" expected = ", thresholdCompare.ExpectedValue, " observed = ", thresholdCompare.ObservedValue)
+func (p *JacocoPlugin) IsThresholdValuesGood() bool {
 
-			return false
+	isGood := true
+	for _, thresholdCheck := range p.GetThresholdChecks() {
+		if thresholdCheck.IsWarningRaised() {
+			logrus.Warnf("JacocoPlugin %s\n", thresholdCheck.String())
+		} else if thresholdCheck.IsFailed() {
+			pd.LogPrintln(p, "JacocoPlugin "+thresholdCheck.String())
+			isGood = false
 		}
 	}
 
-	if p.CoverageThresholds.ComplexityCoverageThreshold > p.InputArgs.MinimumComplexityCoverage {
-		pd.LogPrintln(p, "JacocoPlugin ComplexityCoverage threshold not met",
-			" expected = ", p.InputArgs.MinimumComplexityCoverage,
-			" observed = ", p.CoverageThresholds.ComplexityCoverageThreshold)
-		return false
+	return isGood
+}
+
+func (p *JacocoPlugin) GetThresholdChecks() []pd.ThresholdCheck {
+
+	if p.InputArgs == nil {
+		return nil
 	}
 
-	return true
+	return pd.ApplyThresholdSeverities(pd.GetConfiguredThresholdChecks([]pd.ThresholdCheck{
+		pd.GetMinimumThresholdCheck("Instruction", coverage.InstructionCounter,
+			p.CoverageThresholds.InstructionCoverageThreshold, p.InputArgs.MinimumInstructionCoverage, false),
+		pd.GetMinimumThresholdCheck("Branch", coverage.BranchCounter,
+			p.CoverageThresholds.BranchCoverageThreshold, p.InputArgs.MinimumBranchCoverage, false),
+		pd.GetMinimumThresholdCheck("Line", coverage.LineCounter,
+			p.CoverageThresholds.LineCoverageThreshold, p.InputArgs.MinimumLineCoverage, false),
+		pd.GetMinimumThresholdCheck("Method", coverage.MethodCounter,
+			p.CoverageThresholds.MethodCoverageThreshold, p.InputArgs.MinimumMethodCoverage, false),
+		pd.GetMinimumThresholdCheck("Class", coverage.ClassCounter,
+			p.CoverageThresholds.ClassCoverageThreshold, p.InputArgs.MinimumClassCoverage, false),
+		pd.GetMaximumThresholdCheck("Complexity",
+			float64(p.CoverageThresholds.ComplexityCoverageThreshold), float64(p.InputArgs.MinimumComplexityCoverage)),
+	}), p.InputArgs.EnvPluginInputArgs)
 }
 
 func (p *JacocoPlugin) GenerateJacocoReports() error {
@@ -606,14 +756,14 @@ func (p *JacocoPlugin) GenerateJacocoReports() error {
 
 	parts := strings.Fields(cmdStr)
 
-	cmd := exec.Command(parts[0], parts[1:]...)
+	cmd := exec.CommandContext(p.GetContext(), parts[0], parts[1:]...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	err := cmd.Run()
 	if err != nil {
 		pd.LogPrintln(p, "JacocoPlugin Error in Run: "+err.Error())
-		return pd.GetNewError("Error in Run: " + err.Error())
+		return pd.GetNewKindError(pd.ErrToolExecution, "Error in Run: "+err.Error())
 	} else {
 		pd.LogPrintln(p, "Command executed successfully.")
 	}
@@ -681,6 +831,7 @@ func (p *JacocoPlugin) WriteOutputVariables() error {
 		{Key: "COMPLEXITY_COVERAGE", Value: p.CoverageThresholds.ComplexityCoverageThreshold},
 		{Key: "METHOD_COVERAGE", Value: p.CoverageThresholds.MethodCoverageThreshold},
 		{Key: "CLASS_COVERAGE", Value: p.CoverageThresholds.ClassCoverageThreshold},
+		{Key: "EXEC_DATA_MISMATCHES", Value: len(p.ExecDataMismatches)},
 	}
 
 	var retErr error = nil
@@ -704,9 +855,14 @@ func (p *JacocoPlugin) DebugPrintOutputVariables() {
 		return
 	}
 
-	fmt.Println("\n\nReading JacocoPlugin Output Variables file ", pd.GetOutputVariablesStorageFilePath())
-	fmt.Println(s)
-	fmt.Println("Reading Complete")
+	logrus.Debugf("JacocoPlugin Output Variables file %s:\n%s\n", pd.GetOutputVariablesStorageFilePath(), s)
+}
+
+func (p *JacocoPlugin) GetCoverageReport() *coverage.Report {
+	if p.ParsedReport == nil {
+		return nil
+	}
+	return p.ParsedReport.ToCoverageReport(p.GetPluginType())
 }
 
 func (p *JacocoPlugin) IsQuiet() bool {
@@ -732,6 +888,7 @@ const (
 	DefaultJacocoJarPath           = "/opt/harness/plugins-deps/jacoco/0.8.12/jacoco.jar"
 	TestJacocoJarPath              = "../test/tmp_workspace/jacoco.jar"
 	ExecFilePathsWithPrefixListStr = "ExecFilePathsWithPrefixList"
+	MergedExecFileName             = "jacoco-merged.exec"
 )
 
 //
@@ -2,7 +2,9 @@ package jacoco
 
 import (
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
 	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/sirupsen/logrus"
 	"os"
 	"path/filepath"
 )
@@ -16,8 +18,15 @@ func GetNewJacocoXmlPlugin() JacocoXmlPlugin {
 	return JacocoXmlPlugin{}
 }
 
+// Init sets up the base plugin without looking for the jacoco cli jar, which
+// is only needed to read exec files, so xml reports can be read locally.
 func (jxp *JacocoXmlPlugin) Init(args *pd.Args) error {
-	err := jxp.JacocoBasePlugin.Init(args)
+	jxp.JacocoBasePlugin.InputArgs = args
+
+	err := jxp.JacocoBasePlugin.SetBuildRoot("")
+	if err == nil {
+		err = jxp.JacocoBasePlugin.CreateNewWorkspace()
+	}
 	if err != nil {
 		pd.LogPrintln(jxp, "Error in JacocoXmlPlugin Init: ", err.Error())
 		return err
@@ -64,7 +73,7 @@ func (jxp *JacocoXmlPlugin) LocateJacocoReportXml() error {
 	}
 
 	if len(matchedDirs) < 1 {
-		return pd.GetNewError("No Jacoco report xml found")
+		return pd.GetNewKindError(pd.ErrNoReports, "No Jacoco report xml found")
 	}
 
 	relativeXmlReportPath := matchedDirs[0]
@@ -79,7 +88,7 @@ func (jxp *JacocoXmlPlugin) LocateJacocoReportXml() error {
 		return pd.GetNewError("Error in getting absolute path: " + err.Error())
 	}
 
-	pd.LogPrintln(jxp, "Jacoco report xml found at: ", completeXmlPath)
+	logrus.Printf("Jacoco report xml found at %s\n", completeXmlPath)
 
 	jxp.XmlReportCompletePath = completeXmlPath
 
@@ -89,7 +98,23 @@ func (jxp *JacocoXmlPlugin) LocateJacocoReportXml() error {
 func (jxp *JacocoXmlPlugin) Run() error {
 	pd.LogPrintln(jxp, "Running JacocoXmlPlugin with specified thresholds")
 
-	jacocoThresholdValues := GetJacocoCoverageThresholds(jxp.XmlReportCompletePath)
+	report, err := ParseXMLReport(jxp.XmlReportCompletePath)
+	if err != nil {
+		return err
+	}
+
+	err = jxp.ApplyReportFilters(&report)
+	if err != nil {
+		return err
+	}
+
+	err = jxp.JacocoBasePlugin.ApplySourceExclusions(&report)
+	if err != nil {
+		return err
+	}
+	jxp.JacocoBasePlugin.ParsedReport = &report
+
+	jacocoThresholdValues := GetJacocoCoverageThresholdsFromReport(report)
 	pd.LogPrintln(jxp, "Retrieved Jacoco threshold values: ", jacocoThresholdValues)
 
 	jxp.JacocoBasePlugin.SetCoverageThresholds(jacocoThresholdValues)
@@ -101,12 +126,30 @@ func (jxp *JacocoXmlPlugin) Run() error {
 	isGood := jxp.JacocoBasePlugin.IsThresholdValuesGood()
 	if !isGood {
 		pd.LogPrintln(jxp, "Coverage thresholds not met for JacocoXmlPlugin")
-		return pd.GetNewError("Coverage thresholds not met for JacocoXmlPlugin")
+		return pd.GetNewKindError(pd.ErrThreshold, "Coverage thresholds not met for JacocoXmlPlugin")
 	}
 
 	return nil
 }
 
+// ApplyReportFilters removes the classes left out by the class and source
+// inclusion and exclusion patterns before the thresholds are checked. The
+// jacoco tool applies these patterns to the files given to the JaCoCo CLI
+// instead.
+func (jxp *JacocoXmlPlugin) ApplyReportFilters(report *Report) error {
+
+	classFilter, sourceFilter, err := pd.GetReportFilters(*jxp.JacocoBasePlugin.InputArgs)
+	if err != nil {
+		return err
+	}
+
+	removed := report.Filter(classFilter, sourceFilter)
+	if removed > 0 {
+		logrus.Printf("%d classes excluded from the report by the class and source patterns\n", removed)
+	}
+	return nil
+}
+
 func (jxp *JacocoXmlPlugin) WriteOutputVariables() error {
 	pd.LogPrintln(jxp, "Writing output variables in JacocoXmlPlugin")
 	jxp.JacocoBasePlugin.WriteOutputVariables()
@@ -124,10 +167,20 @@ func (jxp *JacocoXmlPlugin) GetPluginType() string {
 }
 
 func (jxp *JacocoXmlPlugin) IsQuiet() bool {
-	pd.LogPrintln(jxp, "Checking if JacocoXmlPlugin is quiet")
 	return false
 }
 
+func (jxp *JacocoXmlPlugin) GetCoverageReport() *coverage.Report {
+	if jxp.JacocoBasePlugin.ParsedReport == nil {
+		return nil
+	}
+	return jxp.JacocoBasePlugin.ParsedReport.ToCoverageReport(jxp.GetPluginType())
+}
+
+func (jxp *JacocoXmlPlugin) GetThresholdChecks() []pd.ThresholdCheck {
+	return jxp.JacocoBasePlugin.GetThresholdChecks()
+}
+
 func (jxp *JacocoXmlPlugin) InspectProcessArgs(argNamesList []string) (map[string]interface{}, error) {
 	pd.LogPrintln(jxp, "Inspecting process args in JacocoXmlPlugin")
 	return nil, nil
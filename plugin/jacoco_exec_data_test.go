@@ -0,0 +1,167 @@
+package plugin
+
+import (
+	"bytes"
+	jc "github.com/harness-community/drone-coverage-report/plugin/jacoco"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeExecFiles(t *testing.T) {
+
+	var execFilePaths []string
+	var classes, sessions int
+	for _, module := range []string{"gameoflife-core", "gameoflife-web"} {
+		execFilePath := filepath.Join(pd.TestWorkSpaceDir, "game-of-life", module, "target", "jacoco.exec")
+		data, err := jc.ReadExecFile(execFilePath)
+		if err != nil {
+			t.Fatalf("Error in TestMergeExecFiles: %s", err.Error())
+		}
+		execFilePaths = append(execFilePaths, execFilePath)
+		classes += len(data.Classes)
+		sessions += len(data.Sessions)
+	}
+
+	merged, err := jc.MergeExecFiles(execFilePaths)
+	if err != nil {
+		t.Fatalf("Error in TestMergeExecFiles: %s", err.Error())
+	}
+	if len(merged.Sessions) != sessions || len(merged.Classes) == 0 || len(merged.Classes) > classes {
+		t.Errorf("Error in TestMergeExecFiles: %d sessions and %d classes merged from %d and %d",
+			len(merged.Sessions), len(merged.Classes), sessions, classes)
+	}
+
+	mergedPath := filepath.Join(t.TempDir(), "merged.exec")
+	err = jc.WriteExecFile(mergedPath, merged)
+	if err != nil {
+		t.Fatalf("Error in TestMergeExecFiles: %s", err.Error())
+	}
+	written, err := jc.ReadExecFile(mergedPath)
+	if err != nil {
+		t.Fatalf("Error in TestMergeExecFiles: %s", err.Error())
+	}
+	if len(written.Classes) != len(merged.Classes) || !reflect.DeepEqual(written.Sessions, merged.Sessions) {
+		t.Fatalf("Error in TestMergeExecFiles: merged exec file not read back")
+	}
+	for _, class := range merged.Classes {
+		writtenClass, ok := written.GetClass(class.Id)
+		if !ok || !reflect.DeepEqual(writtenClass, class) {
+			t.Errorf("Error in TestMergeExecFiles: class %s not written back", class.Name)
+		}
+	}
+}
+
+func TestExecDataProbes(t *testing.T) {
+
+	probes := make([]bool, 200)
+	probes[0], probes[9], probes[199] = true, true, true
+
+	data := jc.GetNewExecData()
+	data.Sessions = []jc.SessionInfo{{Id: "shard-1", Start: 1700000000000, Dump: 1700000005000}}
+	for _, class := range []*jc.ExecClass{
+		{Id: 0x1234, Name: "com/shop/Cart", Probes: []bool{true, false, false}},
+		{Id: 0x1234, Name: "com/shop/Cart", Probes: []bool{false, false, true}},
+		{Id: 0x5678, Name: "com/shop/Cart", Probes: []bool{false}},
+		{Id: 0x9abc, Name: "com/shop/Stock", Probes: probes},
+	} {
+		err := data.AddClass(class)
+		if err != nil {
+			t.Fatalf("Error in TestExecDataProbes: %s", err.Error())
+		}
+	}
+
+	cart, _ := data.GetClass(0x1234)
+	if !reflect.DeepEqual(cart.Probes, []bool{true, false, true}) {
+		t.Errorf("Error in TestExecDataProbes: probes not or-ed %v", cart.Probes)
+	}
+	mismatches := data.GetClassIdMismatches()
+	if len(mismatches) != 1 || mismatches[0].String() !=
+		"class com/shop/Cart has execution data of 2 different class files (ids 0000000000001234, 0000000000005678)" {
+		t.Errorf("Error in TestExecDataProbes: unexpected mismatches %v", mismatches)
+	}
+
+	err := data.AddClass(&jc.ExecClass{Id: 0x1234, Name: "com/shop/Cart", Probes: []bool{true}})
+	if err == nil || !strings.Contains(err.Error(), "incompatible execution data for class com/shop/Cart") {
+		t.Errorf("Error in TestExecDataProbes: probes of another length accepted, got %v", err)
+	}
+
+	var buffer bytes.Buffer
+	err = data.Write(&buffer)
+	if err != nil {
+		t.Fatalf("Error in TestExecDataProbes: %s", err.Error())
+	}
+	read := jc.GetNewExecData()
+	err = read.Read(&buffer)
+	if err != nil {
+		t.Fatalf("Error in TestExecDataProbes: %s", err.Error())
+	}
+	stock, ok := read.GetClass(0x9abc)
+	if !ok || !reflect.DeepEqual(stock.Probes, probes) || !reflect.DeepEqual(read.Sessions, data.Sessions) {
+		t.Errorf("Error in TestExecDataProbes: exec data not read back %+v", read)
+	}
+
+	err = jc.GetNewExecData().Read(bytes.NewReader([]byte{0x01, 0xc0, 0xc0, 0x10, 0x06}))
+	if err == nil {
+		t.Errorf("Error in TestExecDataProbes: old exec format accepted")
+	}
+
+	// a probe count beyond an int32, and a large count of a truncated file
+	classBlock := []byte{0x01, 0xc0, 0xc0, 0x10, 0x07, 0x11, 0, 0, 0, 0, 0, 0, 0x12, 0x34, 0x00, 0x01, 'A'}
+	for _, probeCount := range [][]byte{{0x80, 0x80, 0x80, 0x80, 0x40}, {0x80, 0x80, 0x80, 0x80, 0x07}} {
+		err = jc.GetNewExecData().Read(bytes.NewReader(append(append([]byte{}, classBlock...), probeCount...)))
+		if err == nil {
+			t.Errorf("Error in TestExecDataProbes: corrupt probe count %v accepted", probeCount)
+		}
+	}
+}
+
+func TestCliMergeExec(t *testing.T) {
+
+	outputPath := filepath.Join(t.TempDir(), "merged.exec")
+	exitCode, stdout, stderr := RunTestCli(t, "merge-exec", "--reports-path-pattern",
+		"game-of-life/**/target/jacoco.exec", "--merged-exec-path", outputPath)
+	if exitCode != pd.ExitCodeOk || !strings.Contains(stdout, "2 exec files merged") {
+		t.Fatalf("Error in TestCliMergeExec: exit code %d, %s%s", exitCode, stdout, stderr)
+	}
+
+	_, err := jc.ReadExecFile(outputPath)
+	if err != nil {
+		t.Errorf("Error in TestCliMergeExec: %s", err.Error())
+	}
+
+	exitCode, _, _ = RunTestCli(t, "merge-exec", "--reports-path-pattern", "**/missing.exec")
+	if exitCode != pd.ExitCodeNoReports {
+		t.Errorf("Error in TestCliMergeExec: expected exit code %d without exec files, got %d",
+			pd.ExitCodeNoReports, exitCode)
+	}
+}
+
+func TestJacocoPluginMergeExecFiles(t *testing.T) {
+
+	workSpaceDir := t.TempDir()
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, workSpaceDir)
+
+	p := jc.GetNewJacocoPlugin()
+	p.InputArgs = &pd.Args{EnvPluginInputArgs: pd.EnvPluginInputArgs{MergedExecPath: "coverage/merged.exec"}}
+	for _, module := range []string{"gameoflife-core", "gameoflife-web"} {
+		p.ExecFilesFinalCompletePath = append(p.ExecFilesFinalCompletePath,
+			filepath.Join(pd.TestWorkSpaceDir, "game-of-life", module, "target", "jacoco.exec"))
+	}
+
+	err := p.MergeJacocoExecFiles()
+	if err != nil {
+		t.Fatalf("Error in TestJacocoPluginMergeExecFiles: %s", err.Error())
+	}
+	if !reflect.DeepEqual(p.ExecFilesFinalCompletePath,
+		[]string{filepath.Join(p.GetExecFilesWorkSpaceDir(), jc.MergedExecFileName)}) {
+		t.Errorf("Error in TestJacocoPluginMergeExecFiles: JaCoCo not given the merged exec file %v",
+			p.ExecFilesFinalCompletePath)
+	}
+	_, err = jc.ReadExecFile(filepath.Join(workSpaceDir, "coverage", "merged.exec"))
+	if err != nil {
+		t.Errorf("Error in TestJacocoPluginMergeExecFiles: %s", err.Error())
+	}
+}
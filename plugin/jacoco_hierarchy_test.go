@@ -0,0 +1,234 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	jc "github.com/harness-community/drone-coverage-report/plugin/jacoco"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAggregateJacocoXml is a report-aggregate report of two modules, one
+// of them nested in a group of its own.
+const TestAggregateJacocoXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<report name="shop">
+  <sessioninfo id="build-1" start="1700000000000" dump="1700000005000"/>
+  <sessioninfo id="build-2" start="1700000010000" dump="1700000015000"/>
+  <group name="shop-api">
+    <package name="com/shop/api">
+      <class name="com/shop/api/Cart" sourcefilename="Cart.java">
+        <method name="add" desc="(I)V" line="5">
+          <counter type="INSTRUCTION" missed="0" covered="6"/>
+          <counter type="BRANCH" missed="1" covered="1"/>
+          <counter type="LINE" missed="0" covered="2"/>
+          <counter type="COMPLEXITY" missed="1" covered="1"/>
+          <counter type="METHOD" missed="0" covered="1"/>
+        </method>
+        <method name="clear" desc="()V" line="9">
+          <counter type="INSTRUCTION" missed="4" covered="0"/>
+          <counter type="LINE" missed="2" covered="0"/>
+          <counter type="COMPLEXITY" missed="1" covered="0"/>
+          <counter type="METHOD" missed="1" covered="0"/>
+        </method>
+        <counter type="INSTRUCTION" missed="4" covered="6"/>
+        <counter type="BRANCH" missed="1" covered="1"/>
+        <counter type="LINE" missed="2" covered="2"/>
+        <counter type="COMPLEXITY" missed="2" covered="1"/>
+        <counter type="METHOD" missed="1" covered="1"/>
+        <counter type="CLASS" missed="0" covered="1"/>
+      </class>
+      <sourcefile name="Cart.java">
+        <line nr="5" mi="0" ci="3" mb="1" cb="1"/>
+        <line nr="6" mi="0" ci="3" mb="0" cb="0"/>
+        <line nr="9" mi="2" ci="0" mb="0" cb="0"/>
+        <line nr="10" mi="2" ci="0" mb="0" cb="0"/>
+        <counter type="INSTRUCTION" missed="4" covered="6"/>
+        <counter type="BRANCH" missed="1" covered="1"/>
+        <counter type="LINE" missed="2" covered="2"/>
+        <counter type="COMPLEXITY" missed="2" covered="1"/>
+        <counter type="METHOD" missed="1" covered="1"/>
+        <counter type="CLASS" missed="0" covered="1"/>
+      </sourcefile>
+      <counter type="INSTRUCTION" missed="4" covered="6"/>
+      <counter type="BRANCH" missed="1" covered="1"/>
+      <counter type="LINE" missed="2" covered="2"/>
+      <counter type="COMPLEXITY" missed="2" covered="1"/>
+      <counter type="METHOD" missed="1" covered="1"/>
+      <counter type="CLASS" missed="0" covered="1"/>
+    </package>
+    <counter type="INSTRUCTION" missed="4" covered="6"/>
+    <counter type="BRANCH" missed="1" covered="1"/>
+    <counter type="LINE" missed="2" covered="2"/>
+    <counter type="COMPLEXITY" missed="2" covered="1"/>
+    <counter type="METHOD" missed="1" covered="1"/>
+    <counter type="CLASS" missed="0" covered="1"/>
+  </group>
+  <group name="shop-backend">
+    <group name="shop-store">
+      <package name="com/shop/store">
+        <class name="com/shop/store/Stock" sourcefilename="Stock.java">
+          <method name="count" desc="()I" line="3">
+            <counter type="INSTRUCTION" missed="3" covered="0"/>
+            <counter type="LINE" missed="1" covered="0"/>
+            <counter type="COMPLEXITY" missed="1" covered="0"/>
+            <counter type="METHOD" missed="1" covered="0"/>
+          </method>
+          <counter type="INSTRUCTION" missed="3" covered="0"/>
+          <counter type="LINE" missed="1" covered="0"/>
+          <counter type="COMPLEXITY" missed="1" covered="0"/>
+          <counter type="METHOD" missed="1" covered="0"/>
+          <counter type="CLASS" missed="1" covered="0"/>
+        </class>
+        <sourcefile name="Stock.java">
+          <line nr="3" mi="3" ci="0" mb="0" cb="0"/>
+          <counter type="INSTRUCTION" missed="3" covered="0"/>
+          <counter type="LINE" missed="1" covered="0"/>
+          <counter type="COMPLEXITY" missed="1" covered="0"/>
+          <counter type="METHOD" missed="1" covered="0"/>
+          <counter type="CLASS" missed="1" covered="0"/>
+        </sourcefile>
+        <counter type="INSTRUCTION" missed="3" covered="0"/>
+        <counter type="LINE" missed="1" covered="0"/>
+        <counter type="COMPLEXITY" missed="1" covered="0"/>
+        <counter type="METHOD" missed="1" covered="0"/>
+        <counter type="CLASS" missed="1" covered="0"/>
+      </package>
+      <counter type="INSTRUCTION" missed="3" covered="0"/>
+      <counter type="LINE" missed="1" covered="0"/>
+      <counter type="COMPLEXITY" missed="1" covered="0"/>
+      <counter type="METHOD" missed="1" covered="0"/>
+      <counter type="CLASS" missed="1" covered="0"/>
+    </group>
+    <counter type="INSTRUCTION" missed="3" covered="0"/>
+    <counter type="LINE" missed="1" covered="0"/>
+    <counter type="COMPLEXITY" missed="1" covered="0"/>
+    <counter type="METHOD" missed="1" covered="0"/>
+    <counter type="CLASS" missed="1" covered="0"/>
+  </group>
+  <counter type="INSTRUCTION" missed="7" covered="6"/>
+  <counter type="BRANCH" missed="1" covered="1"/>
+  <counter type="LINE" missed="3" covered="2"/>
+  <counter type="COMPLEXITY" missed="3" covered="1"/>
+  <counter type="METHOD" missed="2" covered="1"/>
+  <counter type="CLASS" missed="1" covered="1"/>
+</report>
+`
+
+// GetTestAggregateJacocoWorkSpace writes the aggregate report to a new
+// workspace and returns its directory.
+func GetTestAggregateJacocoWorkSpace(t *testing.T) string {
+	workSpaceDir := t.TempDir()
+	reportDir := filepath.Join(workSpaceDir, "target", "site", "jacoco-aggregate")
+	err := os.MkdirAll(reportDir, 0755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(reportDir, "jacoco.xml"), []byte(TestAggregateJacocoXml), 0644)
+	}
+	if err != nil {
+		t.Fatalf("Error in GetTestAggregateJacocoWorkSpace: %s", err.Error())
+	}
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, workSpaceDir)
+	return workSpaceDir
+}
+
+func TestParseJacocoXmlHierarchy(t *testing.T) {
+
+	workSpaceDir := GetTestAggregateJacocoWorkSpace(t)
+	report, err := jc.ParseXMLReport(filepath.Join(workSpaceDir, "target/site/jacoco-aggregate/jacoco.xml"))
+	if err != nil {
+		t.Fatalf("Error in TestParseJacocoXmlHierarchy: %s", err.Error())
+	}
+
+	if len(report.SessionInfos) != 2 || report.SessionInfos[1].Id != "build-2" ||
+		report.SessionInfos[1].Dump != 1700000015000 {
+		t.Errorf("Error in TestParseJacocoXmlHierarchy: unexpected sessions %+v", report.SessionInfos)
+	}
+	if len(report.Groups) != 2 || len(report.Groups[1].Groups) != 1 || report.Groups[1].Groups[0].Name != "shop-store" {
+		t.Errorf("Error in TestParseJacocoXmlHierarchy: unexpected groups %+v", report.Groups)
+	}
+
+	packages := report.GetPackages()
+	if len(packages) != 2 || packages[1].Name != "com/shop/store" {
+		t.Fatalf("Error in TestParseJacocoXmlHierarchy: packages of the groups not found %+v", packages)
+	}
+	methods := packages[0].Classes[0].Methods
+	if len(methods) != 2 || methods[1].Name != "clear" || methods[1].Descriptor != "()V" || methods[1].Line != 9 {
+		t.Errorf("Error in TestParseJacocoXmlHierarchy: unexpected methods %+v", methods)
+	}
+}
+
+func TestJacocoXmlGroupedPackages(t *testing.T) {
+
+	GetTestAggregateJacocoWorkSpace(t)
+
+	args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	args.ExecFilesPathPattern = "**/jacoco-aggregate/jacoco.xml"
+	args.ClassExclusionPatterns = "**/store/**"
+	args.SummaryJsonPath = "summary.json"
+
+	plugin, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlGroupedPackages: %s", err.Error())
+	}
+
+	report := plugin.GetCoverageReport()
+	if len(report.Packages) != 1 || report.Packages[0].Name != "com/shop/api" {
+		t.Fatalf("Error in TestJacocoXmlGroupedPackages: unexpected packages %+v", report.Packages)
+	}
+	if report.Counters.Get(coverage.LineCounter) != (coverage.Counter{Covered: 2, Missed: 2}) {
+		t.Errorf("Error in TestJacocoXmlGroupedPackages: counters not recomputed %v", report.Counters)
+	}
+
+	summary, err := pd.ReadCoverageSummary(filepath.Join(pd.GetTestWorkSpaceDir(), args.SummaryJsonPath))
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlGroupedPackages: %s", err.Error())
+	}
+	if len(summary.UncoveredFiles) != 1 || summary.UncoveredFiles[0].String() !=
+		"com/shop/api/Cart.java: 9-10; partial branches 5 (1/2)" {
+		t.Errorf("Error in TestJacocoXmlGroupedPackages: unexpected uncovered lines %+v", summary.UncoveredFiles)
+	}
+}
+
+func TestJacocoXmlModules(t *testing.T) {
+
+	GetTestAggregateJacocoWorkSpace(t)
+	outputPath := filepath.Join(t.TempDir(), "drone-output")
+	t.Setenv("DRONE_OUTPUT", outputPath)
+
+	args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{MinimumModuleCoverage: 40})
+	args.ExecFilesPathPattern = "**/jacoco-aggregate/jacoco.xml"
+	args.PluginFailOnThreshold = true
+	args.MinimumComplexityCoverage = 10
+	args.SummaryJsonPath = "summary.json"
+
+	_, err := Exec(context.TODO(), args)
+	if !errors.Is(err, pd.ErrThreshold) || !strings.Contains(err.Error(), "1 module coverage thresholds not met") {
+		t.Errorf("Error in TestJacocoXmlModules: shop-store module should fail, got %v", err)
+	}
+
+	summary, err := pd.ReadCoverageSummary(filepath.Join(pd.GetTestWorkSpaceDir(), args.SummaryJsonPath))
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlModules: %s", err.Error())
+	}
+	if len(summary.Modules) != 2 || summary.Modules[1].Name != "shop-backend/shop-store" {
+		t.Errorf("Error in TestJacocoXmlModules: unexpected modules %+v", summary.Modules)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlModules: %s", err.Error())
+	}
+	expected := ModuleCoverageOutputVariableKey + "=shop-api=50.00,shop-backend/shop-store=0.00"
+	if !strings.Contains(string(output), expected) {
+		t.Errorf("Error in TestJacocoXmlModules: %q not found in output %q", expected, output)
+	}
+
+	args.ThresholdWarnings = "module"
+	_, err = Exec(context.TODO(), args)
+	if err != nil {
+		t.Errorf("Error in TestJacocoXmlModules: module threshold should only warn: %s", err.Error())
+	}
+}
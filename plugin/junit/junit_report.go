@@ -0,0 +1,109 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+)
+
+// TestSuites is a JUnit XML report with one test case per threshold check,
+// so CI systems show the coverage gates next to the test results.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Errors    int        `xml:"errors,attr"`
+	Skipped   int        `xml:"skipped,attr"`
+	Time      string     `xml:"time,attr"`
+	TestCases []TestCase `xml:"testcase"`
+}
+
+type TestCase struct {
+	ClassName string   `xml:"classname,attr"`
+	Name      string   `xml:"name,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// GetTestSuites returns a suite for the global threshold checks and one per
+// package for the minimum coverage thresholds applied to that package,
+// together with the package rule checks of the package.
+func GetTestSuites(report *coverage.Report, thresholdChecks []pd.ThresholdCheck) TestSuites {
+
+	var globalChecks []pd.ThresholdCheck
+	packageRuleChecks := map[string][]pd.ThresholdCheck{}
+	for _, check := range thresholdChecks {
+		if check.Package != "" {
+			packageRuleChecks[check.Package] = append(packageRuleChecks[check.Package], check)
+		} else {
+			globalChecks = append(globalChecks, check)
+		}
+	}
+
+	testSuites := TestSuites{Name: SuiteName}
+	testSuites.addSuite(SuiteName, globalChecks)
+
+	for _, pkg := range report.Packages {
+		packageChecks := pd.GetPackageThresholdChecks(globalChecks, pkg.Counters)
+		packageChecks = append(packageChecks, packageRuleChecks[pkg.Name]...)
+		if len(packageChecks) > 0 {
+			testSuites.addSuite(SuiteName+"."+pkg.Name, packageChecks)
+		}
+	}
+	return testSuites
+}
+
+func (t *TestSuites) addSuite(name string, checks []pd.ThresholdCheck) {
+	testSuite := TestSuite{Name: name, Time: "0"}
+	for _, check := range checks {
+		testCase := TestCase{
+			ClassName: name,
+			Name:      fmt.Sprintf("%s %s", check.Metric, check.GetExpectedString()),
+			Time:      "0",
+			SystemOut: fmt.Sprintf("observed = %s", check.FormatValue(check.ObservedValue)),
+		}
+		if check.IsWarningRaised() {
+			testCase.SystemOut += fmt.Sprintf(", warning: expected %s", check.GetExpectedString())
+		} else if !check.Passed {
+			message := fmt.Sprintf("observed %s, expected %s",
+				check.FormatValue(check.ObservedValue), check.GetExpectedString())
+			testCase.Failure = &Failure{Message: message, Type: FailureType, Text: check.String()}
+			testSuite.Failures++
+		}
+		testSuite.TestCases = append(testSuite.TestCases, testCase)
+		testSuite.Tests++
+	}
+
+	t.Suites = append(t.Suites, testSuite)
+	t.Tests += testSuite.Tests
+	t.Failures += testSuite.Failures
+}
+
+func (t TestSuites) Write(junitPath string) error {
+	data, err := xml.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return pd.WriteFileAsString(junitPath, xml.Header+string(data)+"\n")
+}
+
+const (
+	SuiteName   = "coverage"
+	FailureType = "CoverageThreshold"
+)
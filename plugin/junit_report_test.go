@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/harness-community/drone-coverage-report/plugin/junit"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoberturaJunitThresholdReport(t *testing.T) {
+
+	envPluginInputArgs := pd.EnvPluginInputArgs{
+		MinimumLineCoverage:  40.0,
+		MinimumClassCoverage: 30.0,
+	}
+	args := GetTestCoberturaNewArgs(envPluginInputArgs)
+	args.PluginFailOnThreshold = false
+	args.JunitReportPath = filepath.Join(t.TempDir(), "coverage-junit.xml")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaJunitThresholdReport: %s", err.Error())
+	}
+
+	data, err := os.ReadFile(args.JunitReportPath)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaJunitThresholdReport: %s", err.Error())
+	}
+	var testSuites junit.TestSuites
+	err = xml.Unmarshal(data, &testSuites)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaJunitThresholdReport: %s", err.Error())
+	}
+
+	if len(testSuites.Suites) != 4 || testSuites.Suites[0].Name != "coverage" ||
+		testSuites.Suites[1].Name != "coverage.com.example.package1" {
+		t.Fatalf("Error in TestCoberturaJunitThresholdReport: unexpected suites %v", testSuites.Suites)
+	}
+
+	if testSuites.Suites[0].Tests != 2 {
+		t.Errorf("Error in TestCoberturaJunitThresholdReport: expected test cases of the 2 thresholds set, got %v",
+			testSuites.Suites[0].TestCases)
+	}
+
+	testCases := map[string]junit.TestCase{}
+	for _, testCase := range testSuites.Suites[0].TestCases {
+		testCases[testCase.Name] = testCase
+	}
+	lineTestCase, ok := testCases["Line >= 40.00%"]
+	if !ok || lineTestCase.Failure == nil || lineTestCase.Failure.Message != "observed 11.76%, expected >= 40.00%" {
+		t.Errorf("Error in TestCoberturaJunitThresholdReport: unexpected line test case %v", lineTestCase)
+	}
+	classTestCase, ok := testCases["Class >= 30.00%"]
+	if !ok || classTestCase.Failure != nil {
+		t.Errorf("Error in TestCoberturaJunitThresholdReport: unexpected class test case %v", classTestCase)
+	}
+
+	package1Suite := testSuites.Suites[1]
+	if package1Suite.Tests != 2 || package1Suite.Failures != 1 ||
+		package1Suite.TestCases[1].Name != "Line >= 40.00%" || package1Suite.TestCases[1].Failure == nil {
+		t.Errorf("Error in TestCoberturaJunitThresholdReport: unexpected package suite %v", package1Suite)
+	}
+	if testSuites.Failures != testSuites.Suites[0].Failures+5 {
+		t.Errorf("Error in TestCoberturaJunitThresholdReport: unexpected failure count %d", testSuites.Failures)
+	}
+}
+
+func TestJunitReportWithoutThresholds(t *testing.T) {
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.JunitReportPath = filepath.Join(t.TempDir(), "coverage-junit.xml")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestJunitReportWithoutThresholds: %s", err.Error())
+	}
+
+	data, err := os.ReadFile(args.JunitReportPath)
+	if err != nil {
+		t.Fatalf("Error in TestJunitReportWithoutThresholds: %s", err.Error())
+	}
+	var testSuites junit.TestSuites
+	err = xml.Unmarshal(data, &testSuites)
+	if err != nil {
+		t.Fatalf("Error in TestJunitReportWithoutThresholds: %s", err.Error())
+	}
+	if testSuites.Tests != 0 || testSuites.Failures != 0 {
+		t.Errorf("Error in TestJunitReportWithoutThresholds: expected no test cases, got %v", testSuites.Suites)
+	}
+}
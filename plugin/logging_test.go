@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+	"testing"
+)
+
+// CaptureLogs sends the logs to a buffer until the test ends.
+func CaptureLogs(t *testing.T, level, format string) *bytes.Buffer {
+	var logs bytes.Buffer
+	err := pd.SetupLogging(level, format)
+	if err != nil {
+		t.Fatalf("Error in CaptureLogs: %s", err.Error())
+	}
+	logrus.SetOutput(&logs)
+	t.Cleanup(func() {
+		logrus.SetOutput(os.Stderr)
+		_ = pd.SetupLogging("", "")
+	})
+	return &logs
+}
+
+func TestCoberturaJsonLogSummary(t *testing.T) {
+
+	logs := CaptureLogs(t, "info", pd.JsonLogFormat)
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{MinimumLineCoverage: 40.0})
+	args.PluginFailOnThreshold = false
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaJsonLogSummary: %s", err.Error())
+	}
+
+	var summaryEntry map[string]interface{}
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		entry := map[string]interface{}{}
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			t.Fatalf("Error in TestCoberturaJsonLogSummary: log line is not json %s", scanner.Text())
+		}
+		if entry["level"] != "info" {
+			t.Errorf("Error in TestCoberturaJsonLogSummary: unexpected %v log %v", entry["level"], entry["msg"])
+		}
+		if strings.HasPrefix(entry["msg"].(string), "Coverage summary (cobertura):") {
+			summaryEntry = entry
+		}
+	}
+
+	if summaryEntry == nil {
+		t.Fatalf("Error in TestCoberturaJsonLogSummary: coverage summary not logged")
+	}
+	if summaryEntry["passed"] != false || summaryEntry["lineCoverage"].(float64) < 11.76 ||
+		summaryEntry["lineCoverage"].(float64) > 11.77 {
+		t.Errorf("Error in TestCoberturaJsonLogSummary: unexpected summary fields %v", summaryEntry)
+	}
+	message := summaryEntry["msg"].(string)
+	if !strings.Contains(message, "Line threshold not met expected = >= 40.00% observed = 11.76%") {
+		t.Errorf("Error in TestCoberturaJsonLogSummary: failed check missing from %s", message)
+	}
+}
+
+func TestSetupLoggingLevels(t *testing.T) {
+
+	logs := CaptureLogs(t, "info", "")
+	pd.LogPrintln(nil, "debug message")
+	logrus.Printf("info message\n")
+	if logs.String() != "info message\n" {
+		t.Errorf("Error in TestSetupLoggingLevels: unexpected info logs %q", logs.String())
+	}
+
+	logs = CaptureLogs(t, "debug", "")
+	pd.LogPrintln(nil, "debug message")
+	if logs.String() != "level=debug msg=\"debug message\"\n" {
+		t.Errorf("Error in TestSetupLoggingLevels: unexpected debug logs %q", logs.String())
+	}
+
+	err := pd.SetupLogging("verbose", "")
+	if err == nil || !strings.Contains(err.Error(), "invalid log level verbose") {
+		t.Errorf("Error in TestSetupLoggingLevels: expected invalid level error, got %v", err)
+	}
+	err = pd.SetupLogging("info", "xml")
+	if err == nil || !strings.Contains(err.Error(), "invalid log format xml") {
+		t.Errorf("Error in TestSetupLoggingLevels: expected invalid format error, got %v", err)
+	}
+}
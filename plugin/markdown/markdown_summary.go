@@ -0,0 +1,245 @@
+package markdown
+
+import (
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"sort"
+	"strings"
+)
+
+// MarkdownSummary renders the coverage of a run as Markdown suitable for
+// pull request comments and step summaries.
+type MarkdownSummary struct {
+	Report          *coverage.Report
+	Summary         pd.CoverageSummary
+	Baseline        *pd.CoverageSummary
+	ThresholdChecks []pd.ThresholdCheck
+	TopFilesCount   int
+}
+
+func GetNewMarkdownSummary(report *coverage.Report, summary pd.CoverageSummary,
+	baseline *pd.CoverageSummary, topFilesCount int) *MarkdownSummary {
+
+	return &MarkdownSummary{
+		Report:          report,
+		Summary:         summary,
+		Baseline:        baseline,
+		ThresholdChecks: summary.ThresholdChecks,
+		TopFilesCount:   topFilesCount,
+	}
+}
+
+func (m *MarkdownSummary) Render() string {
+	var sb strings.Builder
+
+	m.writeHeading(&sb)
+	m.writeMetricsTable(&sb)
+	m.writeLeastCoveredFiles(&sb)
+	m.writePackagesSection(&sb)
+
+	return sb.String()
+}
+
+func (m *MarkdownSummary) writeHeading(sb *strings.Builder) {
+	if m.Summary.Passed && m.Summary.Warnings > 0 {
+		sb.WriteString(fmt.Sprintf("## %s Coverage report with %d threshold warnings\n\n", WarningIcon,
+			m.Summary.Warnings))
+	} else if m.Summary.Passed {
+		sb.WriteString("## " + PassedIcon + " Coverage report\n\n")
+	} else {
+		sb.WriteString("## " + FailedIcon + " Coverage thresholds not met\n\n")
+	}
+
+	details := []string{"Tool: `" + m.Report.Tool + "`"}
+	if m.Summary.BuildNumber > 0 {
+		details = append(details, fmt.Sprintf("Build: #%d", m.Summary.BuildNumber))
+	}
+	if m.Summary.Commit != "" {
+		details = append(details, "Commit: `"+ShortCommit(m.Summary.Commit)+"`")
+	}
+	if m.Baseline != nil && m.Baseline.Commit != "" {
+		details = append(details, "Baseline: `"+ShortCommit(m.Baseline.Commit)+"`")
+	}
+	sb.WriteString(strings.Join(details, " · ") + "\n\n")
+}
+
+func (m *MarkdownSummary) writeMetricsTable(sb *strings.Builder) {
+	showDelta := m.Baseline != nil
+
+	header := "| Metric | Coverage |"
+	separator := "| :--- | ---: |"
+	if showDelta {
+		header += " Δ |"
+		separator += " ---: |"
+	}
+	sb.WriteString(header + " Threshold | Status |\n")
+	sb.WriteString(separator + " ---: | :---: |\n")
+
+	checksByCounter := map[coverage.CounterType]pd.ThresholdCheck{}
+	var otherChecks []pd.ThresholdCheck
+	for _, check := range m.ThresholdChecks {
+		if check.IsCounterCheck() {
+			checksByCounter[check.CounterType] = check
+		} else {
+			otherChecks = append(otherChecks, check)
+		}
+	}
+
+	for _, counterType := range coverage.AllCounterTypes {
+		if !m.Report.Counters.Has(counterType) {
+			continue
+		}
+		counter := m.Report.Counters.Get(counterType)
+		row := fmt.Sprintf("| %s | %s |", counterType.DisplayName(), FormatCounter(counter))
+		if showDelta {
+			row += " " + m.getDelta(counterType) + " |"
+		}
+		if check, ok := checksByCounter[counterType]; ok {
+			row += fmt.Sprintf(" %s | %s |", check.GetExpectedString(), GetStatusIcon(check))
+		} else {
+			row += " - | |"
+		}
+		sb.WriteString(row + "\n")
+	}
+
+	for _, check := range otherChecks {
+		row := fmt.Sprintf("| %s | %s |", check.Metric, check.FormatValue(check.ObservedValue))
+		if showDelta {
+			row += " |"
+		}
+		row += fmt.Sprintf(" %s | %s |", check.GetExpectedString(), GetStatusIcon(check))
+		sb.WriteString(row + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+func (m *MarkdownSummary) getDelta(counterType coverage.CounterType) string {
+	delta, ok := m.Summary.GetDelta(m.Baseline, counterType)
+	if !ok {
+		return "-"
+	}
+	return FormatDelta(delta)
+}
+
+func (m *MarkdownSummary) writeLeastCoveredFiles(sb *strings.Builder) {
+	files := GetLeastCoveredFiles(m.Report, m.TopFilesCount)
+	if len(files) == 0 {
+		return
+	}
+
+	sb.WriteString("### Least covered files\n\n")
+	sb.WriteString("| File | Line coverage | Missed lines |\n")
+	sb.WriteString("| :--- | ---: | ---: |\n")
+	for _, file := range files {
+		lineCounter := file.Counters.Get(coverage.LineCounter)
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %d |\n", file.Path, FormatCounter(lineCounter), lineCounter.Missed))
+	}
+	sb.WriteString("\n")
+}
+
+func (m *MarkdownSummary) writePackagesSection(sb *strings.Builder) {
+	if len(m.Report.Packages) == 0 {
+		return
+	}
+
+	var columns []coverage.CounterType
+	for _, counterType := range coverage.AllCounterTypes {
+		if counterType != coverage.ComplexityCounter && m.Report.Counters.Has(counterType) {
+			columns = append(columns, counterType)
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("<details>\n<summary>Coverage by package (%d)</summary>\n\n", len(m.Report.Packages)))
+
+	header := "| Package |"
+	separator := "| :--- |"
+	for _, column := range columns {
+		header += " " + column.DisplayName() + " |"
+		separator += " ---: |"
+	}
+	sb.WriteString(header + "\n" + separator + "\n")
+
+	packages := append([]*coverage.Package{}, m.Report.Packages...)
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	for _, pkg := range packages {
+		name := strings.ReplaceAll(pkg.Name, "/", ".")
+		if name == "" {
+			name = "(default)"
+		}
+		row := "| `" + name + "` |"
+		for _, column := range columns {
+			row += fmt.Sprintf(" %.2f%% |", pkg.Counters.Get(column).Percentage())
+		}
+		sb.WriteString(row + "\n")
+	}
+
+	sb.WriteString("\n</details>\n")
+}
+
+// GetLeastCoveredFiles returns up to count files with the lowest line
+// coverage, most missed lines first on ties. A negative count returns none.
+func GetLeastCoveredFiles(report *coverage.Report, count int) []*coverage.File {
+	var files []*coverage.File
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			if file.Counters.Get(coverage.LineCounter).Total() > 0 {
+				files = append(files, file)
+			}
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		x, y := files[i].Counters.Get(coverage.LineCounter), files[j].Counters.Get(coverage.LineCounter)
+		if x.Percentage() != y.Percentage() {
+			return x.Percentage() < y.Percentage()
+		}
+		return x.Missed > y.Missed
+	})
+
+	if count < 0 {
+		count = 0
+	}
+	if count < len(files) {
+		files = files[:count]
+	}
+	return files
+}
+
+func FormatCounter(counter coverage.Counter) string {
+	return fmt.Sprintf("%.2f%% (%d/%d)", counter.Percentage(), counter.Covered, counter.Total())
+}
+
+func FormatDelta(delta float64) string {
+	switch {
+	case delta > 0.005:
+		return fmt.Sprintf("+%.2f%%", delta)
+	case delta < -0.005:
+		return fmt.Sprintf("%.2f%%", delta)
+	default:
+		return "±0.00%"
+	}
+}
+
+func GetStatusIcon(check pd.ThresholdCheck) string {
+	if check.Passed {
+		return PassedIcon
+	}
+	if check.IsWarning() {
+		return WarningIcon
+	}
+	return FailedIcon
+}
+
+func ShortCommit(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
+const (
+	PassedIcon  = "✅"
+	FailedIcon  = "❌"
+	WarningIcon = "⚠️"
+)
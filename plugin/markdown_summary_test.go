@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"context"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoberturaMarkdownSummaryWithBaseline(t *testing.T) {
+
+	outputDir := t.TempDir()
+	envPluginInputArgs := pd.EnvPluginInputArgs{
+		MinimumLineCoverage:  5.0,
+		MinimumClassCoverage: 40,
+	}
+
+	args := GetTestCoberturaNewArgs(envPluginInputArgs)
+	args.PluginFailOnThreshold = false
+	args.SummaryJsonPath = filepath.Join(outputDir, "baseline.json")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaMarkdownSummaryWithBaseline: %s", err.Error())
+	}
+
+	t.Setenv("DRONE_OUTPUT", filepath.Join(outputDir, "drone-output.env"))
+
+	args = GetTestCoberturaNewArgs(envPluginInputArgs)
+	args.PluginFailOnThreshold = false
+	args.BaselineSummaryJsonPath = filepath.Join(outputDir, "baseline.json")
+	args.MarkdownPath = filepath.Join(outputDir, "coverage.md")
+	args.MarkdownOutputVariable = true
+	args.MarkdownTopFiles = 2
+
+	_, err = Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaMarkdownSummaryWithBaseline: %s", err.Error())
+	}
+
+	data, err := os.ReadFile(args.MarkdownPath)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaMarkdownSummaryWithBaseline: %s", err.Error())
+	}
+	markdownSummary := string(data)
+
+	expectedContents := []string{
+		"## ❌ Coverage thresholds not met",
+		"| Metric | Coverage | Δ | Threshold | Status |",
+		"| Line | 11.76% (2/17) | ±0.00% | >= 5.00% | ✅ |",
+		"| Class | 33.33% (1/3) | ±0.00% | >= 40.00% | ❌ |",
+		"### Least covered files",
+		"<summary>Coverage by package (3)</summary>",
+		"| `com.example.package1` |",
+	}
+	for _, expected := range expectedContents {
+		if !strings.Contains(markdownSummary, expected) {
+			t.Errorf("Error in TestCoberturaMarkdownSummaryWithBaseline: markdown does not contain %s", expected)
+		}
+	}
+
+	if strings.Count(markdownSummary, "| `com/example/") != 2 {
+		t.Errorf("Error in TestCoberturaMarkdownSummaryWithBaseline: expected 2 least covered files")
+	}
+
+	outputVariables, err := pd.ReadFileAsString(os.Getenv("DRONE_OUTPUT"))
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaMarkdownSummaryWithBaseline: %s", err.Error())
+	}
+	if !strings.Contains(outputVariables, `COVERAGE_SUMMARY_MARKDOWN="## ❌ Coverage thresholds not met\n`) {
+		t.Errorf("Error in TestCoberturaMarkdownSummaryWithBaseline: markdown output variable not written")
+	}
+}
+
+func TestMarkdownSummaryNegativeTopFiles(t *testing.T) {
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.MarkdownPath = filepath.Join(t.TempDir(), "coverage.md")
+	args.MarkdownTopFiles = -1
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestMarkdownSummaryNegativeTopFiles: %s", err.Error())
+	}
+
+	markdownSummary, err := pd.ReadFileAsString(args.MarkdownPath)
+	if err != nil {
+		t.Fatalf("Error in TestMarkdownSummaryNegativeTopFiles: %s", err.Error())
+	}
+	if strings.Contains(markdownSummary, "| `com/example/") {
+		t.Errorf("Error in TestMarkdownSummaryNegativeTopFiles: expected no least covered files")
+	}
+}
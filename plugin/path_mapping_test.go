@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"context"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoberturaHtmlReportWithoutSourceDirectories(t *testing.T) {
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.HtmlReportDir = t.TempDir()
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaHtmlReportWithoutSourceDirectories: %s", err.Error())
+	}
+
+	CheckHtmlReportPage(t, args.HtmlReportDir, "com.example.package1.Calculator.java.html",
+		`<tr id="L5" class="fc"`, "public int add")
+}
+
+func TestPathMapper(t *testing.T) {
+
+	workSpaceDir := t.TempDir()
+	for _, relPath := range []string{
+		"service/src/main/java/com/example/App.java",
+		"service/src/main/java/com/example/util/Strings.java",
+		"web/src/main/java/com/example/util/Strings.java",
+		"lib/pkg/Lib.java",
+		".git/com/example/App.java",
+		"node_modules/com/example/App.java",
+		"service/pom.xml",
+		"service/target/generated-sources/com/example/App.java",
+	} {
+		completePath := filepath.Join(workSpaceDir, relPath)
+		_ = os.MkdirAll(filepath.Dir(completePath), 0755)
+		err := os.WriteFile(completePath, []byte("class X {}\n"), 0644)
+		if err != nil {
+			t.Fatalf("Error in TestPathMapper: %s", err.Error())
+		}
+	}
+
+	mappings, err := coverage.ParsePathMappings([]string{"/build/libs=lib", " "})
+	if err != nil {
+		t.Fatalf("Error in TestPathMapper: %s", err.Error())
+	}
+	mapper := coverage.GetNewPathMapper(workSpaceDir, mappings)
+
+	testCases := []struct {
+		reportPath  string
+		sourceRoots []string
+		sourcePath  string
+	}{
+		{"/build/libs/pkg/Lib.java", nil, "lib/pkg/Lib.java"},
+		{"com/example/App.java", nil, "service/src/main/java/com/example/App.java"},
+		{"/opt/ci/checkout/service/src/main/java/com/example/App.java", nil,
+			"service/src/main/java/com/example/App.java"},
+		{"com/example/util/Strings.java", nil, ""},
+		{"com/example/util/Strings.java", []string{"/opt/ci/checkout/web/src/main/java"},
+			"web/src/main/java/com/example/util/Strings.java"},
+		{"com/example/Missing.java", nil, ""},
+	}
+
+	for _, testCase := range testCases {
+		sourcePath, _, err := mapper.MapPath(testCase.reportPath, testCase.sourceRoots)
+		if err != nil {
+			t.Fatalf("Error in TestPathMapper: %s", err.Error())
+		}
+		if sourcePath != testCase.sourcePath {
+			t.Errorf("Error in TestPathMapper: %s mapped to %q, expected %q",
+				testCase.reportPath, sourcePath, testCase.sourcePath)
+		}
+	}
+
+	mapper = coverage.GetNewPathMapper(workSpaceDir, nil)
+	mapper.SearchDirs = []string{filepath.Join(workSpaceDir, "web"), filepath.Join(workSpaceDir, "web/src/main")}
+	sourcePath, _, err := mapper.MapPath("com/example/util/Strings.java", nil)
+	if err != nil || sourcePath != "web/src/main/java/com/example/util/Strings.java" {
+		t.Errorf("Error in TestPathMapper: files outside of the search dirs matched, got %q %v", sourcePath, err)
+	}
+
+	_, err = coverage.ParsePathMappings([]string{"/build/libs"})
+	if err == nil {
+		t.Errorf("Error in TestPathMapper: expected an error for a mapping without a target")
+	}
+}
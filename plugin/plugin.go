@@ -6,18 +6,27 @@ package plugin
 
 import (
 	"context"
+	"fmt"
 	cb "github.com/harness-community/drone-coverage-report/plugin/cobertura"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
 	jc "github.com/harness-community/drone-coverage-report/plugin/jacoco"
 	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/sirupsen/logrus"
 )
 
 func GetNewPlugin(ctx context.Context, args pd.Args) (pd.Plugin, error) {
 
 	pluginToolType := args.PluginToolType
 
+	err := pd.ValidateThresholdWarnings(args.ThresholdWarnings)
+	if err != nil {
+		return nil, err
+	}
+
 	switch pluginToolType {
 	case pd.JacocoPluginType:
 		jcp := jc.GetNewJacocoPlugin()
+		jcp.SetContext(ctx)
 		return &jcp, nil
 	case pd.JacocoXmlPluginType:
 		jcxp := jc.GetNewJacocoXmlPlugin()
@@ -31,13 +40,84 @@ func GetNewPlugin(ctx context.Context, args pd.Args) (pd.Plugin, error) {
 	}
 }
 
+// CheckPackageRules fails on the package rules of the config file that are
+// not met, when failing on thresholds.
+func CheckPackageRules(p pd.Plugin, args pd.Args) error {
+	report := p.GetCoverageReport()
+	if report == nil || !args.PluginFailOnThreshold {
+		return nil
+	}
+	failedChecks := pd.GetFailedThresholdChecks(pd.ApplyThresholdSeverities(
+		pd.GetPackageRuleChecks(report, args.PackageRules), args.EnvPluginInputArgs))
+	if len(failedChecks) > 0 {
+		return pd.GetNewKindError(pd.ErrThreshold, fmt.Sprintf("%d package coverage rules not met", len(failedChecks)))
+	}
+	return nil
+}
+
+// GetModuleThresholdChecks checks the modules of the report against
+// threshold_module.
+func GetModuleThresholdChecks(report *coverage.Report, args pd.Args) []pd.ThresholdCheck {
+	return pd.ApplyThresholdSeverities(pd.GetModuleThresholdChecks(report, args.MinimumModuleCoverage),
+		args.EnvPluginInputArgs)
+}
+
+// CheckModuleThresholds fails on the modules below threshold_module, when
+// failing on thresholds.
+func CheckModuleThresholds(p pd.Plugin, args pd.Args) error {
+	report := p.GetCoverageReport()
+	if report == nil || !args.PluginFailOnThreshold {
+		return nil
+	}
+	failedChecks := pd.GetFailedThresholdChecks(GetModuleThresholdChecks(report, args))
+	if len(failedChecks) > 0 {
+		return pd.GetNewKindError(pd.ErrThreshold, fmt.Sprintf("%d module coverage thresholds not met", len(failedChecks)))
+	}
+	return nil
+}
+
+// CheckReportThresholds fails on the package rules, the module thresholds
+// and the ratchet floors not met, when failing on thresholds.
+func CheckReportThresholds(p pd.Plugin, args pd.Args) error {
+
+	err := CheckPackageRules(p, args)
+	if err != nil {
+		return err
+	}
+
+	err = CheckModuleThresholds(p, args)
+	if err != nil {
+		return err
+	}
+
+	return CheckRatchetFloors(p, args)
+}
+
+// Exec runs the plugin and writes its outcome to the COVERAGE_RESULT output
+// variable, whether it failed or not.
 func Exec(ctx context.Context, args pd.Args) (pd.Plugin, error) {
 
+	plugin, err := execPlugin(ctx, args)
+
+	resultErr := pd.WriteEnvVariableAsString(pd.CoverageResultOutputVariable, pd.GetCoverageResult(err))
+	if resultErr != nil {
+		logrus.Warnf("Error in writing %s: %s\n", pd.CoverageResultOutputVariable, resultErr.Error())
+	}
+	return plugin, err
+}
+
+func execPlugin(ctx context.Context, args pd.Args) (pd.Plugin, error) {
+
 	plugin, err := GetNewPlugin(ctx, args)
 	if err != nil {
 		return plugin, err
 	}
 
+	err = LoadRatchetFloors(&args, pd.GetTestWorkSpaceDir())
+	if err != nil {
+		return plugin, err
+	}
+
 	err = plugin.Init(&args)
 	if err != nil {
 		return plugin, err
@@ -59,7 +139,20 @@ func Exec(ctx context.Context, args pd.Args) (pd.Plugin, error) {
 		return plugin, err
 	}
 
-	err = plugin.Run()
+	// the outputs are written whether the checks pass or not, only passing
+	// builds raise the ratchet floors
+	checkErr := plugin.Run()
+	if checkErr == nil {
+		checkErr = CheckReportThresholds(plugin, args)
+	}
+
+	err = WriteReportOutputs(ctx, plugin, args, checkErr == nil)
+	if checkErr != nil {
+		if err != nil {
+			logrus.Warnf("Error in WriteReportOutputs: %s\n", err.Error())
+		}
+		return plugin, checkErr
+	}
 	if err != nil {
 		return plugin, err
 	}
@@ -77,5 +170,43 @@ func Exec(ctx context.Context, args pd.Args) (pd.Plugin, error) {
 	return plugin, nil
 }
 
+// RunPlugin parses the coverage report and checks the thresholds like Exec,
+// without writing any report outputs or output variables.
+func RunPlugin(ctx context.Context, args pd.Args) (pd.Plugin, error) {
+
+	plugin, err := GetNewPlugin(ctx, args)
+	if err != nil {
+		return plugin, err
+	}
+
+	err = LoadRatchetFloors(&args, pd.GetTestWorkSpaceDir())
+	if err != nil {
+		return plugin, err
+	}
+
+	err = plugin.Init(&args)
+	if err != nil {
+		return plugin, err
+	}
+	defer func(p pd.Plugin) {
+		err := p.DeInit()
+		if err != nil {
+			pd.LogPrintln(p, "Error in DeInit: "+err.Error())
+		}
+	}(plugin)
+
+	err = plugin.ValidateAndProcessArgs(args)
+	if err != nil {
+		return plugin, err
+	}
+
+	err = plugin.DoPostArgsValidationSetup(args)
+	if err != nil {
+		return plugin, err
+	}
+
+	return plugin, plugin.Run()
+}
+
 //
 //
@@ -0,0 +1,254 @@
+package plugin_defs
+
+import (
+	"fmt"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	DefaultConfigFileName = ".coverage-report.yml"
+	ConfigFileEnvVarKey   = "PLUGIN_CONFIG_FILE"
+	PackageRulesKey       = "package_rules"
+)
+
+// PackageRule sets minimum coverage percentages for the packages whose name
+// matches the pattern, in addition to the thresholds of the whole report.
+type PackageRule struct {
+	Pattern    string
+	Thresholds map[coverage.CounterType]float64
+}
+
+// packageRuleThresholdKeys are the threshold keys of a package rule, named
+// like the settings of the report thresholds.
+var packageRuleThresholdKeys = map[string]coverage.CounterType{
+	"threshold_instruction": coverage.InstructionCounter,
+	"threshold_branch":      coverage.BranchCounter,
+	"threshold_line":        coverage.LineCounter,
+	"threshold_method":      coverage.MethodCounter,
+	"threshold_class":       coverage.ClassCounter,
+}
+
+// ApplyConfigFile reads the settings of the config file into args. The file
+// is PLUGIN_CONFIG_FILE, relative to the workspace, or .coverage-report.yml
+// in the workspace when it exists. Settings given as PLUGIN_* env vars take
+// precedence over the file, and the file over the setting defaults.
+func ApplyConfigFile(args *Args, workSpaceDir string) error {
+
+	configPath := GetWorkSpaceRelativePath(workSpaceDir, args.ConfigFile)
+	if args.ConfigFile == "" {
+		configPath = GetWorkSpaceRelativePath(workSpaceDir, DefaultConfigFileName)
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			return nil
+		}
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return GetNewError("Error in ApplyConfigFile: " + err.Error())
+	}
+
+	err = ParseConfigFile(data, args, os.LookupEnv)
+	if err != nil {
+		return GetNewError(fmt.Sprintf("Error in config file %s: %s", configPath, err.Error()))
+	}
+
+	logrus.Printf("Settings read from config file %s\n", configPath)
+	return nil
+}
+
+// ParseConfigFile validates the yaml config and sets the settings it
+// contains in args, skipping those lookupEnv finds in the environment. The
+// package rules can only be set in the file.
+func ParseConfigFile(data []byte, args *Args, lookupEnv func(string) (string, bool)) error {
+
+	var document yaml.Node
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return err
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping of setting names to values", root.Line)
+	}
+
+	settings := map[string]Setting{}
+	for _, setting := range GetSettings(args) {
+		if setting.EnvVarKey != ConfigFileEnvVarKey {
+			settings[setting.Name] = setting
+		}
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		keyNode, valueNode := root.Content[i], root.Content[i+1]
+		key := keyNode.Value
+
+		if key == PackageRulesKey {
+			rules, err := parsePackageRules(valueNode)
+			if err != nil {
+				return err
+			}
+			args.PackageRules = rules
+			continue
+		}
+
+		setting, ok := settings[key]
+		if !ok {
+			return fmt.Errorf("line %d: unknown key %q", keyNode.Line, key)
+		}
+		value, err := getSettingValue(setting.Value.Type(), valueNode)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid value for key %q: %s", valueNode.Line, key, err.Error())
+		}
+		if key == "tool" && !IsKnownPluginType(value.String()) {
+			return fmt.Errorf("line %d: invalid value for key %q: unknown tool %s, expected one of %s",
+				valueNode.Line, key, value.String(), strings.Join(GetKnownPluginTypes(), ", "))
+		}
+
+		if _, ok := lookupEnv(setting.EnvVarKey); ok {
+			logrus.Debugf("Config file setting %s ignored, %s is set\n", key, setting.EnvVarKey)
+			continue
+		}
+		setting.Value.Set(value)
+	}
+
+	return nil
+}
+
+// getSettingValue converts the yaml value to the type of the setting. Lists
+// are accepted for the comma separated string settings.
+func getSettingValue(typ reflect.Type, node *yaml.Node) (reflect.Value, error) {
+
+	value := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		switch node.Kind {
+		case yaml.ScalarNode:
+			value.SetString(node.Value)
+		case yaml.SequenceNode:
+			var items []string
+			for _, item := range node.Content {
+				if item.Kind != yaml.ScalarNode {
+					return value, fmt.Errorf("expected a list of strings")
+				}
+				items = append(items, item.Value)
+			}
+			value.SetString(strings.Join(items, ","))
+		default:
+			return value, fmt.Errorf("expected a string or a list of strings")
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" || node.Decode(value.Addr().Interface()) != nil {
+			return value, fmt.Errorf("expected true or false")
+		}
+	case reflect.Int:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" || node.Decode(value.Addr().Interface()) != nil {
+			return value, fmt.Errorf("expected an integer")
+		}
+	case reflect.Float64:
+		if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") ||
+			node.Decode(value.Addr().Interface()) != nil {
+			return value, fmt.Errorf("expected a number")
+		}
+	default:
+		return value, fmt.Errorf("unsupported setting type %s", typ.Kind())
+	}
+	return value, nil
+}
+
+func parsePackageRules(node *yaml.Node) ([]PackageRule, error) {
+
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: invalid value for key %q: expected a list of rules",
+			node.Line, PackageRulesKey)
+	}
+
+	var rules []PackageRule
+	for i, ruleNode := range node.Content {
+		ruleKey := fmt.Sprintf("%s[%d]", PackageRulesKey, i)
+		if ruleNode.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: invalid value for key %q: expected a mapping", ruleNode.Line, ruleKey)
+		}
+
+		rule := PackageRule{Thresholds: map[coverage.CounterType]float64{}}
+		for j := 0; j+1 < len(ruleNode.Content); j += 2 {
+			keyNode, valueNode := ruleNode.Content[j], ruleNode.Content[j+1]
+			key := ruleKey + "." + keyNode.Value
+
+			if keyNode.Value == "pattern" {
+				if valueNode.Kind != yaml.ScalarNode || !doublestar.ValidatePattern(valueNode.Value) {
+					return nil, fmt.Errorf("line %d: invalid value for key %q: expected a glob pattern",
+						valueNode.Line, key)
+				}
+				rule.Pattern = valueNode.Value
+				continue
+			}
+
+			counterType, ok := packageRuleThresholdKeys[keyNode.Value]
+			if !ok {
+				return nil, fmt.Errorf("line %d: unknown key %q", keyNode.Line, key)
+			}
+			var threshold float64
+			value, err := getSettingValue(reflect.TypeOf(threshold), valueNode)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value for key %q: %s", valueNode.Line, key, err.Error())
+			}
+			threshold = value.Float()
+			if threshold < 0 || threshold > 100 {
+				return nil, fmt.Errorf("line %d: invalid value for key %q: expected a percentage between 0 and 100",
+					valueNode.Line, key)
+			}
+			rule.Thresholds[counterType] = threshold
+		}
+
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("line %d: missing key %q", ruleNode.Line, ruleKey+".pattern")
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// IsMatch tells whether the rule applies to the package. Package names
+// separated by dots are matched with slashes as well, so one pattern works
+// for both forms.
+func (r PackageRule) IsMatch(packageName string) bool {
+	if ok, _ := doublestar.Match(r.Pattern, packageName); ok {
+		return true
+	}
+	ok, _ := doublestar.Match(r.Pattern, strings.ReplaceAll(packageName, ".", "/"))
+	return ok
+}
+
+// GetPackageRuleChecks checks the packages of the report against the rules
+// matching them.
+func GetPackageRuleChecks(report *coverage.Report, rules []PackageRule) []ThresholdCheck {
+	var checks []ThresholdCheck
+	for _, pkg := range report.Packages {
+		for _, rule := range rules {
+			if !rule.IsMatch(pkg.Name) {
+				continue
+			}
+			for _, counterType := range coverage.AllCounterTypes {
+				threshold, ok := rule.Thresholds[counterType]
+				if !ok || !pkg.Counters.Has(counterType) {
+					continue
+				}
+				check := GetMinimumThresholdCheck(fmt.Sprintf("%s (%s)", counterType.DisplayName(), pkg.Name),
+					counterType, pkg.Counters.Get(counterType).Percentage(), threshold, true)
+				check.Package = pkg.Name
+				checks = append(checks, check)
+			}
+		}
+	}
+	return checks
+}
@@ -1,5 +1,9 @@
 package plugin_defs
 
+import (
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+)
+
 type Plugin interface {
 	Init(args *Args) error
 	SetBuildRoot(buildRootPath string) error
@@ -12,13 +16,17 @@ type Plugin interface {
 	GetPluginType() string
 	IsQuiet() bool
 	InspectProcessArgs(argNamesList []string) (map[string]interface{}, error)
+	GetCoverageReport() *coverage.Report
+	GetThresholdChecks() []ThresholdCheck
 }
 
 type Args struct {
 	Pipeline
 	CoveragePluginArgs
 	EnvPluginInputArgs
-	Level string `envconfig:"PLUGIN_LOG_LEVEL"`
+	Level      string `envconfig:"PLUGIN_LOG_LEVEL"`
+	LogFormat  string `envconfig:"PLUGIN_LOG_FORMAT"`
+	ConfigFile string `envconfig:"PLUGIN_CONFIG_FILE"`
 }
 
 type CoveragePluginArgs struct {
@@ -40,6 +48,14 @@ type EnvPluginInputArgs struct {
 
 	SkipCopyOfSrcFiles bool `envconfig:"PLUGIN_SKIP_SOURCE_COPY"`
 
+	// Exec files merged before JaCoCo runs, only for JaCoCo
+	MergeExecFiles bool   `envconfig:"PLUGIN_MERGE_EXEC_FILES"`
+	MergedExecPath string `envconfig:"PLUGIN_MERGED_EXEC_PATH"`
+
+	// Class files checked against the exec data, only for JaCoCo
+	WarnOnExecDataMismatch bool `envconfig:"PLUGIN_WARN_ON_EXEC_DATA_MISMATCH"`
+	FailOnExecDataMismatch bool `envconfig:"PLUGIN_FAIL_ON_EXEC_DATA_MISMATCH"`
+
 	MinimumInstructionCoverage float64 `envconfig:"PLUGIN_THRESHOLD_INSTRUCTION"`
 	MinimumBranchCoverage      float64 `envconfig:"PLUGIN_THRESHOLD_BRANCH"`
 	MinimumComplexityCoverage  int     `envconfig:"PLUGIN_THRESHOLD_COMPLEXITY"`
@@ -52,6 +68,73 @@ type EnvPluginInputArgs struct {
 	MinimumFileCoverage          float64 `envconfig:"PLUGIN_THRESHOLD_FILE"`
 	MinimumLOC                   int     `envconfig:"PLUGIN_THRESHOLD_LOC"`
 	MaxComplexityDensityCoverage float64 `envconfig:"PLUGIN_THRESHOLD_COMPLEXITY_DENSITY"`
+
+	// Module only for reports with modules, like JaCoCo aggregate reports
+	MinimumModuleCoverage float64 `envconfig:"PLUGIN_THRESHOLD_MODULE"`
+
+	ThresholdWarnings string `envconfig:"PLUGIN_THRESHOLD_WARNINGS"`
+	SoftFail          bool   `envconfig:"PLUGIN_SOFT_FAIL"`
+
+	HtmlReportDir   string `envconfig:"PLUGIN_HTML_REPORT_DIR"`
+	HtmlReportTitle string `envconfig:"PLUGIN_HTML_REPORT_TITLE"`
+
+	BadgeDir           string  `envconfig:"PLUGIN_BADGE_DIR"`
+	BadgeColorBands    string  `envconfig:"PLUGIN_BADGE_COLOR_BANDS"`
+	BadgeWarningMargin float64 `envconfig:"PLUGIN_BADGE_WARNING_MARGIN" default:"10"`
+
+	SummaryJsonPath         string `envconfig:"PLUGIN_SUMMARY_JSON_PATH"`
+	BaselineSummaryJsonPath string `envconfig:"PLUGIN_BASELINE_SUMMARY_PATH"`
+
+	MarkdownPath           string `envconfig:"PLUGIN_MARKDOWN_PATH"`
+	MarkdownOutputVariable bool   `envconfig:"PLUGIN_MARKDOWN_OUTPUT_VARIABLE"`
+	MarkdownTopFiles       int    `envconfig:"PLUGIN_MARKDOWN_TOP_FILES" default:"5"`
+
+	PullRequestComment       bool   `envconfig:"PLUGIN_PR_COMMENT"`
+	PullRequestCommentMarker string `envconfig:"PLUGIN_PR_COMMENT_MARKER"`
+	ScmProvider              string `envconfig:"PLUGIN_SCM_PROVIDER"`
+	ScmBaseUrl               string `envconfig:"PLUGIN_SCM_BASE_URL"`
+	ScmToken                 string `envconfig:"PLUGIN_SCM_TOKEN"`
+
+	CommitStatus        bool   `envconfig:"PLUGIN_COMMIT_STATUS"`
+	CommitStatusContext string `envconfig:"PLUGIN_COMMIT_STATUS_CONTEXT" default:"coverage"`
+	CommitStatusMetrics string `envconfig:"PLUGIN_COMMIT_STATUS_METRICS" default:"line"`
+	DiffBase            string `envconfig:"PLUGIN_DIFF_BASE"`
+	DiffFile            string `envconfig:"PLUGIN_DIFF_FILE"`
+
+	CodecovPath       string `envconfig:"PLUGIN_CODECOV_PATH"`
+	CodecovUrl        string `envconfig:"PLUGIN_CODECOV_URL"`
+	CodecovToken      string `envconfig:"PLUGIN_CODECOV_TOKEN"`
+	SonarCoveragePath string `envconfig:"PLUGIN_SONAR_COVERAGE_PATH"`
+	SonarUploadUrl    string `envconfig:"PLUGIN_SONAR_UPLOAD_URL"`
+	SonarToken        string `envconfig:"PLUGIN_SONAR_TOKEN"`
+
+	ConvertFormat     string `envconfig:"PLUGIN_CONVERT_FORMAT"`
+	ConvertOutputPath string `envconfig:"PLUGIN_CONVERT_OUTPUT_PATH"`
+
+	SarifPath             string `envconfig:"PLUGIN_SARIF_PATH"`
+	SarifChangedLinesOnly bool   `envconfig:"PLUGIN_SARIF_CHANGED_LINES_ONLY"`
+
+	JunitReportPath string `envconfig:"PLUGIN_JUNIT_REPORT_PATH"`
+
+	HistoryPath   string `envconfig:"PLUGIN_HISTORY_PATH"`
+	HistoryBuilds int    `envconfig:"PLUGIN_HISTORY_BUILDS" default:"10"`
+
+	Ratchet        bool    `envconfig:"PLUGIN_RATCHET"`
+	RatchetPath    string  `envconfig:"PLUGIN_RATCHET_PATH"`
+	RatchetSlack   float64 `envconfig:"PLUGIN_RATCHET_SLACK" default:"0.5"`
+	RatchetMetrics string  `envconfig:"PLUGIN_RATCHET_METRICS" default:"line,branch"`
+
+	PathMappings string `envconfig:"PLUGIN_PATH_MAPPINGS"`
+
+	SkipExclusionMarkers bool `envconfig:"PLUGIN_SKIP_EXCLUSION_MARKERS"`
+
+	UncoveredFilesLimit int `envconfig:"PLUGIN_UNCOVERED_FILES_LIMIT" default:"20"`
+
+	// Only set from the package_rules of the config file
+	PackageRules []PackageRule `ignored:"true"`
+
+	// Only set from the ratchet file or history store when ratchet is enabled
+	RatchetFloors map[coverage.CounterType]float64 `ignored:"true"`
 }
 
 type PluginOutputVariables struct {
@@ -68,3 +151,16 @@ const (
 	JacocoXmlPluginType = "jacoco-xml"
 	CoberturaPluginType = "cobertura"
 )
+
+func GetKnownPluginTypes() []string {
+	return []string{JacocoPluginType, JacocoXmlPluginType, CoberturaPluginType}
+}
+
+func IsKnownPluginType(pluginType string) bool {
+	for _, knownType := range GetKnownPluginTypes() {
+		if pluginType == knownType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package plugin_defs
+
+import (
+	"errors"
+)
+
+// The kinds of failure a pipeline may want to handle differently. Errors of
+// these kinds unwrap to them, so errors.Is tells them apart. Other errors,
+// like invalid settings, mean the step is broken in some other way.
+var (
+	ErrNoReports        = errors.New("no coverage reports found")
+	ErrParse            = errors.New("coverage report cannot be parsed")
+	ErrToolExecution    = errors.New("coverage tool failed")
+	ErrThreshold        = errors.New("coverage thresholds not met")
+	ErrExecDataMismatch = errors.New("execution data does not match the class files")
+)
+
+// KindError is an error of one of the failure kinds, keeping the message of
+// the place it happened.
+type KindError struct {
+	Kind    error
+	Message string
+}
+
+func (e *KindError) Error() string {
+	return e.Message
+}
+
+func (e *KindError) Unwrap() error {
+	return e.Kind
+}
+
+// GetNewKindError returns an error of the kind, like GetNewError.
+func GetNewKindError(kind error, s string) error {
+	return &KindError{Kind: kind, Message: s}
+}
+
+const (
+	ExitCodeOk               = 0
+	ExitCodeError            = 1
+	ExitCodeUsage            = 2
+	ExitCodeThresholdsNotMet = 3
+	ExitCodeNoReports        = 4
+	ExitCodeParseError       = 5
+	ExitCodeToolError        = 6
+	ExitCodeExecDataMismatch = 7
+)
+
+const (
+	CoverageResultOutputVariable = "COVERAGE_RESULT"
+
+	ResultPassed           = "passed"
+	ResultThresholdsNotMet = "thresholds_not_met"
+	ResultNoReports        = "no_reports"
+	ResultParseError       = "parse_error"
+	ResultToolError        = "tool_error"
+	ResultExecDataMismatch = "exec_data_mismatch"
+	ResultError            = "error"
+)
+
+var errorKinds = []struct {
+	kind     error
+	exitCode int
+	result   string
+}{
+	{ErrThreshold, ExitCodeThresholdsNotMet, ResultThresholdsNotMet},
+	{ErrNoReports, ExitCodeNoReports, ResultNoReports},
+	{ErrParse, ExitCodeParseError, ResultParseError},
+	{ErrToolExecution, ExitCodeToolError, ResultToolError},
+	{ErrExecDataMismatch, ExitCodeExecDataMismatch, ResultExecDataMismatch},
+}
+
+// GetExitCode returns the exit code of the binary for the error of a run.
+func GetExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOk
+	}
+	for _, errorKind := range errorKinds {
+		if errors.Is(err, errorKind.kind) {
+			return errorKind.exitCode
+		}
+	}
+	return ExitCodeError
+}
+
+// GetCoverageResult returns the value of the COVERAGE_RESULT output
+// variable for the error of a run.
+func GetCoverageResult(err error) string {
+	if err == nil {
+		return ResultPassed
+	}
+	for _, errorKind := range errorKinds {
+		if errors.Is(err, errorKind.kind) {
+			return errorKind.result
+		}
+	}
+	return ResultError
+}
@@ -0,0 +1,427 @@
+package plugin_defs
+
+import (
+	"context"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/sirupsen/logrus"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultFileWorkers is the number of directories read, or files copied, at
+// the same time.
+const DefaultFileWorkers = 16
+
+// FilterFileOrDirUsingGlobPatterns finds, in every directory matching one of
+// the directory patterns, the files matching the include and the exclude
+// patterns, which are relative to that directory. The root dir is walked
+// once for all the patterns, up to DefaultFileWorkers directories at a time,
+// skipping the directories no pattern can match below. Like doublestar.Glob,
+// symlinked directories are followed, except those leading back to a
+// directory being walked. The first directory that cannot be read stops the
+// walk with its error.
+func FilterFileOrDirUsingGlobPatterns(ctx context.Context, rootSearchDir string, dirsGlobList []string,
+	includeGlobPatternCsvStr, excludeGlobPatternCsvStr string, autoFillIncludePattern string) ([]FilesInfoStore, error) {
+
+	if len(includeGlobPatternCsvStr) == 0 {
+		if len(autoFillIncludePattern) > 0 {
+			includeGlobPatternCsvStr = autoFillIncludePattern
+		}
+	}
+
+	walker, err := getNewFileWalker(rootSearchDir, dirsGlobList, ToStringArrayFromCsvString(includeGlobPatternCsvStr),
+		ToStringArrayFromCsvString(excludeGlobPatternCsvStr))
+	if err != nil {
+		return nil, err
+	}
+
+	err = walker.walk(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return walker.getFilesInfoStores(), nil
+}
+
+// fileWalker walks the root dir once, matching every directory against the
+// directory patterns and every file below a matched directory, a base,
+// against the include and exclude patterns.
+type fileWalker struct {
+	rootDir     string
+	dirPatterns []fileWalkPattern
+	includes    []string
+	excludes    []string
+
+	workers chan struct{}
+	mutex   sync.Mutex
+	bases   []*fileWalkBase
+	err     error
+	cancel  context.CancelFunc
+}
+
+type fileWalkPattern struct {
+	pattern string
+	// the leading directories of the pattern without glob meta characters,
+	// below which the walk can be skipped when they differ
+	literalDirs []string
+	isLiteral   bool
+}
+
+type fileWalkBase struct {
+	relPath        string
+	completePath   string
+	patternIndexes []int
+	includes       []string
+	excludes       []string
+	included       []string
+	excluded       []string
+}
+
+func getNewFileWalker(rootDir string, dirPatterns, includes, excludes []string) (*fileWalker, error) {
+
+	w := &fileWalker{rootDir: rootDir, workers: make(chan struct{}, DefaultFileWorkers)}
+
+	for _, dirPattern := range dirPatterns {
+		relPattern := strings.TrimPrefix(dirPattern, rootDir+"/")
+		if relPattern == "" {
+			continue
+		}
+		if !doublestar.ValidatePattern(relPattern) {
+			return nil, doublestar.ErrBadPattern
+		}
+		w.dirPatterns = append(w.dirPatterns, getFileWalkPattern(relPattern))
+	}
+
+	for _, include := range includes {
+		if include == "" {
+			continue
+		}
+		if !doublestar.ValidatePattern(include) {
+			return nil, doublestar.ErrBadPattern
+		}
+		w.includes = append(w.includes, include)
+	}
+
+	for _, exclude := range excludes {
+		if exclude == "" {
+			continue
+		}
+		if !doublestar.ValidatePattern(exclude) {
+			LogPrintln(nil, "Error in exclude pattern: ", exclude, doublestar.ErrBadPattern.Error())
+			continue
+		}
+		w.excludes = append(w.excludes, exclude)
+	}
+
+	return w, nil
+}
+
+func getFileWalkPattern(pattern string) fileWalkPattern {
+	walkPattern := fileWalkPattern{pattern: pattern, isLiteral: true}
+	for _, dir := range strings.Split(pattern, "/") {
+		if strings.ContainsAny(dir, `*?[{\`) {
+			walkPattern.isLiteral = false
+			break
+		}
+		walkPattern.literalDirs = append(walkPattern.literalDirs, dir)
+	}
+	return walkPattern
+}
+
+func (w *fileWalker) walk(ctx context.Context) error {
+
+	var bases []*fileWalkBase
+	if base := w.getBase(""); base != nil {
+		bases = append(bases, base)
+	}
+
+	walkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w.cancel = cancel
+
+	var wg sync.WaitGroup
+	w.walkDir(walkCtx, &wg, "", bases, nil)
+	wg.Wait()
+
+	if w.err != nil {
+		return w.err
+	}
+	return ctx.Err()
+}
+
+// setError keeps the first error of the walk and stops it.
+func (w *fileWalker) setError(err error) {
+	w.mutex.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mutex.Unlock()
+	w.cancel()
+}
+
+// walkDir reads the directory, relative to the root dir, in a new goroutine
+// when a worker is free, else in the current one. followed holds the real
+// paths of the symlinked directories the walk went through to get there.
+func (w *fileWalker) walkDir(ctx context.Context, wg *sync.WaitGroup, relDir string, bases []*fileWalkBase,
+	followed []string) {
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	entries, err := os.ReadDir(filepath.Join(w.rootDir, filepath.FromSlash(relDir)))
+	if err != nil {
+		w.setError(err)
+		return
+	}
+
+	for _, entry := range entries {
+		relPath := path.Join(relDir, entry.Name())
+
+		isDir := entry.IsDir()
+		childFollowed := followed
+		if entry.Type()&fs.ModeSymlink != 0 {
+			completePath := filepath.Join(w.rootDir, filepath.FromSlash(relPath))
+			info, err := os.Stat(completePath)
+			if err != nil {
+				continue
+			}
+			isDir = info.IsDir()
+			if isDir {
+				realPath, err := filepath.EvalSymlinks(completePath)
+				if err != nil || w.isWalkCycle(relDir, realPath, followed) {
+					continue
+				}
+				childFollowed = append(followed[:len(followed):len(followed)], realPath)
+			}
+		}
+
+		if !isDir {
+			w.matchFile(relPath, bases)
+			continue
+		}
+
+		childBases := bases
+		if base := w.getBase(relPath); base != nil {
+			childBases = append(bases[:len(bases):len(bases)], base)
+		}
+		if len(childBases) == 0 && !w.canMatchBelow(relPath) {
+			continue
+		}
+
+		select {
+		case w.workers <- struct{}{}:
+			wg.Add(1)
+			go func(relPath string, bases []*fileWalkBase, followed []string) {
+				defer wg.Done()
+				defer func() { <-w.workers }()
+				w.walkDir(ctx, wg, relPath, bases, followed)
+			}(relPath, childBases, childFollowed)
+		default:
+			w.walkDir(ctx, wg, relPath, childBases, childFollowed)
+		}
+	}
+}
+
+// isWalkCycle tells whether a symlinked directory found in relDir leads to a
+// directory already followed, or to relDir itself or one of its parents.
+func (w *fileWalker) isWalkCycle(relDir, realPath string, followed []string) bool {
+	for _, followedPath := range followed {
+		if followedPath == realPath {
+			return true
+		}
+	}
+
+	realDir, err := filepath.EvalSymlinks(filepath.Join(w.rootDir, filepath.FromSlash(relDir)))
+	if err != nil {
+		return true
+	}
+	relPath, err := filepath.Rel(realPath, realDir)
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+// getBase returns a new base when the directory matches directory patterns,
+// the root dir being the empty path.
+func (w *fileWalker) getBase(relDir string) *fileWalkBase {
+
+	name := relDir
+	if name == "" {
+		name = "."
+	}
+
+	var patternIndexes []int
+	for i, dirPattern := range w.dirPatterns {
+		if doublestar.MatchUnvalidated(dirPattern.pattern, name) {
+			patternIndexes = append(patternIndexes, i)
+		}
+	}
+	if len(patternIndexes) == 0 {
+		return nil
+	}
+
+	completePath := filepath.Join(w.rootDir, relDir)
+	base := &fileWalkBase{relPath: relDir, completePath: completePath, patternIndexes: patternIndexes}
+	for _, include := range w.includes {
+		base.includes = append(base.includes, strings.TrimPrefix(include, completePath+"/"))
+	}
+	for _, exclude := range w.excludes {
+		base.excludes = append(base.excludes, strings.TrimPrefix(exclude, completePath+"/"))
+	}
+
+	w.mutex.Lock()
+	w.bases = append(w.bases, base)
+	w.mutex.Unlock()
+	return base
+}
+
+// canMatchBelow tells whether a directory pattern may match a directory
+// below relDir, which is outside of any base.
+func (w *fileWalker) canMatchBelow(relDir string) bool {
+	dirs := strings.Split(relDir, "/")
+	for _, dirPattern := range w.dirPatterns {
+		matched := true
+		for i, dir := range dirs {
+			if i >= len(dirPattern.literalDirs) {
+				matched = !dirPattern.isLiteral
+				break
+			}
+			if dir != dirPattern.literalDirs[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *fileWalker) matchFile(relPath string, bases []*fileWalkBase) {
+	for _, base := range bases {
+		baseRelPath := relPath
+		if base.relPath != "" {
+			baseRelPath = strings.TrimPrefix(relPath, base.relPath+"/")
+		}
+		isIncluded := matchAny(base.includes, baseRelPath)
+		isExcluded := matchAny(base.excludes, baseRelPath)
+		if !isIncluded && !isExcluded {
+			continue
+		}
+
+		w.mutex.Lock()
+		if isIncluded {
+			base.included = append(base.included, baseRelPath)
+		}
+		if isExcluded {
+			base.excluded = append(base.excluded, baseRelPath)
+		}
+		w.mutex.Unlock()
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if doublestar.MatchUnvalidated(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// getFilesInfoStores returns the files of every base, by directory pattern
+// then directory path, sorted like a glob of each pattern would.
+func (w *fileWalker) getFilesInfoStores() []FilesInfoStore {
+
+	sort.Slice(w.bases, func(i, j int) bool {
+		return comparePaths(w.bases[i].relPath, w.bases[j].relPath)
+	})
+
+	var filesStoreList []FilesInfoStore
+	for i := range w.dirPatterns {
+		for _, base := range w.bases {
+			if !containsInt(base.patternIndexes, i) {
+				continue
+			}
+			filesStoreList = append(filesStoreList, FilesInfoStore{
+				IncludedPathsListWithPrefix: base.getPathsWithPrefix(base.included),
+				ExcludedPathsListWithPrefix: base.getPathsWithPrefix(base.excluded),
+			})
+		}
+	}
+	return filesStoreList
+}
+
+func (b *fileWalkBase) getPathsWithPrefix(relPaths []string) []PathWithPrefix {
+	sort.Slice(relPaths, func(i, j int) bool {
+		return comparePaths(relPaths[i], relPaths[j])
+	})
+
+	var pathsWithPrefix []PathWithPrefix
+	for _, relPath := range relPaths {
+		pathsWithPrefix = append(pathsWithPrefix, PathWithPrefix{CompletePathPrefix: b.completePath, RelativePath: relPath})
+	}
+	return pathsWithPrefix
+}
+
+// comparePaths orders paths directory by directory, the files of a
+// directory before those of a sibling directory with a longer name.
+func comparePaths(a, b string) bool {
+	return strings.ReplaceAll(a, "/", "\x00") < strings.ReplaceAll(b, "/", "\x00")
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FileCopy is a file to copy to another path.
+type FileCopy struct {
+	SrcPath string
+	DstPath string
+}
+
+// CopyFiles copies the files with DefaultFileWorkers workers. The files that
+// cannot be copied are logged as warnings and skipped; copying stops when
+// ctx is done, returning its error.
+func CopyFiles(ctx context.Context, fileCopies []FileCopy) error {
+
+	workers := DefaultFileWorkers
+	if len(fileCopies) < workers {
+		workers = len(fileCopies)
+	}
+
+	jobs := make(chan FileCopy)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fileCopy := range jobs {
+				err := CopyFile(fileCopy.SrcPath, fileCopy.DstPath)
+				if err != nil {
+					logrus.Warnf("Error in copying file: %s\n", err.Error())
+				}
+			}
+		}()
+	}
+
+	defer wg.Wait()
+	defer close(jobs)
+	for _, fileCopy := range fileCopies {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case jobs <- fileCopy:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package plugin_defs
+
+import (
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"strings"
+)
+
+const (
+	TextLogFormat = "text"
+	JsonLogFormat = "json"
+)
+
+// SetupLogging configures logrus from the PLUGIN_LOG_LEVEL and
+// PLUGIN_LOG_FORMAT settings. Info level logs are written as plain messages,
+// debug and trace logs carry their level and json logs carry their fields.
+func SetupLogging(level, format string) error {
+
+	logLevel := logrus.InfoLevel
+	if level == "" && IsDevTestingMode() {
+		logLevel = logrus.DebugLevel
+	} else if level != "" {
+		var err error
+		logLevel, err = logrus.ParseLevel(strings.ToLower(level))
+		if err != nil {
+			return GetNewError("Error in SetupLogging: invalid log level " + level)
+		}
+	}
+
+	var formatter logrus.Formatter
+	switch strings.ToLower(format) {
+	case "", TextLogFormat:
+		formatter = &messageFormatter{}
+		if logLevel >= logrus.DebugLevel {
+			formatter = &trimmedFormatter{&logrus.TextFormatter{DisableTimestamp: true}}
+		}
+	case JsonLogFormat:
+		formatter = &trimmedFormatter{&logrus.JSONFormatter{}}
+	default:
+		return GetNewError("Error in SetupLogging: invalid log format " + format +
+			", expected " + TextLogFormat + " or " + JsonLogFormat)
+	}
+
+	logrus.SetLevel(logLevel)
+	logrus.SetFormatter(formatter)
+	return nil
+}
+
+// LogCoverageSummary writes the coverage of the run and the verdict of its
+// threshold checks at info level. Json logs get the numbers as fields too.
+func LogCoverageSummary(summary CoverageSummary) {
+
+	failedChecks := GetFailedThresholdChecks(summary.ThresholdChecks)
+	warningChecks := GetWarningThresholdChecks(summary.ThresholdChecks)
+	fields := logrus.Fields{
+		"tool":          summary.Tool,
+		"passed":        summary.Passed,
+		"failedChecks":  len(failedChecks),
+		"warningChecks": len(warningChecks),
+	}
+
+	lines := []string{fmt.Sprintf("Coverage summary (%s):", summary.Tool)}
+	for _, counterType := range coverage.AllCounterTypes {
+		if !summary.Counters.Has(counterType) {
+			continue
+		}
+		counter := summary.Counters.Get(counterType)
+		lines = append(lines, fmt.Sprintf("  %-12s %7.2f%% (%d/%d)", counterType.DisplayName(),
+			counter.Percentage(), counter.Covered, counter.Total()))
+		fields[strings.ToLower(string(counterType))+"Coverage"] = counter.Percentage()
+	}
+	if len(summary.Modules) > 0 {
+		lines = append(lines, "Line coverage by module:")
+		for _, module := range summary.Modules {
+			counter := module.Counters.Get(coverage.LineCounter)
+			lines = append(lines, fmt.Sprintf("  %-24s %7.2f%% (%d/%d)", module.Name, counter.Percentage(),
+				counter.Covered, counter.Total()))
+		}
+	}
+
+	switch {
+	case len(summary.ThresholdChecks) == 0:
+		lines = append(lines, "No coverage thresholds checked")
+	case len(failedChecks) == 0 && len(warningChecks) == 0:
+		lines = append(lines, fmt.Sprintf("All %d coverage thresholds met", len(summary.ThresholdChecks)))
+	case len(failedChecks) > 0:
+		lines = append(lines, fmt.Sprintf("%d of %d coverage thresholds not met:",
+			len(failedChecks), len(summary.ThresholdChecks)))
+		for _, check := range failedChecks {
+			lines = append(lines, "  "+check.String())
+		}
+	}
+	if len(warningChecks) > 0 {
+		lines = append(lines, fmt.Sprintf("%d coverage threshold warnings:", len(warningChecks)))
+		for _, check := range warningChecks {
+			lines = append(lines, "  "+check.String())
+		}
+	}
+
+	logrus.WithFields(fields).Info(strings.Join(lines, "\n"))
+}
+
+// LogUncoveredFiles writes the uncovered lines of up to limit files, those
+// with the most missed lines first. They are logged at info level when a
+// threshold is not met, and at debug level otherwise.
+func LogUncoveredFiles(summary CoverageSummary, limit int) {
+
+	if len(summary.UncoveredFiles) == 0 {
+		return
+	}
+	level := logrus.DebugLevel
+	if !summary.Passed || summary.Warnings > 0 {
+		level = logrus.InfoLevel
+	}
+	if !logrus.IsLevelEnabled(level) {
+		return
+	}
+
+	files := append([]UncoveredFile{}, summary.UncoveredFiles...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].MissedLines > files[j].MissedLines
+	})
+
+	lines := []string{"Uncovered lines:"}
+	for i, file := range files {
+		if limit > 0 && i == limit {
+			lines = append(lines, fmt.Sprintf("  ... and %d more files", len(files)-limit))
+			break
+		}
+		lines = append(lines, "  "+file.String())
+	}
+	logrus.StandardLogger().Log(level, strings.Join(lines, "\n"))
+}
+
+// messageFormatter writes the message alone, without timestamp or level.
+type messageFormatter struct{}
+
+func (*messageFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if strings.HasSuffix(entry.Message, "\n") {
+		return []byte(entry.Message), nil
+	}
+	return []byte(entry.Message + "\n"), nil
+}
+
+// trimmedFormatter drops the trailing newline most plugin messages end with,
+// as formatters that write the level or fields add their own.
+type trimmedFormatter struct {
+	logrus.Formatter
+}
+
+func (t *trimmedFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	trimmed := *entry
+	trimmed.Message = strings.TrimSuffix(entry.Message, "\n")
+	return t.Formatter.Format(&trimmed)
+}
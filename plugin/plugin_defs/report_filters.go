@@ -0,0 +1,25 @@
+package plugin_defs
+
+import (
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+)
+
+// GetReportFilters returns the filters the class and source inclusion and
+// exclusion patterns apply to the classes and source files of a parsed
+// report.
+func GetReportFilters(args Args) (*coverage.PathFilter, *coverage.PathFilter, error) {
+
+	classFilter, err := coverage.GetNewPathFilter(ToStringArrayFromCsvString(args.ClassInclusionPatterns),
+		ToStringArrayFromCsvString(args.ClassExclusionPatterns))
+	if err != nil {
+		return nil, nil, GetNewError("Error in class_inclusion_pattern or class_exclusion_pattern: " + err.Error())
+	}
+
+	sourceFilter, err := coverage.GetNewPathFilter(ToStringArrayFromCsvString(args.SourceInclusionPattern),
+		ToStringArrayFromCsvString(args.SourceExclusionPattern))
+	if err != nil {
+		return nil, nil, GetNewError("Error in source_inclusion_pattern or source_exclusion_pattern: " + err.Error())
+	}
+
+	return classFilter, sourceFilter, nil
+}
@@ -0,0 +1,79 @@
+package plugin_defs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const settingEnvVarPrefix = "PLUGIN_"
+
+// Setting is a PLUGIN_* setting of the plugin, named like in the step
+// settings: the env var name without the PLUGIN_ prefix, in lower case.
+type Setting struct {
+	Name      string
+	EnvVarKey string
+	Value     reflect.Value
+}
+
+// GetSettings returns the settings of args in the order they are declared.
+func GetSettings(args *Args) []Setting {
+	var settings []Setting
+	getSettings(reflect.ValueOf(args).Elem(), &settings)
+	return settings
+}
+
+func getSettings(val reflect.Value, settings *[]Setting) {
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			getSettings(val.Field(i), settings)
+			continue
+		}
+		envVarKey := field.Tag.Get("envconfig")
+		if !strings.HasPrefix(envVarKey, settingEnvVarPrefix) {
+			continue
+		}
+		*settings = append(*settings, Setting{
+			Name:      strings.ToLower(strings.TrimPrefix(envVarKey, settingEnvVarPrefix)),
+			EnvVarKey: envVarKey,
+			Value:     val.Field(i),
+		})
+	}
+}
+
+// SetString sets the setting from its text form, as given in an env var.
+func (s Setting) SetString(value string) error {
+	switch s.Value.Kind() {
+	case reflect.String:
+		s.Value.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s, expected true or false", value, s.Name)
+		}
+		s.Value.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s, expected an integer", value, s.Name)
+		}
+		s.Value.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s, expected a number", value, s.Name)
+		}
+		s.Value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s of %s", s.Value.Kind(), s.Name)
+	}
+	return nil
+}
+
+// String returns the text form of the setting value.
+func (s Setting) String() string {
+	return fmt.Sprintf("%v", s.Value.Interface())
+}
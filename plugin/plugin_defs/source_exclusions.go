@@ -0,0 +1,67 @@
+package plugin_defs
+
+import (
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"path/filepath"
+)
+
+// GetPathMapper returns the mapper finding report files in the workspace
+// with the configured path mappings. When source directories are set, only
+// they are searched for files matching by suffix.
+func GetPathMapper(args Args, workSpaceDir string) (*coverage.PathMapper, error) {
+
+	mappings, err := coverage.ParsePathMappings(ToStringArrayFromCsvString(args.PathMappings))
+	if err != nil {
+		return nil, err
+	}
+
+	completeWorkSpaceDir, err := filepath.Abs(workSpaceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	locator, err := coverage.GetNewSourceLocator(completeWorkSpaceDir, ToStringArrayFromCsvString(args.SourcePattern), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := coverage.GetNewPathMapper(completeWorkSpaceDir, mappings)
+	mapper.SearchDirs = locator.Roots
+	return mapper, nil
+}
+
+// GetSourceExclusions reads the exclusion markers of the source files of
+// the report, and returns the excluded line numbers by report file path.
+// Files whose source is not found keep all their lines.
+func GetSourceExclusions(report *coverage.Report, args Args, workSpaceDir string) (map[string]map[int]bool, error) {
+
+	mapper, err := GetPathMapper(args, workSpaceDir)
+	if err != nil {
+		return nil, err
+	}
+	_, err = mapper.Apply(report)
+	if err != nil {
+		return nil, err
+	}
+
+	locator, err := coverage.GetNewSourceLocator(mapper.WorkSpaceDir,
+		ToStringArrayFromCsvString(args.SourcePattern), report.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := map[string]map[int]bool{}
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			sourceLines, found := locator.ReadSourceLines(file)
+			if !found {
+				continue
+			}
+			excludedLines := coverage.GetExcludedLines(sourceLines)
+			if len(excludedLines) > 0 {
+				exclusions[file.Path] = excludedLines
+			}
+		}
+	}
+	return exclusions, nil
+}
@@ -0,0 +1,189 @@
+package plugin_defs
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CoverageSummary is the JSON summary of a run. A summary written by an
+// earlier run on the target branch serves as the baseline for deltas.
+type CoverageSummary struct {
+	Tool            string            `json:"tool"`
+	Repo            string            `json:"repo,omitempty"`
+	Branch          string            `json:"branch,omitempty"`
+	Commit          string            `json:"commit,omitempty"`
+	BuildNumber     int               `json:"buildNumber,omitempty"`
+	Counters        coverage.Counters `json:"counters"`
+	Packages        []PackageSummary  `json:"packages"`
+	Modules         []ModuleSummary   `json:"modules,omitempty"`
+	ThresholdChecks []ThresholdCheck  `json:"thresholdChecks"`
+	Passed          bool              `json:"passed"`
+	Warnings        int               `json:"warnings"`
+
+	RatchetFloors map[coverage.CounterType]float64 `json:"ratchetFloors,omitempty"`
+
+	UncoveredFiles []UncoveredFile `json:"uncoveredFiles,omitempty"`
+}
+
+type PackageSummary struct {
+	Name     string            `json:"name"`
+	Counters coverage.Counters `json:"counters"`
+}
+
+type ModuleSummary struct {
+	Name     string            `json:"name"`
+	Counters coverage.Counters `json:"counters"`
+}
+
+func GetCoverageSummary(report *coverage.Report, thresholdChecks []ThresholdCheck, pipeline Pipeline) CoverageSummary {
+
+	summary := CoverageSummary{
+		Tool:            report.Tool,
+		Repo:            pipeline.Repo.Slug,
+		Branch:          pipeline.Commit.Branch,
+		Commit:          pipeline.Commit.Rev,
+		BuildNumber:     pipeline.Build.Number,
+		Counters:        report.Counters,
+		ThresholdChecks: thresholdChecks,
+		Passed:          IsAllThresholdChecksPassed(thresholdChecks),
+		Warnings:        len(GetWarningThresholdChecks(thresholdChecks)),
+	}
+
+	for _, pkg := range report.Packages {
+		summary.Packages = append(summary.Packages, PackageSummary{Name: pkg.Name, Counters: pkg.Counters})
+	}
+	for _, module := range report.GetModules() {
+		summary.Modules = append(summary.Modules, ModuleSummary{Name: module.Name, Counters: module.Counters})
+	}
+	summary.UncoveredFiles = GetUncoveredFiles(report)
+
+	return summary
+}
+
+// GetDelta returns the change in coverage percentage of the counter type
+// relative to the baseline, if the baseline has data for it.
+func (s CoverageSummary) GetDelta(baseline *CoverageSummary, counterType coverage.CounterType) (float64, bool) {
+	if baseline == nil || !baseline.Counters.Has(counterType) || !s.Counters.Has(counterType) {
+		return 0, false
+	}
+	return s.Counters.Get(counterType).Percentage() - baseline.Counters.Get(counterType).Percentage(), true
+}
+
+func WriteCoverageSummary(summaryPath string, summary CoverageSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal coverage summary: %w", err)
+	}
+
+	err = CreateDir(filepath.Dir(summaryPath))
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(summaryPath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write coverage summary %s: %w", summaryPath, err)
+	}
+	return nil
+}
+
+func ReadCoverageSummary(summaryPath string) (*CoverageSummary, error) {
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage summary %s: %w", summaryPath, err)
+	}
+
+	var summary CoverageSummary
+	err = json.Unmarshal(data, &summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coverage summary %s: %w", summaryPath, err)
+	}
+	return &summary, nil
+}
+
+// GetModuleCoverage returns the line coverage of the modules as
+// comma separated name=percentage pairs, the value of MODULE_COVERAGE.
+func (s CoverageSummary) GetModuleCoverage() string {
+	var pairs []string
+	for _, module := range s.Modules {
+		pairs = append(pairs, fmt.Sprintf("%s=%.2f", module.Name,
+			module.Counters.Get(coverage.LineCounter).Percentage()))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// UncoveredFile lists the lines of a file left to test: the ranges of lines
+// never executed and the executed lines with branches not taken.
+type UncoveredFile struct {
+	Path            string               `json:"path"`
+	MissedLines     int                  `json:"missedLines"`
+	UncoveredLines  []coverage.LineRange `json:"uncoveredLines,omitempty"`
+	PartialBranches []PartialBranch      `json:"partialBranches,omitempty"`
+}
+
+type PartialBranch struct {
+	Line            int `json:"line"`
+	CoveredBranches int `json:"coveredBranches"`
+	Branches        int `json:"branches"`
+}
+
+// GetUncoveredFiles returns the files of the report with uncovered lines or
+// partially covered branches, in the order of the report.
+func GetUncoveredFiles(report *coverage.Report) []UncoveredFile {
+	var uncoveredFiles []UncoveredFile
+	for _, pkg := range report.Packages {
+		for _, file := range pkg.Files {
+			uncoveredFile := UncoveredFile{Path: file.GetSourcePath(), UncoveredLines: file.GetUncoveredRanges()}
+			for _, lineRange := range uncoveredFile.UncoveredLines {
+				uncoveredFile.MissedLines += getLineCount(file.Lines, lineRange)
+			}
+			for _, line := range file.GetPartiallyCoveredLines() {
+				uncoveredFile.PartialBranches = append(uncoveredFile.PartialBranches, PartialBranch{
+					Line:            line.Number,
+					CoveredBranches: line.CoveredBranches,
+					Branches:        line.Branches,
+				})
+			}
+			if len(uncoveredFile.UncoveredLines) > 0 || len(uncoveredFile.PartialBranches) > 0 {
+				uncoveredFiles = append(uncoveredFiles, uncoveredFile)
+			}
+		}
+	}
+	return uncoveredFiles
+}
+
+// getLineCount returns the number of reported lines in the range.
+func getLineCount(lines []coverage.Line, lineRange coverage.LineRange) int {
+	count := 0
+	for _, line := range lines {
+		if line.Number >= lineRange.Start && line.Number <= lineRange.End {
+			count++
+		}
+	}
+	return count
+}
+
+// String writes the uncovered lines compactly, like
+// "App.java: 12-15, 20; partial branches 18 (1/2)".
+func (f UncoveredFile) String() string {
+	var parts []string
+	if len(f.UncoveredLines) > 0 {
+		var ranges []string
+		for _, lineRange := range f.UncoveredLines {
+			ranges = append(ranges, lineRange.String())
+		}
+		parts = append(parts, strings.Join(ranges, ", "))
+	}
+	if len(f.PartialBranches) > 0 {
+		var branches []string
+		for _, branch := range f.PartialBranches {
+			branches = append(branches, fmt.Sprintf("%d (%d/%d)", branch.Line, branch.CoveredBranches, branch.Branches))
+		}
+		parts = append(parts, "partial branches "+strings.Join(branches, ", "))
+	}
+	return f.Path + ": " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,251 @@
+package plugin_defs
+
+import (
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// thresholdSettingNames are the names of the threshold_* settings, which
+// threshold_warnings lists to make their checks warnings.
+var thresholdSettingNames = []string{"instruction", "branch", "line", "method", "class", "complexity",
+	"package", "file", "loc", "complexity_density", "module"}
+
+// ThresholdCheck is the outcome of comparing one observed coverage value
+// with the threshold configured for it.
+type ThresholdCheck struct {
+	Metric        string               `json:"metric"`
+	CounterType   coverage.CounterType `json:"counterType,omitempty"`
+	Package       string               `json:"package,omitempty"`
+	Module        string               `json:"module,omitempty"`
+	ObservedValue float64              `json:"observed"`
+	ExpectedValue float64              `json:"expected"`
+	IsMaximum     bool                 `json:"isMaximum,omitempty"`
+	IsStrict      bool                 `json:"isStrict,omitempty"`
+	IsPercentage  bool                 `json:"isPercentage,omitempty"`
+	IsRatchet     bool                 `json:"isRatchet,omitempty"`
+	Severity      string               `json:"severity,omitempty"`
+	Passed        bool                 `json:"passed"`
+}
+
+// GetMinimumThresholdCheck checks a coverage percentage that must not fall
+// below the threshold. Tools differ on whether reaching the threshold
+// exactly passes.
+func GetMinimumThresholdCheck(metric string, counterType coverage.CounterType,
+	observed, expected float64, inclusive bool) ThresholdCheck {
+
+	thresholdCheck := GetMinimumValueThresholdCheck(metric, observed, expected, inclusive)
+	thresholdCheck.CounterType = counterType
+	thresholdCheck.IsPercentage = true
+	return thresholdCheck
+}
+
+// GetMinimumValueThresholdCheck checks an absolute value, like the lines of
+// code, that must not fall below the threshold.
+func GetMinimumValueThresholdCheck(metric string, observed, expected float64, inclusive bool) ThresholdCheck {
+
+	passed := observed > expected
+	if inclusive {
+		passed = observed >= expected
+	}
+
+	return ThresholdCheck{
+		Metric:        metric,
+		ObservedValue: observed,
+		ExpectedValue: expected,
+		IsStrict:      !inclusive,
+		Passed:        passed,
+	}
+}
+
+// GetMaximumThresholdCheck checks a value that must not exceed the threshold.
+func GetMaximumThresholdCheck(metric string, observed, expected float64) ThresholdCheck {
+	return ThresholdCheck{
+		Metric:        metric,
+		ObservedValue: observed,
+		ExpectedValue: expected,
+		IsMaximum:     true,
+		Passed:        observed <= expected,
+	}
+}
+
+func (t ThresholdCheck) GetExpectedString() string {
+	if t.IsMaximum {
+		return fmt.Sprintf("<= %s", t.FormatValue(t.ExpectedValue))
+	}
+	if t.IsStrict {
+		return fmt.Sprintf("> %s", t.FormatValue(t.ExpectedValue))
+	}
+	return fmt.Sprintf(">= %s", t.FormatValue(t.ExpectedValue))
+}
+
+func (t ThresholdCheck) FormatValue(value float64) string {
+	if t.IsPercentage {
+		return fmt.Sprintf("%.2f%%", value)
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// GetSettingName returns the name of the threshold setting of the check,
+// like branch for threshold_branch.
+func (t ThresholdCheck) GetSettingName() string {
+	if t.Module != "" {
+		return "module"
+	}
+	if t.CounterType != "" {
+		return strings.ToLower(string(t.CounterType))
+	}
+	return strings.ReplaceAll(strings.ToLower(t.Metric), " ", "_")
+}
+
+// IsCounterCheck tells whether the check is the threshold setting of a
+// counter of the whole report, shown next to the coverage of the counter.
+func (t ThresholdCheck) IsCounterCheck() bool {
+	return t.CounterType != "" && t.Package == "" && t.Module == "" && !t.IsRatchet
+}
+
+func (t ThresholdCheck) IsWarning() bool {
+	return t.Severity == SeverityWarning
+}
+
+// IsFailed tells whether the check is not met and fails the run. Checks
+// without a severity, like those of older summaries, are errors.
+func (t ThresholdCheck) IsFailed() bool {
+	return !t.Passed && !t.IsWarning()
+}
+
+// IsWarningRaised tells whether the check is not met but only warns.
+func (t ThresholdCheck) IsWarningRaised() bool {
+	return !t.Passed && t.IsWarning()
+}
+
+func (t ThresholdCheck) String() string {
+	status := "met"
+	if t.IsWarningRaised() {
+		status = "not met (warning)"
+	} else if !t.Passed {
+		status = "not met"
+	}
+	return fmt.Sprintf("%s threshold %s expected = %s observed = %s",
+		t.Metric, status, t.GetExpectedString(), t.FormatValue(t.ObservedValue))
+}
+
+// IsAllThresholdChecksPassed tells whether no check fails the run. Checks
+// that only warn do not count.
+func IsAllThresholdChecksPassed(checks []ThresholdCheck) bool {
+	return len(GetFailedThresholdChecks(checks)) == 0
+}
+
+func GetFailedThresholdChecks(checks []ThresholdCheck) []ThresholdCheck {
+	var failed []ThresholdCheck
+	for _, check := range checks {
+		if check.IsFailed() {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+func GetWarningThresholdChecks(checks []ThresholdCheck) []ThresholdCheck {
+	var warnings []ThresholdCheck
+	for _, check := range checks {
+		if check.IsWarningRaised() {
+			warnings = append(warnings, check)
+		}
+	}
+	return warnings
+}
+
+// ValidateThresholdWarnings checks the metric names of the
+// threshold_warnings setting.
+func ValidateThresholdWarnings(thresholdWarnings string) error {
+	for _, name := range ToStringArrayFromCsvString(thresholdWarnings) {
+		if name != "" && !isThresholdSettingName(strings.ToLower(name)) {
+			return GetNewError(fmt.Sprintf("Error in ValidateThresholdWarnings: unknown metric %s, expected one of %s",
+				name, strings.Join(thresholdSettingNames, ", ")))
+		}
+	}
+	return nil
+}
+
+func isThresholdSettingName(name string) bool {
+	for _, settingName := range thresholdSettingNames {
+		if name == settingName {
+			return true
+		}
+	}
+	return false
+}
+
+// GetConfiguredThresholdChecks drops the checks of thresholds that are not
+// set. Unset thresholds are 0, like threshold_module, for the minimums and
+// the maximums alike.
+func GetConfiguredThresholdChecks(checks []ThresholdCheck) []ThresholdCheck {
+	var configuredChecks []ThresholdCheck
+	for _, check := range checks {
+		if check.ExpectedValue > 0 {
+			configuredChecks = append(configuredChecks, check)
+		}
+	}
+	return configuredChecks
+}
+
+// ApplyThresholdSeverities sets the severity of the checks: warning for the
+// metrics listed in threshold_warnings, or for all of them in soft fail
+// mode, and error for the others.
+func ApplyThresholdSeverities(checks []ThresholdCheck, args EnvPluginInputArgs) []ThresholdCheck {
+
+	warningNames := map[string]bool{}
+	for _, name := range ToStringArrayFromCsvString(args.ThresholdWarnings) {
+		warningNames[strings.ToLower(name)] = true
+	}
+
+	for i := range checks {
+		checks[i].Severity = SeverityError
+		if args.SoftFail || warningNames[checks[i].GetSettingName()] {
+			checks[i].Severity = SeverityWarning
+		}
+	}
+	return checks
+}
+
+// GetModuleThresholdChecks checks the line coverage of every module of the
+// report against threshold_module. Reports without modules have no checks.
+func GetModuleThresholdChecks(report *coverage.Report, threshold float64) []ThresholdCheck {
+	if threshold <= 0 {
+		return nil
+	}
+	var checks []ThresholdCheck
+	for _, module := range report.GetModules() {
+		if !module.Counters.Has(coverage.LineCounter) {
+			continue
+		}
+		check := GetMinimumThresholdCheck(fmt.Sprintf("Module (%s)", module.Name), coverage.LineCounter,
+			module.Counters.Get(coverage.LineCounter).Percentage(), threshold, true)
+		check.Module = module.Name
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// GetPackageThresholdChecks applies the configured minimum coverage
+// percentages of the global checks to the counters of one package.
+func GetPackageThresholdChecks(globalChecks []ThresholdCheck, packageCounters coverage.Counters) []ThresholdCheck {
+	var packageChecks []ThresholdCheck
+	for _, check := range globalChecks {
+		if !check.IsCounterCheck() || check.IsMaximum || !check.IsPercentage || check.ExpectedValue <= 0 ||
+			!packageCounters.Has(check.CounterType) {
+			continue
+		}
+		packageCheck := GetMinimumThresholdCheck(check.Metric, check.CounterType,
+			packageCounters.Get(check.CounterType).Percentage(), check.ExpectedValue, !check.IsStrict)
+		packageCheck.Severity = check.Severity
+		packageChecks = append(packageChecks, packageCheck)
+	}
+	return packageChecks
+}
@@ -5,6 +5,7 @@
 package plugin_defs
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
@@ -37,6 +38,11 @@ func writeCard(path, schema string, card interface{}) {
 	}
 }
 
+// WriteCard writes the adaptive card data to the card path of the pipeline.
+func WriteCard(path, schema string, card interface{}) {
+	writeCard(path, schema, card)
+}
+
 func writeCardTo(out io.Writer, data []byte) {
 	encoded := base64.StdEncoding.EncodeToString(data)
 	io.WriteString(out, "\u001B]1338;")
@@ -49,9 +55,11 @@ func GetNewError(s string) error {
 	return errors.New(s)
 }
 
+// LogPrintln writes the plugin progress messages at debug level, so they
+// show up with PLUGIN_LOG_LEVEL set to debug or trace.
 func LogPrintln(p Plugin, args ...interface{}) {
 
-	if !IsDevTestingMode() {
+	if !logrus.IsLevelEnabled(logrus.DebugLevel) {
 		return
 	}
 
@@ -61,12 +69,12 @@ func LogPrintln(p Plugin, args ...interface{}) {
 		}
 	}
 
-	logrus.Println(append([]interface{}{"Plugin Info:"}, args...)...)
+	logrus.Debugln(args...)
 }
 
 func LogPrintf(p Plugin, format string, v ...interface{}) {
 
-	if !IsDevTestingMode() {
+	if !logrus.IsLevelEnabled(logrus.DebugLevel) {
 		return
 	}
 
@@ -75,7 +83,7 @@ func LogPrintf(p Plugin, format string, v ...interface{}) {
 			return
 		}
 	}
-	logrus.Printf(format, v...)
+	logrus.Debugf(format, v...)
 }
 
 func IsDirExists(dir string) (bool, error) {
@@ -114,46 +122,6 @@ func GetAllJacocoExecFilesFromGlobPattern(rootDir, globPatterns string) ([]PathW
 	return execFilesPathWithPrefixList, nil
 }
 
-func FilterFileOrDirUsingGlobPatterns(rootSearchDir string, dirsGlobList []string,
-	includeGlobPatternCsvStr, excludeGlobPatternCsvStr string, autoFillIncludePattern string) ([]FilesInfoStore, error) {
-
-	var filesStoreList []FilesInfoStore
-
-	if len(includeGlobPatternCsvStr) == 0 {
-		if len(autoFillIncludePattern) > 0 {
-			includeGlobPatternCsvStr = autoFillIncludePattern
-		}
-	}
-
-	includeGlobPatternStrList := ToStringArrayFromCsvString(includeGlobPatternCsvStr)
-	excludeGlobPatternStrList := ToStringArrayFromCsvString(excludeGlobPatternCsvStr)
-
-	for _, dirPattern := range dirsGlobList {
-
-		rootSearchDirFS := os.DirFS(rootSearchDir)
-
-		relPattern := strings.TrimPrefix(dirPattern, rootSearchDir+"/")
-
-		matchedDirs, err := doublestar.Glob(rootSearchDirFS, relPattern)
-		if err != nil {
-			return filesStoreList, err
-		}
-		for _, relativePath := range matchedDirs {
-			completePath := filepath.Join(rootSearchDir, relativePath)
-			filesInfoStore, err := WalkDir2(completePath, relativePath, rootSearchDir+"/",
-				includeGlobPatternStrList, excludeGlobPatternStrList)
-			if err != nil {
-				LogPrintln(nil, "Error in WalkDir: ", err.Error())
-				return filesStoreList, err
-			}
-			filesStoreList = append(filesStoreList, filesInfoStore)
-		}
-
-	}
-
-	return filesStoreList, nil
-}
-
 type FilesInfoStore struct {
 	IncludedPathsListWithPrefix []PathWithPrefix
 	ExcludedPathsListWithPrefix []PathWithPrefix
@@ -163,7 +131,7 @@ type IncludeExcludesMerged struct {
 	CompletePathsWithPrefixList []PathWithPrefix
 }
 
-func (i *IncludeExcludesMerged) CopyTo(toDstPathPrefix, buildRootPath string) error {
+func (i *IncludeExcludesMerged) CopyTo(ctx context.Context, toDstPathPrefix, buildRootPath string) error {
 
 	err := i.CreateUniqueDirs(toDstPathPrefix)
 	if err != nil {
@@ -171,23 +139,10 @@ func (i *IncludeExcludesMerged) CopyTo(toDstPathPrefix, buildRootPath string) er
 		return err
 	}
 
-	for _, pathWithPrefix := range i.CompletePathsWithPrefixList {
-		prefix := pathWithPrefix.CompletePathPrefix
-		relPath := pathWithPrefix.RelativePath
-
-		srcPath := filepath.Join(prefix, relPath)
-		dstPath := filepath.Join(toDstPathPrefix, relPath)
-
-		err := CopyFile(srcPath, dstPath)
-		if err != nil {
-			LogPrintln(nil, "** Error **:  in copying file: ", err.Error())
-		}
-	}
-
-	return nil
+	return CopyFiles(ctx, i.GetFileCopies(toDstPathPrefix))
 }
 
-func (i *IncludeExcludesMerged) CopySourceTo(toDstPathPrefix, buildRootPath string) error {
+func (i *IncludeExcludesMerged) CopySourceTo(ctx context.Context, toDstPathPrefix, buildRootPath string) error {
 
 	uniqueDirs := i.GetAllUniqueDirsForSource(toDstPathPrefix, buildRootPath)
 	for _, dir := range uniqueDirs {
@@ -197,23 +152,20 @@ func (i *IncludeExcludesMerged) CopySourceTo(toDstPathPrefix, buildRootPath stri
 		}
 	}
 
-	for _, prefixWithPath := range i.CompletePathsWithPrefixList {
-
-		prefix := prefixWithPath.CompletePathPrefix
-		relPath := prefixWithPath.RelativePath
-		srcPath := filepath.Join(prefix, relPath)
-
-		dstFile := filepath.Join(toDstPathPrefix, relPath)
-
-		err := CopyFile(srcPath, dstFile)
-		if err != nil {
-			LogPrintln(nil, "Error in copying file: ", err.Error())
-			continue
-		}
+	return CopyFiles(ctx, i.GetFileCopies(toDstPathPrefix))
+}
 
+// GetFileCopies returns the copies of the files to the same relative paths
+// in toDstPathPrefix.
+func (i *IncludeExcludesMerged) GetFileCopies(toDstPathPrefix string) []FileCopy {
+	var fileCopies []FileCopy
+	for _, pathWithPrefix := range i.CompletePathsWithPrefixList {
+		fileCopies = append(fileCopies, FileCopy{
+			SrcPath: filepath.Join(pathWithPrefix.CompletePathPrefix, pathWithPrefix.RelativePath),
+			DstPath: filepath.Join(toDstPathPrefix, pathWithPrefix.RelativePath),
+		})
 	}
-
-	return nil
+	return fileCopies
 }
 
 func getSrcDir(absolutepath string) string {
@@ -239,8 +191,7 @@ func (i *IncludeExcludesMerged) GetAllUniqueDirsForSource1(toDstPathPrefix, buil
 		srcPath := filepath.Join(prefix, relPath)
 		sourcePathDir := getSrcDir(filepath.Dir(srcPath))
 
-		fmt.Println(sourcePathDir)
-		fmt.Println(filepath.Join(toDstPathPrefix, sourcePathDir))
+		logrus.Traceln(sourcePathDir, "->", filepath.Join(toDstPathPrefix, sourcePathDir))
 
 		dstDir := filepath.Join(toDstPathPrefix, sourcePathDir)
 
@@ -352,6 +303,8 @@ func MergeIncludeExcludeFileCompletePaths(filesInfoStore []FilesInfoStore) []Inc
 			includedFileCompletePath := filepath.Join(includedPathWithPrefix.CompletePathPrefix,
 				includedPathWithPrefix.RelativePath)
 			if _, excluded := excludeMap[includedFileCompletePath]; !excluded {
+				// a directory matching several patterns has its files once
+				excludeMap[includedFileCompletePath] = true
 				validFileList = append(validFileList, includedFileCompletePath)
 				validFilesListWithPrefix = append(validFilesListWithPrefix, includedPathWithPrefix)
 			}
@@ -371,72 +324,6 @@ type PathWithPrefix struct {
 	RelativePath       string
 }
 
-func WalkDir2(completePath, relativePath, completePathPrefix string,
-	includeGlobPatternStrList, excludeGlobPatternStrList []string) (FilesInfoStore, error) {
-
-	relativeIncludePathsList := []string{}
-	relativeExcludePathsList := []string{}
-
-	includedCompletePathsList := []string{}
-	excludedCompletePathsList := []string{}
-
-	var includedPathsListWithPrefix []PathWithPrefix
-	var excludedPathsListWithPrefix []PathWithPrefix
-
-	for _, includeGlobPatternStr := range includeGlobPatternStrList {
-
-		rootSearchDirFS := os.DirFS(completePath)
-		relPattern := strings.TrimPrefix(includeGlobPatternStr, completePath+"/")
-		matchedFiles, err := doublestar.Glob(rootSearchDirFS, relPattern)
-
-		if err != nil {
-			LogPrintln(nil, "Error in doublestar.Glob: ", err.Error())
-			return FilesInfoStore{}, err
-		}
-
-		for _, matchedFile := range matchedFiles {
-			matchedFileCompletePath := filepath.Join(completePath, matchedFile)
-			includedCompletePathsList = append(includedCompletePathsList, matchedFileCompletePath)
-			pathWithPrefix := PathWithPrefix{
-				CompletePathPrefix: completePath,
-				RelativePath:       matchedFile,
-			}
-			includedPathsListWithPrefix = append(includedPathsListWithPrefix, pathWithPrefix)
-		}
-
-		relativeIncludePathsList = append(relativeIncludePathsList, matchedFiles...)
-	}
-
-	for _, excludeGlobPatternStr := range excludeGlobPatternStrList {
-		rootSearchDirFS := os.DirFS(completePath)
-		relPattern := strings.TrimPrefix(excludeGlobPatternStr, completePath+"/")
-		matchedFiles, err := doublestar.Glob(rootSearchDirFS, relPattern)
-
-		if err != nil {
-			LogPrintln(nil, "Error in doublestar.Glob: ", err.Error())
-		}
-
-		for _, matchedFile := range matchedFiles {
-			matchedFileCompletePath := filepath.Join(completePath, matchedFile)
-			excludedCompletePathsList = append(excludedCompletePathsList, matchedFileCompletePath)
-			pathWithPrefix := PathWithPrefix{
-				CompletePathPrefix: completePath,
-				RelativePath:       matchedFile,
-			}
-			excludedPathsListWithPrefix = append(excludedPathsListWithPrefix, pathWithPrefix)
-		}
-
-		relativeExcludePathsList = append(relativeExcludePathsList, matchedFiles...)
-	}
-
-	classInfoStore := FilesInfoStore{
-		IncludedPathsListWithPrefix: includedPathsListWithPrefix,
-		ExcludedPathsListWithPrefix: excludedPathsListWithPrefix,
-	}
-
-	return classInfoStore, nil
-}
-
 func TrimStrings(input []string) []string {
 	var trimmed []string
 	for _, str := range input {
@@ -548,6 +435,15 @@ func CreateDir(absolutePath string) error {
 	return nil
 }
 
+// GetWorkSpaceRelativePath resolves paths given in the plugin settings
+// relative to the workspace dir.
+func GetWorkSpaceRelativePath(workSpaceDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workSpaceDir, path)
+}
+
 func GetOutputVariablesStorageFilePath() string {
 	return os.Getenv("DRONE_OUTPUT")
 }
@@ -560,6 +456,14 @@ func ReadFileAsString(filePath string) (string, error) {
 	return string(data), nil
 }
 
+func WriteFileAsString(filePath, content string) error {
+	err := CreateDir(filepath.Dir(filePath))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, []byte(content), 0644)
+}
+
 func WriteEnvVariableAsString(key string, value interface{}) error {
 
 	if GetOutputVariablesStorageFilePath() == "" {
@@ -582,6 +486,13 @@ func WriteEnvVariableAsString(key string, value interface{}) error {
 	return nil
 }
 
+// WriteEnvVariableAsMultilineString writes a value spanning several lines as
+// a double quoted dotenv value with escaped newlines.
+func WriteEnvVariableAsMultilineString(key, value string) error {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`)
+	return WriteEnvVariableAsString(key, `"`+replacer.Replace(value)+`"`)
+}
+
 func IsDevTestingMode() bool {
 	return os.Getenv("DEV_TEST_d6c9b463090c") == "true"
 }
@@ -0,0 +1,146 @@
+package ratchet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Floors are the minimum coverage percentages the ratchet enforces, per
+// counter type. They only go up, as builds of the default branch improve.
+type Floors map[coverage.CounterType]float64
+
+// FloorsFile is the content of the floors file, meant to be committed with
+// the sources.
+type FloorsFile struct {
+	Floors      Floors `json:"floors"`
+	Commit      string `json:"commit,omitempty"`
+	BuildNumber int    `json:"buildNumber,omitempty"`
+}
+
+// Change is a floor raised by a build.
+type Change struct {
+	CounterType coverage.CounterType
+	Previous    float64
+	Current     float64
+	IsNew       bool
+}
+
+func (c Change) String() string {
+	if c.IsNew {
+		return fmt.Sprintf("%s floor set to %.2f%%", c.CounterType.DisplayName(), c.Current)
+	}
+	return fmt.Sprintf("%s floor raised from %.2f%% to %.2f%%", c.CounterType.DisplayName(), c.Previous, c.Current)
+}
+
+// GetCounterTypes parses the comma separated metrics to ratchet, which are
+// named like the threshold settings. Only the coverage percentages can be
+// ratcheted.
+func GetCounterTypes(metrics string) ([]coverage.CounterType, error) {
+	var counterTypes []coverage.CounterType
+	for _, metric := range pd.ToStringArrayFromCsvString(metrics) {
+		if metric == "" {
+			continue
+		}
+		counterType := coverage.CounterType(strings.ToUpper(metric))
+		if counterType == coverage.ComplexityCounter || !isCounterType(counterType) {
+			return nil, fmt.Errorf("unknown ratchet metric %s, expected instruction, branch, line, method or class",
+				metric)
+		}
+		counterTypes = append(counterTypes, counterType)
+	}
+	return counterTypes, nil
+}
+
+func isCounterType(counterType coverage.CounterType) bool {
+	for _, knownType := range coverage.AllCounterTypes {
+		if counterType == knownType {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadFloorsFile reads the floors of the file. A file that does not exist
+// yet has no floors.
+func ReadFloorsFile(floorsPath string) (Floors, error) {
+	data, err := os.ReadFile(floorsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return Floors{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var floorsFile FloorsFile
+	err = json.Unmarshal(data, &floorsFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ratchet file %s: %w", floorsPath, err)
+	}
+	if floorsFile.Floors == nil {
+		return Floors{}, nil
+	}
+	return floorsFile.Floors, nil
+}
+
+func WriteFloorsFile(floorsPath string, floorsFile FloorsFile) error {
+	data, err := json.MarshalIndent(floorsFile, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = pd.CreateDir(filepath.Dir(floorsPath))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(floorsPath, append(data, '\n'), 0644)
+}
+
+// GetChecks checks the coverage of the counters against the floors.
+func GetChecks(floors Floors, counters coverage.Counters, counterTypes []coverage.CounterType) []pd.ThresholdCheck {
+	var checks []pd.ThresholdCheck
+	for _, counterType := range counterTypes {
+		floor, ok := floors[counterType]
+		if !ok || !counters.Has(counterType) {
+			continue
+		}
+		check := pd.GetMinimumThresholdCheck(counterType.DisplayName()+" (ratchet)", counterType,
+			counters.Get(counterType).Percentage(), floor, true)
+		check.IsRatchet = true
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// Raise returns the floors after a build of the default branch: a floor
+// the coverage exceeds by more than the slack becomes the coverage minus
+// the slack, and floors never go down.
+func Raise(floors Floors, counters coverage.Counters, counterTypes []coverage.CounterType,
+	slack float64) (Floors, []Change) {
+
+	raised := Floors{}
+	for counterType, floor := range floors {
+		raised[counterType] = floor
+	}
+
+	var changes []Change
+	for _, counterType := range counterTypes {
+		if !counters.Has(counterType) {
+			continue
+		}
+		candidate := math.Floor(math.Max(counters.Get(counterType).Percentage()-slack, 0)*100) / 100
+		floor, ok := floors[counterType]
+		if ok && candidate <= floor {
+			continue
+		}
+		raised[counterType] = candidate
+		changes = append(changes, Change{CounterType: counterType, Previous: floor, Current: candidate, IsNew: !ok})
+	}
+	return raised, changes
+}
@@ -0,0 +1,158 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/history"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/harness-community/drone-coverage-report/plugin/ratchet"
+	"path/filepath"
+	"testing"
+)
+
+func GetTestRatchetArgs(branch string) pd.Args {
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{
+		MinimumComplexityCoverage:    10,
+		MaxComplexityDensityCoverage: 0.50,
+	})
+	args.Ratchet = true
+	args.RatchetSlack = 0.5
+	args.Repo.Slug = "octocat/hello-world"
+	args.Repo.Branch = "main"
+	args.Commit.Branch = branch
+	return args
+}
+
+func TestRatchetFile(t *testing.T) {
+
+	ratchetPath := filepath.Join(t.TempDir(), ".coverage-ratchet.json")
+
+	args := GetTestRatchetArgs("main")
+	args.RatchetPath = ratchetPath
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestRatchetFile: %s", err.Error())
+	}
+
+	floors, err := ratchet.ReadFloorsFile(ratchetPath)
+	if err != nil {
+		t.Fatalf("Error in TestRatchetFile: %s", err.Error())
+	}
+	if floors[coverage.LineCounter] != 11.26 || floors[coverage.BranchCounter] != 0 {
+		t.Errorf("Error in TestRatchetFile: unexpected floors %v", floors)
+	}
+
+	err = ratchet.WriteFloorsFile(ratchetPath, ratchet.FloorsFile{Floors: ratchet.Floors{coverage.LineCounter: 50}})
+	if err != nil {
+		t.Fatalf("Error in TestRatchetFile: %s", err.Error())
+	}
+
+	for _, branch := range []string{"feature", "main"} {
+		args := GetTestRatchetArgs(branch)
+		args.RatchetPath = ratchetPath
+		_, err = Exec(context.TODO(), args)
+		if !errors.Is(err, pd.ErrThreshold) {
+			t.Errorf("Error in TestRatchetFile: build of %s should fail below the floor, got %v", branch, err)
+		}
+	}
+
+	args = GetTestRatchetArgs("main")
+	args.RatchetPath = ratchetPath
+	args.SoftFail = true
+	_, err = Exec(context.TODO(), args)
+	if err != nil {
+		t.Errorf("Error in TestRatchetFile: soft fail should only warn: %s", err.Error())
+	}
+
+	floors, err = ratchet.ReadFloorsFile(ratchetPath)
+	if err != nil {
+		t.Fatalf("Error in TestRatchetFile: %s", err.Error())
+	}
+	if floors[coverage.LineCounter] != 50 {
+		t.Errorf("Error in TestRatchetFile: floor lowered to %.2f", floors[coverage.LineCounter])
+	}
+}
+
+func TestRatchetNotRaisedByFailedBuilds(t *testing.T) {
+
+	ratchetPath := filepath.Join(t.TempDir(), ".coverage-ratchet.json")
+	err := ratchet.WriteFloorsFile(ratchetPath, ratchet.FloorsFile{Floors: ratchet.Floors{coverage.LineCounter: 5}})
+	if err != nil {
+		t.Fatalf("Error in TestRatchetNotRaisedByFailedBuilds: %s", err.Error())
+	}
+
+	packageRuleArgs := GetTestRatchetArgs("main")
+	packageRuleArgs.PackageRules = []pd.PackageRule{{Pattern: "com/example/**",
+		Thresholds: map[coverage.CounterType]float64{coverage.LineCounter: 90}}}
+	thresholdArgs := GetTestRatchetArgs("main")
+	thresholdArgs.MinimumLineCoverage = 90
+
+	for _, args := range []pd.Args{packageRuleArgs, thresholdArgs} {
+		args.RatchetPath = ratchetPath
+		_, err = Exec(context.TODO(), args)
+		if !errors.Is(err, pd.ErrThreshold) {
+			t.Errorf("Error in TestRatchetNotRaisedByFailedBuilds: build should fail, got %v", err)
+		}
+
+		floors, err := ratchet.ReadFloorsFile(ratchetPath)
+		if err != nil {
+			t.Fatalf("Error in TestRatchetNotRaisedByFailedBuilds: %s", err.Error())
+		}
+		if floors[coverage.LineCounter] != 5 {
+			t.Errorf("Error in TestRatchetNotRaisedByFailedBuilds: floor raised to %.2f by a failed build",
+				floors[coverage.LineCounter])
+		}
+	}
+}
+
+func TestRatchetHistory(t *testing.T) {
+
+	historyPath := filepath.Join(t.TempDir(), "coverage-history.jsonl")
+
+	for buildNumber, branch := range []string{"main", "feature"} {
+		args := GetTestRatchetArgs(branch)
+		args.HistoryPath = historyPath
+		args.Build.Number = buildNumber + 1
+		_, err := Exec(context.TODO(), args)
+		if err != nil {
+			t.Fatalf("Error in TestRatchetHistory: %s", err.Error())
+		}
+	}
+
+	floors, err := history.GetNewStore(historyPath).GetLatestRatchetFloors("octocat/hello-world", "main")
+	if err != nil {
+		t.Fatalf("Error in TestRatchetHistory: %s", err.Error())
+	}
+	if floors[coverage.LineCounter] != 11.26 {
+		t.Errorf("Error in TestRatchetHistory: unexpected floors %v", floors)
+	}
+
+	args := GetTestRatchetArgs("main")
+	args.RatchetMetrics = "line,complexity"
+	args.HistoryPath = historyPath
+	_, err = Exec(context.TODO(), args)
+	if err == nil {
+		t.Errorf("Error in TestRatchetHistory: complexity should not be accepted as a ratchet metric")
+	}
+}
+
+func TestRatchetRaise(t *testing.T) {
+
+	counters := coverage.Counters{
+		coverage.LineCounter:   {Covered: 80, Missed: 20},
+		coverage.BranchCounter: {Covered: 50, Missed: 50},
+	}
+	floors := ratchet.Floors{coverage.LineCounter: 79.8, coverage.BranchCounter: 60}
+
+	raised, changes := ratchet.Raise(floors, counters,
+		[]coverage.CounterType{coverage.LineCounter, coverage.BranchCounter}, 0.5)
+	if len(changes) != 0 || raised[coverage.LineCounter] != 79.8 || raised[coverage.BranchCounter] != 60 {
+		t.Errorf("Error in TestRatchetRaise: floors within the slack or above the coverage changed %v", raised)
+	}
+
+	raised, changes = ratchet.Raise(floors, counters, []coverage.CounterType{coverage.LineCounter}, 0.1)
+	if len(changes) != 1 || raised[coverage.LineCounter] != 79.9 {
+		t.Errorf("Error in TestRatchetRaise: line floor not raised %v", raised)
+	}
+}
@@ -0,0 +1,183 @@
+package plugin
+
+import (
+	"context"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoberturaReportFilters(t *testing.T) {
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.ClassExclusionPatterns = "com/example/package2/**"
+	args.SourceExclusionPattern = "**/Divider.java"
+	args.SummaryJsonPath = filepath.Join(t.TempDir(), "summary.json")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaReportFilters: %s", err.Error())
+	}
+
+	summary, err := pd.ReadCoverageSummary(args.SummaryJsonPath)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaReportFilters: %s", err.Error())
+	}
+	if len(summary.Packages) != 1 || summary.Packages[0].Name != "com.example.package1" {
+		t.Errorf("Error in TestCoberturaReportFilters: unexpected packages %v", summary.Packages)
+	}
+	lineCounter := summary.Counters.Get(coverage.LineCounter)
+	branchCounter := summary.Counters.Get(coverage.BranchCounter)
+	if lineCounter != (coverage.Counter{Covered: 2, Missed: 4}) || branchCounter != (coverage.Counter{Covered: 0, Missed: 2}) {
+		t.Errorf("Error in TestCoberturaReportFilters: unexpected line %v branch %v", lineCounter, branchCounter)
+	}
+}
+
+func TestJacocoXmlReportFilters(t *testing.T) {
+
+	testCases := []struct {
+		classExclusions  string
+		sourceInclusions string
+		lines            int
+		classes          int
+	}{
+		{"**/GridReader.class, **/GridWriter.class", "", 96, 3},
+		{"", "**/Cell.java", 13, 1},
+		{"com/wakaleo/**", "", 0, 0},
+	}
+
+	for _, testCase := range testCases {
+		args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+		args.ExecFilesPathPattern = "**/gameoflife-core/**/jacoco.xml"
+		args.ClassExclusionPatterns = testCase.classExclusions
+		args.SourceInclusionPattern = testCase.sourceInclusions
+		args.SummaryJsonPath = filepath.Join(t.TempDir(), "summary.json")
+
+		_, err := Exec(context.TODO(), args)
+		if err != nil {
+			t.Fatalf("Error in TestJacocoXmlReportFilters: %s", err.Error())
+		}
+
+		summary, err := pd.ReadCoverageSummary(args.SummaryJsonPath)
+		if err != nil {
+			t.Fatalf("Error in TestJacocoXmlReportFilters: %s", err.Error())
+		}
+		lineCounter := summary.Counters.Get(coverage.LineCounter)
+		classCounter := summary.Counters.Get(coverage.ClassCounter)
+		if lineCounter.Covered != testCase.lines || lineCounter.Missed != 0 || classCounter.Covered != testCase.classes {
+			t.Errorf("Error in TestJacocoXmlReportFilters: %s %s gave line %v class %v", testCase.classExclusions,
+				testCase.sourceInclusions, lineCounter, classCounter)
+		}
+	}
+
+	args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	args.ExecFilesPathPattern = "**/gameoflife-core/**/jacoco.xml"
+	args.ClassInclusionPatterns = "**/[Grid.class"
+	_, err := Exec(context.TODO(), args)
+	if err == nil || !strings.Contains(err.Error(), "class_inclusion_pattern") {
+		t.Errorf("Error in TestJacocoXmlReportFilters: expected an invalid pattern error, got %v", err)
+	}
+}
+
+// TestInnerClassJacocoXml is a report of a source file holding a class and
+// its inner class.
+const TestInnerClassJacocoXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<report name="shop">
+  <package name="com/shop/api">
+    <class name="com/shop/api/Cart" sourcefilename="Cart.java">
+      <method name="add" desc="(I)V" line="5">
+        <counter type="INSTRUCTION" missed="0" covered="6"/>
+        <counter type="LINE" missed="0" covered="2"/>
+        <counter type="COMPLEXITY" missed="0" covered="1"/>
+        <counter type="METHOD" missed="0" covered="1"/>
+      </method>
+      <counter type="INSTRUCTION" missed="0" covered="6"/>
+      <counter type="LINE" missed="0" covered="2"/>
+      <counter type="COMPLEXITY" missed="0" covered="1"/>
+      <counter type="METHOD" missed="0" covered="1"/>
+      <counter type="CLASS" missed="0" covered="1"/>
+    </class>
+    <class name="com/shop/api/Cart$Item" sourcefilename="Cart.java">
+      <method name="price" desc="()I" line="12">
+        <counter type="INSTRUCTION" missed="4" covered="0"/>
+        <counter type="BRANCH" missed="2" covered="0"/>
+        <counter type="LINE" missed="2" covered="0"/>
+        <counter type="COMPLEXITY" missed="2" covered="0"/>
+        <counter type="METHOD" missed="1" covered="0"/>
+      </method>
+      <counter type="INSTRUCTION" missed="4" covered="0"/>
+      <counter type="BRANCH" missed="2" covered="0"/>
+      <counter type="LINE" missed="2" covered="0"/>
+      <counter type="COMPLEXITY" missed="2" covered="0"/>
+      <counter type="METHOD" missed="1" covered="0"/>
+      <counter type="CLASS" missed="1" covered="0"/>
+    </class>
+    <sourcefile name="Cart.java">
+      <line nr="5" mi="0" ci="3" mb="0" cb="0"/>
+      <line nr="6" mi="0" ci="3" mb="0" cb="0"/>
+      <line nr="12" mi="2" ci="0" mb="2" cb="0"/>
+      <line nr="13" mi="2" ci="0" mb="0" cb="0"/>
+      <counter type="INSTRUCTION" missed="4" covered="6"/>
+      <counter type="BRANCH" missed="2" covered="0"/>
+      <counter type="LINE" missed="2" covered="2"/>
+      <counter type="COMPLEXITY" missed="2" covered="1"/>
+      <counter type="METHOD" missed="1" covered="1"/>
+      <counter type="CLASS" missed="1" covered="1"/>
+    </sourcefile>
+    <counter type="INSTRUCTION" missed="4" covered="6"/>
+    <counter type="BRANCH" missed="2" covered="0"/>
+    <counter type="LINE" missed="2" covered="2"/>
+    <counter type="COMPLEXITY" missed="2" covered="1"/>
+    <counter type="METHOD" missed="1" covered="1"/>
+    <counter type="CLASS" missed="1" covered="1"/>
+  </package>
+  <counter type="INSTRUCTION" missed="4" covered="6"/>
+  <counter type="BRANCH" missed="2" covered="0"/>
+  <counter type="LINE" missed="2" covered="2"/>
+  <counter type="COMPLEXITY" missed="2" covered="1"/>
+  <counter type="METHOD" missed="1" covered="1"/>
+  <counter type="CLASS" missed="1" covered="1"/>
+</report>
+`
+
+func TestJacocoXmlInnerClassFilter(t *testing.T) {
+
+	workSpaceDir := t.TempDir()
+	t.Setenv(pd.DefaultWorkSpaceDirEnvVarKey, workSpaceDir)
+	err := os.WriteFile(filepath.Join(workSpaceDir, "jacoco.xml"), []byte(TestInnerClassJacocoXml), 0644)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlInnerClassFilter: %s", err.Error())
+	}
+
+	args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	args.ExecFilesPathPattern = "jacoco.xml"
+	args.ClassExclusionPatterns = "**/Cart$Item.class"
+
+	plugin, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlInnerClassFilter: %s", err.Error())
+	}
+
+	report := plugin.GetCoverageReport()
+	if len(report.Packages) != 1 || len(report.Packages[0].Files) != 1 {
+		t.Fatalf("Error in TestJacocoXmlInnerClassFilter: unexpected packages %+v", report.Packages)
+	}
+	file := report.Packages[0].Files[0]
+	if len(file.Lines) != 2 || file.Lines[1].Number != 6 {
+		t.Errorf("Error in TestJacocoXmlInnerClassFilter: lines of the inner class kept %+v", file.Lines)
+	}
+	for _, counterType := range []coverage.CounterType{coverage.LineCounter, coverage.BranchCounter,
+		coverage.MethodCounter, coverage.ClassCounter} {
+		if file.Counters.Get(counterType) != report.Counters.Get(counterType) {
+			t.Errorf("Error in TestJacocoXmlInnerClassFilter: file %s %v, report %v", counterType,
+				file.Counters.Get(counterType), report.Counters.Get(counterType))
+		}
+	}
+	if report.Counters.Get(coverage.LineCounter) != (coverage.Counter{Covered: 2}) {
+		t.Errorf("Error in TestJacocoXmlInnerClassFilter: unexpected lines %v", report.Counters.Get(coverage.LineCounter))
+	}
+}
@@ -0,0 +1,414 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"github.com/harness-community/drone-coverage-report/plugin/badge"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	"github.com/harness-community/drone-coverage-report/plugin/history"
+	"github.com/harness-community/drone-coverage-report/plugin/html"
+	"github.com/harness-community/drone-coverage-report/plugin/junit"
+	"github.com/harness-community/drone-coverage-report/plugin/markdown"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/harness-community/drone-coverage-report/plugin/scm"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// GetThresholdChecks returns the threshold checks of the plugin followed by
+// the checks of the package rules, the modules and the ratchet floors.
+func GetThresholdChecks(p pd.Plugin, args pd.Args) []pd.ThresholdCheck {
+	thresholdChecks := p.GetThresholdChecks()
+	if report := p.GetCoverageReport(); report != nil {
+		packageRuleChecks := pd.ApplyThresholdSeverities(pd.GetPackageRuleChecks(report, args.PackageRules),
+			args.EnvPluginInputArgs)
+		thresholdChecks = append(thresholdChecks, packageRuleChecks...)
+		thresholdChecks = append(thresholdChecks, GetModuleThresholdChecks(report, args)...)
+		thresholdChecks = append(thresholdChecks, GetRatchetChecks(report, args)...)
+	}
+	return thresholdChecks
+}
+
+// WriteReportOutputs renders the optional report outputs configured in args
+// from the coverage report parsed by the plugin. Plugins that did not get as
+// far as parsing a report produce no outputs. The ratchet floors are only
+// raised when the build passed its checks. The context cancels posting to
+// the SCM provider.
+func WriteReportOutputs(ctx context.Context, p pd.Plugin, args pd.Args, passed bool) error {
+
+	report := p.GetCoverageReport()
+	if report == nil {
+		return nil
+	}
+
+	workSpaceDir := pd.GetTestWorkSpaceDir()
+	err := MapSourcePaths(report, args, workSpaceDir)
+	if err != nil {
+		return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+	}
+
+	summary := pd.GetCoverageSummary(report, GetThresholdChecks(p, args), args.Pipeline)
+	pd.LogCoverageSummary(summary)
+	pd.LogUncoveredFiles(summary, getUncoveredFilesLimit(args))
+
+	err = pd.WriteEnvVariableAsString(ThresholdWarningsOutputVariableKey, summary.Warnings)
+	if err != nil {
+		return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+	}
+
+	if len(summary.Modules) > 0 {
+		err = pd.WriteEnvVariableAsString(ModuleCoverageOutputVariableKey, summary.GetModuleCoverage())
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	if args.Ratchet && passed {
+		summary.RatchetFloors, err = UpdateRatchetFloors(summary, args, workSpaceDir)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	} else if args.Ratchet {
+		logrus.Printf("Coverage ratchet floors not updated, the build did not pass its checks\n")
+		summary.RatchetFloors = args.RatchetFloors
+	}
+	baseline := GetBaselineSummary(args, workSpaceDir)
+
+	var historyEntries []history.Entry
+	if args.HistoryPath != "" {
+		var err error
+		historyEntries, err = UpdateHistory(summary, args, workSpaceDir)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	if args.HtmlReportDir != "" {
+		err := WriteHtmlReport(report, historyEntries, args, workSpaceDir)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	if args.BadgeDir != "" {
+		err := WriteBadges(report, args, pd.GetWorkSpaceRelativePath(workSpaceDir, args.BadgeDir))
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	if args.SummaryJsonPath != "" {
+		summaryPath := pd.GetWorkSpaceRelativePath(workSpaceDir, args.SummaryJsonPath)
+		err := pd.WriteCoverageSummary(summaryPath, summary)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+		logrus.Printf("Coverage summary written to %s\n", summaryPath)
+	}
+
+	if args.JunitReportPath != "" {
+		junitPath := pd.GetWorkSpaceRelativePath(workSpaceDir, args.JunitReportPath)
+		err := junit.GetTestSuites(report, summary.ThresholdChecks).Write(junitPath)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+		logrus.Printf("JUnit threshold report written to %s\n", junitPath)
+	}
+
+	if args.MarkdownPath != "" || args.MarkdownOutputVariable || args.PullRequestComment {
+		markdownSummary := markdown.GetNewMarkdownSummary(report, summary, baseline, args.MarkdownTopFiles).Render()
+
+		err := WriteMarkdownSummary(markdownSummary, args, workSpaceDir)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+
+		err = PostPullRequestComment(ctx, markdownSummary, args)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	if args.ConvertFormat != "" {
+		err := WriteConvertedReport(report, args, workSpaceDir)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	if args.SarifPath != "" {
+		err := WriteSarifReport(report, args, workSpaceDir)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	if args.CodecovPath != "" || args.CodecovUrl != "" || args.SonarCoveragePath != "" || args.SonarUploadUrl != "" {
+		err := WriteExports(report, args, workSpaceDir)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	if args.CommitStatus {
+		err := PublishCommitStatuses(ctx, report, summary, baseline, args, workSpaceDir)
+		if err != nil {
+			return pd.GetNewError("Error in WriteReportOutputs: " + err.Error())
+		}
+	}
+
+	WriteCoverageCard(summary, historyEntries, args)
+
+	return nil
+}
+
+// MapSourcePaths finds the report files in the workspace, so outputs that
+// read sources or match diffs work with reports written on other machines.
+func MapSourcePaths(report *coverage.Report, args pd.Args, workSpaceDir string) error {
+
+	mapper, err := pd.GetPathMapper(args, workSpaceDir)
+	if err != nil {
+		return err
+	}
+
+	mapped, err := mapper.Apply(report)
+	if err != nil {
+		return err
+	}
+
+	fileCount := 0
+	for _, pkg := range report.Packages {
+		fileCount += len(pkg.Files)
+	}
+	logrus.Debugf("Source files of %d of %d report files found in the workspace\n", mapped, fileCount)
+	return nil
+}
+
+func WriteHtmlReport(report *coverage.Report, historyEntries []history.Entry, args pd.Args, workSpaceDir string) error {
+
+	locator, err := coverage.GetNewSourceLocator(workSpaceDir,
+		pd.ToStringArrayFromCsvString(args.SourcePattern), report.Sources)
+	if err != nil {
+		return err
+	}
+
+	htmlReport := html.GetNewHtmlReport(report, locator, args.HtmlReportTitle,
+		pd.GetWorkSpaceRelativePath(workSpaceDir, args.HtmlReportDir))
+	htmlReport.History = historyEntries
+	indexPath, err := htmlReport.Write()
+	if err != nil {
+		return err
+	}
+
+	logrus.Printf("HTML coverage report written to %s\n", indexPath)
+	return nil
+}
+
+// WriteBadges writes the line, branch and overall coverage badges coloured
+// relative to the line and branch thresholds.
+func WriteBadges(report *coverage.Report, args pd.Args, badgeDir string) error {
+
+	lineBands, err := badge.GetColorBands(args.BadgeColorBands, args.MinimumLineCoverage, args.BadgeWarningMargin)
+	if err != nil {
+		return err
+	}
+	branchBands, err := badge.GetColorBands(args.BadgeColorBands, args.MinimumBranchCoverage, args.BadgeWarningMargin)
+	if err != nil {
+		return err
+	}
+
+	badges := badge.GetCoverageBadges(report, lineBands, branchBands, lineBands)
+	badgePaths, err := badge.WriteBadges(badges, badgeDir)
+	if err != nil {
+		return err
+	}
+
+	for _, badgePath := range badgePaths {
+		logrus.Printf("Coverage badge written to %s\n", badgePath)
+	}
+	return nil
+}
+
+// WriteMarkdownSummary writes the Markdown summary to the markdown path
+// and, if enabled, to the COVERAGE_SUMMARY_MARKDOWN output variable.
+func WriteMarkdownSummary(content string, args pd.Args, workSpaceDir string) error {
+
+	if args.MarkdownPath != "" {
+		markdownPath := pd.GetWorkSpaceRelativePath(workSpaceDir, args.MarkdownPath)
+		err := pd.WriteFileAsString(markdownPath, content)
+		if err != nil {
+			return err
+		}
+		logrus.Printf("Markdown coverage summary written to %s\n", markdownPath)
+	}
+
+	if args.MarkdownOutputVariable {
+		err := pd.WriteEnvVariableAsMultilineString(MarkdownOutputVariableKey, content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PostPullRequestComment creates or updates the coverage comment on the pull
+// request of the build. Builds that are not for a pull request are skipped.
+func PostPullRequestComment(ctx context.Context, markdownSummary string, args pd.Args) error {
+
+	if !args.PullRequestComment {
+		return nil
+	}
+	if args.PullRequest.Number <= 0 {
+		logrus.Printf("Not a pull request build, skipping the coverage comment\n")
+		return nil
+	}
+
+	notifier, err := scm.GetNewNotifier(GetScmConfig(args), nil)
+	if err != nil {
+		return err
+	}
+
+	err = notifier.UpsertComment(ctx, markdownSummary)
+	if err != nil {
+		return err
+	}
+
+	logrus.Printf("Coverage comment posted on pull request #%d\n", args.PullRequest.Number)
+	return nil
+}
+
+func GetScmConfig(args pd.Args) scm.Config {
+	provider := scm.GetProvider(args.ScmProvider, args.Repo.SCM, args.Repo.Link)
+
+	baseUrl := args.ScmBaseUrl
+	if baseUrl == "" {
+		baseUrl = scm.GetDefaultBaseURL(provider, args.Repo.Link)
+	}
+
+	return scm.Config{
+		Provider:    provider,
+		BaseURL:     baseUrl,
+		Token:       args.ScmToken,
+		RepoSlug:    args.Repo.Slug,
+		PullRequest: args.PullRequest.Number,
+		Marker:      args.PullRequestCommentMarker,
+		CommitSha:   args.Commit.Rev,
+	}
+}
+
+// UpdateHistory adds the run to the history store and returns the latest
+// builds of the branch, including this one.
+func UpdateHistory(summary pd.CoverageSummary, args pd.Args, workSpaceDir string) ([]history.Entry, error) {
+
+	historyPath := pd.GetWorkSpaceRelativePath(workSpaceDir, args.HistoryPath)
+	store := history.GetNewStore(historyPath)
+
+	err := store.Append(history.GetEntry(summary, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	logrus.Printf("Coverage history updated in %s\n", historyPath)
+
+	builds := args.HistoryBuilds
+	if builds <= 0 {
+		builds = DefaultHistoryBuilds
+	}
+	return store.GetLastEntries(summary.Repo, summary.Branch, builds)
+}
+
+// GetBaselineSummary reads the summary of an earlier run to compare against.
+// A missing baseline is not an error, the deltas are left out instead.
+func GetBaselineSummary(args pd.Args, workSpaceDir string) *pd.CoverageSummary {
+	if args.BaselineSummaryJsonPath == "" {
+		return nil
+	}
+
+	baseline, err := pd.ReadCoverageSummary(pd.GetWorkSpaceRelativePath(workSpaceDir, args.BaselineSummaryJsonPath))
+	if err != nil {
+		logrus.Printf("Baseline coverage summary not used: %s\n", err.Error())
+		return nil
+	}
+	return baseline
+}
+
+func getUncoveredFilesLimit(args pd.Args) int {
+	if args.UncoveredFilesLimit <= 0 {
+		return DefaultUncoveredFilesLimit
+	}
+	return args.UncoveredFilesLimit
+}
+
+// WriteCoverageCard writes the adaptive card shown in the step summary.
+func WriteCoverageCard(summary pd.CoverageSummary, historyEntries []history.Entry, args pd.Args) {
+
+	if args.Card.Path == "" {
+		return
+	}
+
+	type CardMetric struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	var metrics []CardMetric
+	for _, counterType := range coverage.AllCounterTypes {
+		if summary.Counters.Has(counterType) {
+			metrics = append(metrics, CardMetric{
+				Name:  counterType.DisplayName(),
+				Value: markdown.FormatCounter(summary.Counters.Get(counterType)),
+			})
+		}
+	}
+	for _, check := range pd.GetFailedThresholdChecks(summary.ThresholdChecks) {
+		metrics = append(metrics, CardMetric{
+			Name:  check.Metric + " threshold",
+			Value: fmt.Sprintf("%s observed, expected %s", check.FormatValue(check.ObservedValue), check.GetExpectedString()),
+		})
+	}
+	for _, check := range pd.GetWarningThresholdChecks(summary.ThresholdChecks) {
+		metrics = append(metrics, CardMetric{
+			Name: check.Metric + " threshold (warning)",
+			Value: fmt.Sprintf("%s observed, expected %s", check.FormatValue(check.ObservedValue),
+				check.GetExpectedString()),
+		})
+	}
+
+	var historyFacts []CardMetric
+	for i := len(historyEntries) - 1; i >= 0; i-- {
+		entry := historyEntries[i]
+		var values []string
+		for _, counterType := range history.GetTrendCounterTypes(historyEntries) {
+			if entry.Counters.Has(counterType) {
+				values = append(values, fmt.Sprintf("%s %.2f%%", counterType.DisplayName(),
+					entry.Counters.Get(counterType).Percentage()))
+			}
+		}
+		historyFacts = append(historyFacts, CardMetric{Name: entry.GetBuildLabel(), Value: strings.Join(values, ", ")})
+	}
+
+	status, statusColor := "Passed", "Good"
+	if !summary.Passed {
+		status, statusColor = "Thresholds not met", "Attention"
+	} else if summary.Warnings > 0 {
+		status, statusColor = fmt.Sprintf("Passed with %d warnings", summary.Warnings), "Warning"
+	}
+
+	pd.WriteCard(args.Card.Path, CardSchema, map[string]interface{}{
+		"tool":        summary.Tool,
+		"status":      status,
+		"statusColor": statusColor,
+		"metrics":     metrics,
+		"history":     historyFacts,
+	})
+}
+
+const (
+	MarkdownOutputVariableKey          = "COVERAGE_SUMMARY_MARKDOWN"
+	ThresholdWarningsOutputVariableKey = "THRESHOLD_WARNINGS"
+	ModuleCoverageOutputVariableKey    = "MODULE_COVERAGE"
+	DefaultHistoryBuilds               = 10
+	DefaultUncoveredFilesLimit         = 20
+	CardSchema                         = "https://raw.githubusercontent.com/harness-community/drone-coverage-report/main/card.json"
+)
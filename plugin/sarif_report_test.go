@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/harness-community/drone-coverage-report/plugin/export"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func ReadSarifLog(t *testing.T, sarifPath string) export.SarifLog {
+	data, err := os.ReadFile(sarifPath)
+	if err != nil {
+		t.Fatalf("Error in ReadSarifLog: %s", err.Error())
+	}
+	var sarifLog export.SarifLog
+	err = json.Unmarshal(data, &sarifLog)
+	if err != nil {
+		t.Fatalf("Error in ReadSarifLog: %s", err.Error())
+	}
+	return sarifLog
+}
+
+func TestCoberturaSarifReport(t *testing.T) {
+
+	outputDir := t.TempDir()
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.SourcePattern = "**/bad-metrics-project/src/main/java"
+	args.SarifPath = filepath.Join(outputDir, "coverage.sarif")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaSarifReport: %s", err.Error())
+	}
+
+	sarifLog := ReadSarifLog(t, args.SarifPath)
+	if sarifLog.Version != "2.1.0" || len(sarifLog.Runs) != 1 || len(sarifLog.Runs[0].Tool.Driver.Rules) != 2 {
+		t.Fatalf("Error in TestCoberturaSarifReport: unexpected sarif log %v", sarifLog)
+	}
+
+	results := sarifLog.Runs[0].Results
+	if len(results) != 3 {
+		t.Fatalf("Error in TestCoberturaSarifReport: expected 3 results, found %d", len(results))
+	}
+	location := results[0].Locations[0].PhysicalLocation
+	if results[0].RuleID != "coverage/line" || location.ArtifactLocation.URI != TestCalculatorPath ||
+		location.Region.StartLine != 9 || location.Region.EndLine != 18 ||
+		results[0].Message.Text != "Lines 9-18 are not covered by tests." {
+		t.Errorf("Error in TestCoberturaSarifReport: unexpected result %v", results[0])
+	}
+
+	args.SarifChangedLinesOnly = true
+	args.DiffFile = filepath.Join(outputDir, "changes.diff")
+	err = os.WriteFile(args.DiffFile, []byte(TestCalculatorDiff), 0644)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaSarifReport: %s", err.Error())
+	}
+
+	_, err = Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaSarifReport: %s", err.Error())
+	}
+
+	results = ReadSarifLog(t, args.SarifPath).Runs[0].Results
+	if len(results) != 1 || results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI !=
+		"src/main/java/com/example/package1/Calculator.java" {
+		t.Errorf("Error in TestCoberturaSarifReport: expected only the changed Calculator lines, found %v", results)
+	}
+}
+
+func TestJacocoXmlSarifPartialBranches(t *testing.T) {
+
+	args := GetTestJacocoXmlNewArgs(pd.EnvPluginInputArgs{})
+	args.ExecFilesPathPattern = "**/gameoflife-core/**/jacoco.xml"
+	args.SarifPath = filepath.Join(t.TempDir(), "coverage.sarif")
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestJacocoXmlSarifPartialBranches: %s", err.Error())
+	}
+
+	results := ReadSarifLog(t, args.SarifPath).Runs[0].Results
+	if len(results) != 1 || results[0].RuleID != "coverage/branch" || results[0].Level != "note" ||
+		results[0].Message.Text != "Line 24 has 1 of 2 branches covered." ||
+		results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != "com/wakaleo/gameoflife/domain/GridWriter.java" {
+		t.Errorf("Error in TestJacocoXmlSarifPartialBranches: unexpected results %v", results)
+	}
+}
@@ -0,0 +1,110 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BitbucketNotifier comments on Bitbucket Cloud pull requests.
+type BitbucketNotifier struct {
+	*apiClient
+}
+
+type bitbucketContent struct {
+	Raw string `json:"raw"`
+}
+
+type bitbucketComment struct {
+	ID      int64            `json:"id,omitempty"`
+	Content bitbucketContent `json:"content"`
+}
+
+type bitbucketCommentsPage struct {
+	Values []bitbucketComment `json:"values"`
+	Next   string             `json:"next"`
+}
+
+func (n *BitbucketNotifier) UpsertComment(ctx context.Context, body string) error {
+	comment := bitbucketComment{Content: bitbucketContent{Raw: GetCommentBody(body, n.config.Marker)}}
+
+	commentID, err := n.findComment(ctx)
+	if err != nil {
+		return err
+	}
+
+	commentsUrl := n.getCommentsUrl()
+	if commentID == 0 {
+		return n.do(ctx, http.MethodPost, commentsUrl, comment, nil)
+	}
+	return n.do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", commentsUrl, commentID), comment, nil)
+}
+
+func (n *BitbucketNotifier) getCommentsUrl() string {
+	return fmt.Sprintf("%s/repositories/%s/%s/pullrequests/%d/comments",
+		n.config.BaseURL, n.owner, n.repo, n.config.PullRequest)
+}
+
+func (n *BitbucketNotifier) findComment(ctx context.Context) (int64, error) {
+	requestUrl := fmt.Sprintf("%s?pagelen=%d", n.getCommentsUrl(), commentsPageSize)
+
+	for page := 1; page <= maxCommentPages && requestUrl != ""; page++ {
+		var commentsPage bitbucketCommentsPage
+		err := n.do(ctx, http.MethodGet, requestUrl, nil, &commentsPage)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, comment := range commentsPage.Values {
+			if strings.Contains(comment.Content.Raw, n.config.Marker) {
+				return comment.ID, nil
+			}
+		}
+		requestUrl = commentsPage.Next
+	}
+	return 0, nil
+}
+
+// BitbucketStatusPublisher publishes the status as a Bitbucket build status.
+type BitbucketStatusPublisher struct {
+	*apiClient
+}
+
+type bitbucketBuildStatus struct {
+	Key         string `json:"key"`
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+// PublishStatus links statuses without a target URL to the commit, as
+// Bitbucket requires a URL on build statuses.
+func (p *BitbucketStatusPublisher) PublishStatus(ctx context.Context, status CommitStatus) error {
+	state := "SUCCESSFUL"
+	if !status.Passed {
+		state = "FAILED"
+	}
+
+	// Build status keys are limited to 40 characters.
+	key := status.Name
+	if len(key) > 40 {
+		key = key[:40]
+	}
+
+	targetUrl := status.TargetURL
+	if targetUrl == "" {
+		targetUrl = fmt.Sprintf("%s/%s/%s/commits/%s", BitbucketWebURL, p.owner, p.repo, p.config.CommitSha)
+	}
+
+	requestUrl := fmt.Sprintf("%s/repositories/%s/%s/commit/%s/statuses/build",
+		p.config.BaseURL, p.owner, p.repo, p.config.CommitSha)
+	return p.do(ctx, http.MethodPost, requestUrl, bitbucketBuildStatus{
+		Key:         key,
+		State:       state,
+		Name:        status.Name,
+		Description: status.Description,
+		URL:         targetUrl,
+	}, nil)
+}
@@ -0,0 +1,106 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// GithubChecksPublisher publishes the status as a completed GitHub check run.
+// Creating check runs needs a GitHub App installation token.
+type GithubChecksPublisher struct {
+	*apiClient
+}
+
+type githubCheckRun struct {
+	ID         int64             `json:"id,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	HeadSha    string            `json:"head_sha,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	Conclusion string            `json:"conclusion,omitempty"`
+	DetailsURL string            `json:"details_url,omitempty"`
+	Output     githubCheckOutput `json:"output"`
+}
+
+type githubCheckOutput struct {
+	Title       string                  `json:"title"`
+	Summary     string                  `json:"summary"`
+	Annotations []githubCheckAnnotation `json:"annotations,omitempty"`
+}
+
+type githubCheckAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+}
+
+func (p *GithubChecksPublisher) PublishStatus(ctx context.Context, status CommitStatus) error {
+
+	conclusion := "success"
+	if !status.Passed {
+		conclusion = "failure"
+	}
+
+	summary := status.Summary
+	if summary == "" {
+		summary = status.Description
+	}
+
+	// The checks API accepts at most 50 annotations per request, the rest
+	// are added by updating the check run.
+	var batches [][]githubCheckAnnotation
+	for start := 0; start < len(status.Annotations); start += maxCheckAnnotations {
+		end := start + maxCheckAnnotations
+		if end > len(status.Annotations) {
+			end = len(status.Annotations)
+		}
+		var batch []githubCheckAnnotation
+		for _, annotation := range status.Annotations[start:end] {
+			batch = append(batch, githubCheckAnnotation{
+				Path:            annotation.Path,
+				StartLine:       annotation.StartLine,
+				EndLine:         annotation.EndLine,
+				AnnotationLevel: "warning",
+				Title:           annotation.Title,
+				Message:         annotation.Message,
+			})
+		}
+		batches = append(batches, batch)
+	}
+
+	checkRun := githubCheckRun{
+		Name:       status.Name,
+		HeadSha:    p.config.CommitSha,
+		Status:     "completed",
+		Conclusion: conclusion,
+		DetailsURL: status.TargetURL,
+		Output:     githubCheckOutput{Title: status.Description, Summary: summary},
+	}
+	if len(batches) > 0 {
+		checkRun.Output.Annotations = batches[0]
+	}
+
+	var created githubCheckRun
+	err := p.do(ctx, http.MethodPost, fmt.Sprintf("%s/repos/%s/%s/check-runs", p.config.BaseURL, p.owner, p.repo),
+		checkRun, &created)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i < len(batches); i++ {
+		update := githubCheckRun{
+			Output: githubCheckOutput{Title: status.Description, Summary: summary, Annotations: batches[i]},
+		}
+		err = p.do(ctx, http.MethodPatch,
+			fmt.Sprintf("%s/repos/%s/%s/check-runs/%d", p.config.BaseURL, p.owner, p.repo, created.ID), update, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const maxCheckAnnotations = 50
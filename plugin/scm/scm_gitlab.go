@@ -0,0 +1,92 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitlabNotifier comments on merge requests through the notes API.
+type GitlabNotifier struct {
+	*apiClient
+}
+
+type gitlabNote struct {
+	ID     int64  `json:"id,omitempty"`
+	Body   string `json:"body"`
+	System bool   `json:"system,omitempty"`
+}
+
+func (n *GitlabNotifier) UpsertComment(ctx context.Context, body string) error {
+	body = GetCommentBody(body, n.config.Marker)
+
+	noteID, err := n.findNote(ctx)
+	if err != nil {
+		return err
+	}
+
+	notesUrl := fmt.Sprintf("%s/notes", n.getMergeRequestUrl())
+	if noteID == 0 {
+		return n.do(ctx, http.MethodPost, notesUrl, gitlabNote{Body: body}, nil)
+	}
+	return n.do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", notesUrl, noteID), gitlabNote{Body: body}, nil)
+}
+
+func (c *apiClient) getGitlabProjectUrl() string {
+	return fmt.Sprintf("%s/projects/%s", c.config.BaseURL, url.PathEscape(c.config.RepoSlug))
+}
+
+func (n *GitlabNotifier) getMergeRequestUrl() string {
+	return fmt.Sprintf("%s/merge_requests/%d", n.getGitlabProjectUrl(), n.config.PullRequest)
+}
+
+func (n *GitlabNotifier) findNote(ctx context.Context) (int64, error) {
+	for page := 1; page <= maxCommentPages; page++ {
+		requestUrl := fmt.Sprintf("%s/notes?per_page=%d&page=%d", n.getMergeRequestUrl(), commentsPageSize, page)
+
+		var notes []gitlabNote
+		err := n.do(ctx, http.MethodGet, requestUrl, nil, &notes)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, note := range notes {
+			if !note.System && strings.Contains(note.Body, n.config.Marker) {
+				return note.ID, nil
+			}
+		}
+		if len(notes) == 0 {
+			break
+		}
+	}
+	return 0, nil
+}
+
+// GitlabStatusPublisher publishes the status as a GitLab commit status.
+type GitlabStatusPublisher struct {
+	*apiClient
+}
+
+type gitlabCommitStatus struct {
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
+func (p *GitlabStatusPublisher) PublishStatus(ctx context.Context, status CommitStatus) error {
+	state := "success"
+	if !status.Passed {
+		state = "failed"
+	}
+
+	requestUrl := fmt.Sprintf("%s/statuses/%s", p.getGitlabProjectUrl(), p.config.CommitSha)
+	return p.do(ctx, http.MethodPost, requestUrl, gitlabCommitStatus{
+		State:       state,
+		Name:        status.Name,
+		Description: status.Description,
+		TargetURL:   status.TargetURL,
+	}, nil)
+}
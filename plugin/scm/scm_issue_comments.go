@@ -0,0 +1,60 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// IssueCommentNotifier comments through the issue comments API shared by
+// GitHub and Gitea, where pull requests are issues.
+type IssueCommentNotifier struct {
+	*apiClient
+}
+
+type issueComment struct {
+	ID   int64  `json:"id,omitempty"`
+	Body string `json:"body"`
+}
+
+func (n *IssueCommentNotifier) UpsertComment(ctx context.Context, body string) error {
+	body = GetCommentBody(body, n.config.Marker)
+
+	commentID, err := n.findComment(ctx)
+	if err != nil {
+		return err
+	}
+
+	if commentID == 0 {
+		requestUrl := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments",
+			n.config.BaseURL, n.owner, n.repo, n.config.PullRequest)
+		return n.do(ctx, http.MethodPost, requestUrl, issueComment{Body: body}, nil)
+	}
+
+	requestUrl := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%d", n.config.BaseURL, n.owner, n.repo, commentID)
+	return n.do(ctx, http.MethodPatch, requestUrl, issueComment{Body: body}, nil)
+}
+
+func (n *IssueCommentNotifier) findComment(ctx context.Context) (int64, error) {
+	for page := 1; page <= maxCommentPages; page++ {
+		requestUrl := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments?per_page=%d&limit=%d&page=%d",
+			n.config.BaseURL, n.owner, n.repo, n.config.PullRequest, commentsPageSize, commentsPageSize, page)
+
+		var comments []issueComment
+		err := n.do(ctx, http.MethodGet, requestUrl, nil, &comments)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, n.config.Marker) {
+				return comment.ID, nil
+			}
+		}
+		if len(comments) == 0 {
+			break
+		}
+	}
+	return 0, nil
+}
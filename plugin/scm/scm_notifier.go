@@ -0,0 +1,211 @@
+package scm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Notifier publishes the coverage summary as a single pull request comment.
+// The comment is found again by its marker so later builds update it in
+// place instead of adding new comments.
+type Notifier interface {
+	UpsertComment(ctx context.Context, body string) error
+}
+
+type Config struct {
+	Provider    string
+	BaseURL     string
+	Token       string
+	RepoSlug    string
+	PullRequest int
+	Marker      string
+	CommitSha   string
+}
+
+// GetNewNotifier returns the notifier for the configured provider.
+func GetNewNotifier(config Config, httpClient *http.Client) (Notifier, error) {
+
+	if config.PullRequest <= 0 {
+		return nil, fmt.Errorf("no pull request number")
+	}
+
+	client, err := getNewApiClient(config, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Provider {
+	case GithubProvider, GiteaProvider:
+		return &IssueCommentNotifier{apiClient: client}, nil
+	case GitlabProvider:
+		return &GitlabNotifier{apiClient: client}, nil
+	case BitbucketProvider:
+		return &BitbucketNotifier{apiClient: client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scm provider: %s", config.Provider)
+	}
+}
+
+// GetProvider returns the explicitly configured provider, or infers it from
+// the DRONE_REPO_SCM value or the host of the repository link.
+func GetProvider(provider, repoScm, repoLink string) string {
+	for _, candidate := range []string{provider, repoScm} {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		switch candidate {
+		case GithubProvider, GitlabProvider, BitbucketProvider, GiteaProvider:
+			return candidate
+		}
+	}
+
+	link, err := url.Parse(repoLink)
+	if err != nil {
+		return ""
+	}
+	switch host := strings.ToLower(link.Host); {
+	case strings.Contains(host, "github"):
+		return GithubProvider
+	case strings.Contains(host, "gitlab"):
+		return GitlabProvider
+	case strings.Contains(host, "bitbucket"):
+		return BitbucketProvider
+	case strings.Contains(host, "gitea"):
+		return GiteaProvider
+	}
+	return ""
+}
+
+// GetDefaultBaseURL returns the API base URL of the hosted provider, or of a
+// self-hosted instance serving the repository link.
+func GetDefaultBaseURL(provider, repoLink string) string {
+	link, err := url.Parse(repoLink)
+	selfHosted := err == nil && link.Host != "" &&
+		!strings.HasSuffix(link.Host, "github.com") &&
+		!strings.HasSuffix(link.Host, "gitlab.com") &&
+		!strings.HasSuffix(link.Host, "bitbucket.org")
+
+	switch provider {
+	case GithubProvider:
+		if selfHosted {
+			return link.Scheme + "://" + link.Host + "/api/v3"
+		}
+		return "https://api.github.com"
+	case GitlabProvider:
+		if selfHosted {
+			return link.Scheme + "://" + link.Host + "/api/v4"
+		}
+		return "https://gitlab.com/api/v4"
+	case BitbucketProvider:
+		return "https://api.bitbucket.org/2.0"
+	case GiteaProvider:
+		if selfHosted {
+			return link.Scheme + "://" + link.Host + "/api/v1"
+		}
+	}
+	return ""
+}
+
+// GetCommentBody appends the marker used to find the comment again.
+func GetCommentBody(body, marker string) string {
+	return strings.TrimRight(body, "\n") + "\n\n" + marker + "\n"
+}
+
+type apiClient struct {
+	config     Config
+	httpClient *http.Client
+	owner      string
+	repo       string
+}
+
+func getNewApiClient(config Config, httpClient *http.Client) (*apiClient, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("no api base url for scm provider %s", config.Provider)
+	}
+	if config.Token == "" {
+		return nil, fmt.Errorf("no token for scm provider %s", config.Provider)
+	}
+	if config.Marker == "" {
+		config.Marker = DefaultMarker
+	}
+
+	parts := strings.SplitN(config.RepoSlug, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid repository slug: %q", config.RepoSlug)
+	}
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	return &apiClient{config: config, httpClient: httpClient, owner: parts[0], repo: parts[1]}, nil
+}
+
+func (c *apiClient) setAuthHeader(req *http.Request) {
+	switch c.config.Provider {
+	case GitlabProvider:
+		req.Header.Set("PRIVATE-TOKEN", c.config.Token)
+	case GiteaProvider:
+		req.Header.Set("Authorization", "token "+c.config.Token)
+	default:
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+}
+
+// do sends the request with an optional JSON body and decodes the JSON
+// response into out when given.
+func (c *apiClient) do(ctx context.Context, method, requestUrl string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.setAuthHeader(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s failed with status %d: %s", method, requestUrl, resp.StatusCode,
+			strings.TrimSpace(string(respBody)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+const (
+	GithubProvider    = "github"
+	GitlabProvider    = "gitlab"
+	BitbucketProvider = "bitbucket"
+	GiteaProvider     = "gitea"
+	BitbucketWebURL   = "https://bitbucket.org"
+	DefaultMarker     = "<!-- drone-coverage-report -->"
+	DefaultTimeout    = 30 * time.Second
+	commentsPageSize  = 100
+	maxCommentPages   = 20
+)
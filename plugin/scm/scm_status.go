@@ -0,0 +1,59 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// StatusPublisher publishes a named coverage status on a commit.
+type StatusPublisher interface {
+	PublishStatus(ctx context.Context, status CommitStatus) error
+}
+
+// CommitStatus is a named pass or fail result shown on a commit, such as
+// "coverage/line" with the description "78.40% (+1.20%)".
+type CommitStatus struct {
+	Name        string
+	Description string
+	Summary     string
+	TargetURL   string
+	Passed      bool
+	Annotations []Annotation
+}
+
+// Annotation marks a range of lines of a repository file. Only providers
+// with a checks API show annotations, the others ignore them.
+type Annotation struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Title     string
+	Message   string
+}
+
+// GetNewStatusPublisher returns the status publisher for the configured
+// provider: check runs on GitHub, commit statuses on GitLab and build
+// statuses on Bitbucket.
+func GetNewStatusPublisher(config Config, httpClient *http.Client) (StatusPublisher, error) {
+
+	if config.CommitSha == "" {
+		return nil, fmt.Errorf("no commit sha")
+	}
+
+	client, err := getNewApiClient(config, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Provider {
+	case GithubProvider:
+		return &GithubChecksPublisher{apiClient: client}, nil
+	case GitlabProvider:
+		return &GitlabStatusPublisher{apiClient: client}, nil
+	case BitbucketProvider:
+		return &BitbucketStatusPublisher{apiClient: client}, nil
+	default:
+		return nil, fmt.Errorf("commit statuses are not supported for scm provider: %s", config.Provider)
+	}
+}
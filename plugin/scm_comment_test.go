@@ -0,0 +1,223 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"github.com/harness-community/drone-coverage-report/plugin/scm"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// FakeScmServer is a minimal in memory stand-in for the comment APIs of the
+// supported SCM providers.
+type FakeScmServer struct {
+	mutex      sync.Mutex
+	comments   map[int64]string
+	nextID     int64
+	authHeader string
+	// bodyWithID is set when a created or updated comment sends an id
+	bodyWithID bool
+}
+
+func GetNewFakeScmServer() *FakeScmServer {
+	return &FakeScmServer{comments: map[int64]string{}, nextID: 1}
+}
+
+func (f *FakeScmServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r.Header.Get("PRIVATE-TOKEN") != "" {
+		f.authHeader = "PRIVATE-TOKEN " + r.Header.Get("PRIVATE-TOKEN")
+	} else {
+		f.authHeader = r.Header.Get("Authorization")
+	}
+
+	var request struct {
+		ID      *int64 `json:"id"`
+		Body    string `json:"body"`
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&request)
+	}
+	body := request.Body + request.Content.Raw
+	if r.Method != http.MethodGet && request.ID != nil {
+		f.bodyWithID = true
+	}
+
+	lastSegment := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	commentID, _ := strconv.ParseInt(lastSegment, 10, 64)
+
+	switch r.Method {
+	case http.MethodGet:
+		if r.URL.Query().Get("page") != "" && r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte("[]"))
+			return
+		}
+		var comments []map[string]interface{}
+		for id, commentBody := range f.comments {
+			comments = append(comments, map[string]interface{}{
+				"id": id, "body": commentBody, "content": map[string]string{"raw": commentBody},
+			})
+		}
+		if strings.Contains(r.URL.Path, "/pullrequests/") {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"values": comments})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(comments)
+	case http.MethodPost:
+		f.comments[f.nextID] = body
+		f.nextID++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("{}"))
+	case http.MethodPatch, http.MethodPut:
+		if _, ok := f.comments[commentID]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		f.comments[commentID] = body
+		_, _ = w.Write([]byte("{}"))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestCoberturaPullRequestComment(t *testing.T) {
+
+	fakeScmServer := GetNewFakeScmServer()
+	server := httptest.NewServer(fakeScmServer)
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{MinimumLineCoverage: 5.0})
+		args.PluginFailOnThreshold = false
+		args.Repo.Slug = "octocat/hello-world"
+		args.Repo.Link = "https://github.com/octocat/hello-world"
+		args.PullRequest.Number = 7
+		args.PullRequestComment = true
+		args.ScmBaseUrl = server.URL
+		args.ScmToken = "test-token"
+
+		_, err := Exec(context.TODO(), args)
+		if err != nil {
+			t.Fatalf("Error in TestCoberturaPullRequestComment: %s", err.Error())
+		}
+	}
+
+	if len(fakeScmServer.comments) != 1 {
+		t.Fatalf("Error in TestCoberturaPullRequestComment: expected 1 comment, found %d",
+			len(fakeScmServer.comments))
+	}
+	comment := fakeScmServer.comments[1]
+	if !strings.Contains(comment, "| Line | 11.76% (2/17) |") || !strings.Contains(comment, scm.DefaultMarker) {
+		t.Errorf("Error in TestCoberturaPullRequestComment: unexpected comment %s", comment)
+	}
+	if fakeScmServer.authHeader != "Bearer test-token" {
+		t.Errorf("Error in TestCoberturaPullRequestComment: unexpected auth header %s", fakeScmServer.authHeader)
+	}
+}
+
+func TestPullRequestCommentSkippedWithoutPullRequest(t *testing.T) {
+
+	fakeScmServer := GetNewFakeScmServer()
+	server := httptest.NewServer(fakeScmServer)
+	defer server.Close()
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{})
+	args.PluginFailOnThreshold = false
+	args.Repo.Slug = "octocat/hello-world"
+	args.PullRequestComment = true
+	args.ScmProvider = scm.GithubProvider
+	args.ScmBaseUrl = server.URL
+	args.ScmToken = "test-token"
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestPullRequestCommentSkippedWithoutPullRequest: %s", err.Error())
+	}
+	if len(fakeScmServer.comments) != 0 {
+		t.Errorf("Error in TestPullRequestCommentSkippedWithoutPullRequest: comment posted outside a pull request")
+	}
+}
+
+func TestScmNotifiersUpsertComment(t *testing.T) {
+
+	testCases := []struct {
+		provider   string
+		authHeader string
+	}{
+		{scm.GithubProvider, "Bearer test-token"},
+		{scm.GitlabProvider, "PRIVATE-TOKEN test-token"},
+		{scm.BitbucketProvider, "Bearer test-token"},
+		{scm.GiteaProvider, "token test-token"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.provider, func(t *testing.T) {
+			fakeScmServer := GetNewFakeScmServer()
+			server := httptest.NewServer(fakeScmServer)
+			defer server.Close()
+
+			notifier, err := scm.GetNewNotifier(scm.Config{
+				Provider:    testCase.provider,
+				BaseURL:     server.URL,
+				Token:       "test-token",
+				RepoSlug:    "group/project",
+				PullRequest: 3,
+			}, nil)
+			if err != nil {
+				t.Fatalf("Error in TestScmNotifiersUpsertComment: %s", err.Error())
+			}
+
+			for _, body := range []string{"first", "second"} {
+				err = notifier.UpsertComment(context.TODO(), body)
+				if err != nil {
+					t.Fatalf("Error in TestScmNotifiersUpsertComment: %s", err.Error())
+				}
+			}
+
+			if len(fakeScmServer.comments) != 1 || !strings.HasPrefix(fakeScmServer.comments[1], "second") {
+				t.Errorf("Error in TestScmNotifiersUpsertComment: expected one updated comment, found %v",
+					fakeScmServer.comments)
+			}
+			if fakeScmServer.bodyWithID {
+				t.Errorf("Error in TestScmNotifiersUpsertComment: comment id sent in the request body")
+			}
+			if fakeScmServer.authHeader != testCase.authHeader {
+				t.Errorf("Error in TestScmNotifiersUpsertComment: unexpected auth header %s", fakeScmServer.authHeader)
+			}
+		})
+	}
+}
+
+func TestGetScmProviderAndBaseUrl(t *testing.T) {
+
+	testCases := []struct {
+		repoScm, repoLink, provider, baseUrl string
+	}{
+		{"", "https://github.com/o/r", scm.GithubProvider, "https://api.github.com"},
+		{"", "https://gitlab.com/o/r", scm.GitlabProvider, "https://gitlab.com/api/v4"},
+		{"", "https://bitbucket.org/o/r", scm.BitbucketProvider, "https://api.bitbucket.org/2.0"},
+		{"gitea", "https://git.example.com/o/r", scm.GiteaProvider, "https://git.example.com/api/v1"},
+		{"github", "https://ghe.example.com/o/r", scm.GithubProvider, "https://ghe.example.com/api/v3"},
+	}
+
+	for _, testCase := range testCases {
+		provider := scm.GetProvider("", testCase.repoScm, testCase.repoLink)
+		if provider != testCase.provider {
+			t.Errorf("Error in TestGetScmProviderAndBaseUrl: expected %s, found %s", testCase.provider, provider)
+		}
+		baseUrl := scm.GetDefaultBaseURL(provider, testCase.repoLink)
+		if baseUrl != testCase.baseUrl {
+			t.Errorf("Error in TestGetScmProviderAndBaseUrl: expected %s, found %s", testCase.baseUrl, baseUrl)
+		}
+	}
+}
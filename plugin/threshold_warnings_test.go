@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func GetTestThresholdWarningsArgs(summaryPath string) pd.Args {
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{
+		MinimumClassCoverage:         40,
+		MinimumLineCoverage:          5.0,
+		MinimumPackageCoverage:       30,
+		MinimumFileCoverage:          30.0,
+		MinimumLOC:                   15,
+		MinimumComplexityCoverage:    10,
+		MaxComplexityDensityCoverage: 0.50,
+	})
+	args.SummaryJsonPath = summaryPath
+	return args
+}
+
+func TestThresholdWarnings(t *testing.T) {
+
+	summaryPath := filepath.Join(t.TempDir(), "summary.json")
+	outputPath := filepath.Join(t.TempDir(), "drone-output")
+	t.Setenv("DRONE_OUTPUT", outputPath)
+
+	args := GetTestThresholdWarningsArgs(summaryPath)
+	args.ThresholdWarnings = "branch, Class"
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestThresholdWarnings: class threshold should only warn: %s", err.Error())
+	}
+
+	summary, err := pd.ReadCoverageSummary(summaryPath)
+	if err != nil {
+		t.Fatalf("Error in TestThresholdWarnings: %s", err.Error())
+	}
+	warnings := pd.GetWarningThresholdChecks(summary.ThresholdChecks)
+	if !summary.Passed || summary.Warnings != 1 || len(warnings) != 1 || warnings[0].Metric != "Class" {
+		t.Errorf("Error in TestThresholdWarnings: expected a class warning, got %+v", summary.ThresholdChecks)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Error in TestThresholdWarnings: %s", err.Error())
+	}
+	for _, expected := range []string{ThresholdWarningsOutputVariableKey + "=1",
+		pd.CoverageResultOutputVariable + "=" + pd.ResultPassed} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("Error in TestThresholdWarnings: %q not found in output %q", expected, output)
+		}
+	}
+
+	args.ThresholdWarnings = "line"
+	_, err = Exec(context.TODO(), args)
+	if !errors.Is(err, pd.ErrThreshold) {
+		t.Errorf("Error in TestThresholdWarnings: class threshold should fail, got %v", err)
+	}
+}
+
+func TestThresholdSoftFail(t *testing.T) {
+
+	summaryPath := filepath.Join(t.TempDir(), "summary.json")
+	args := GetTestThresholdWarningsArgs(summaryPath)
+	args.MinimumLineCoverage = 99
+	args.SoftFail = true
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestThresholdSoftFail: %s", err.Error())
+	}
+
+	summary, err := pd.ReadCoverageSummary(summaryPath)
+	if err != nil {
+		t.Fatalf("Error in TestThresholdSoftFail: %s", err.Error())
+	}
+	if !summary.Passed || summary.Warnings != 2 {
+		t.Errorf("Error in TestThresholdSoftFail: expected 2 warnings, got %d", summary.Warnings)
+	}
+}
+
+func TestThresholdWarningsValidation(t *testing.T) {
+
+	args := GetTestThresholdWarningsArgs("")
+	args.ThresholdWarnings = "line,coverage"
+	_, err := Exec(context.TODO(), args)
+	if err == nil || !strings.Contains(err.Error(), "unknown metric coverage") {
+		t.Errorf("Error in TestThresholdWarningsValidation: expected an unknown metric error, got %v", err)
+	}
+}
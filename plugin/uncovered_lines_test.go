@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"context"
+	"github.com/harness-community/drone-coverage-report/plugin/coverage"
+	pd "github.com/harness-community/drone-coverage-report/plugin/plugin_defs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoberturaUncoveredLines(t *testing.T) {
+
+	logs := CaptureLogs(t, "info", pd.TextLogFormat)
+
+	args := GetTestCoberturaNewArgs(pd.EnvPluginInputArgs{MinimumLineCoverage: 40.0})
+	args.PluginFailOnThreshold = false
+	args.SummaryJsonPath = filepath.Join(t.TempDir(), "summary.json")
+	args.UncoveredFilesLimit = 1
+
+	_, err := Exec(context.TODO(), args)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaUncoveredLines: %s", err.Error())
+	}
+
+	summary, err := pd.ReadCoverageSummary(args.SummaryJsonPath)
+	if err != nil {
+		t.Fatalf("Error in TestCoberturaUncoveredLines: %s", err.Error())
+	}
+	if len(summary.UncoveredFiles) != 3 {
+		t.Fatalf("Error in TestCoberturaUncoveredLines: expected 3 files with uncovered lines, got %+v",
+			summary.UncoveredFiles)
+	}
+	divider := summary.UncoveredFiles[2]
+	if !strings.HasSuffix(divider.Path, "com/example/package3/Divider.java") || divider.MissedLines != 6 ||
+		len(divider.UncoveredLines) != 1 || divider.UncoveredLines[0] != (coverage.LineRange{Start: 3, End: 10}) {
+		t.Errorf("Error in TestCoberturaUncoveredLines: unexpected uncovered lines %+v", divider)
+	}
+
+	for _, expected := range []string{"Uncovered lines:\n", "Divider.java: 3-10\n", "  ... and 2 more files\n"} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("Error in TestCoberturaUncoveredLines: %q not logged in\n%s", expected, logs.String())
+		}
+	}
+}
+
+func TestGetUncoveredFiles(t *testing.T) {
+
+	report := &coverage.Report{Packages: []*coverage.Package{{
+		Name: "com.example",
+		Files: []*coverage.File{
+			{Path: "com/example/App.java", Lines: []coverage.Line{
+				{Number: 3, Hits: 1}, {Number: 4}, {Number: 6}, {Number: 8, Hits: 2, Branches: 4, CoveredBranches: 3},
+				{Number: 9}, {Number: 12, Hits: 1, Branches: 2, CoveredBranches: 2},
+			}},
+			{Path: "com/example/Covered.java", Lines: []coverage.Line{{Number: 1, Hits: 1}}},
+		},
+	}}}
+
+	files := pd.GetUncoveredFiles(report)
+	if len(files) != 1 {
+		t.Fatalf("Error in TestGetUncoveredFiles: expected only App.java, got %+v", files)
+	}
+	expected := "com/example/App.java: 4-6, 9; partial branches 8 (3/4)"
+	if files[0].String() != expected || files[0].MissedLines != 3 {
+		t.Errorf("Error in TestGetUncoveredFiles: got %q with %d missed lines, expected %q", files[0].String(),
+			files[0].MissedLines, expected)
+	}
+}